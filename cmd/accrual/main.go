@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/fragpit/gophermart/internal/accrual/api/router"
+	"github.com/fragpit/gophermart/internal/accrual/config"
+	"github.com/fragpit/gophermart/internal/accrual/service"
+	"github.com/fragpit/gophermart/internal/accrual/storage/postgresql"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(
+		context.Background(),
+		syscall.SIGTERM,
+		syscall.SIGINT,
+	)
+	defer cancel()
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		slog.Error("failed to initialize config", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	var logLevel slog.Level
+	switch strings.ToUpper(cfg.LogLevel) {
+	case slog.LevelDebug.String():
+		logLevel = slog.LevelDebug
+	case slog.LevelWarn.String():
+		logLevel = slog.LevelWarn
+	case slog.LevelError.String():
+		logLevel = slog.LevelError
+	default:
+		logLevel = slog.LevelInfo
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: logLevel,
+	}))
+	slog.SetDefault(logger)
+
+	if cfg.LogLevel == "debug" {
+		slog.Debug("running with config")
+		fmt.Println(cfg.String())
+	}
+
+	slog.Info("starting accrual")
+
+	st, err := postgresql.NewStorage(ctx, cfg.DatabaseURI)
+	if err != nil {
+		slog.Error("failed to initialize storage", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	router := router.NewRouter(router.Deps{
+		RateLimit:      cfg.RateLimit,
+		AccrualService: service.NewAccrualService(st.Rewards, st.Orders),
+	})
+	processor := service.NewProcessor(cfg.ProcessInterval, st.Rewards, st.Orders)
+
+	wg := &sync.WaitGroup{}
+	var exitCode int32
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		slog.Info("starting api", slog.String("address", cfg.RunAddress))
+		if err := router.Run(ctx, cfg.RunAddress); err != nil {
+			slog.Error("api failed", slog.Any("error", err))
+			atomic.StoreInt32(&exitCode, 1)
+			cancel()
+			return
+		}
+		slog.Info("api shut down gracefully")
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		slog.Info(
+			"starting processor",
+			slog.Duration("interval", cfg.ProcessInterval),
+		)
+		if err := processor.Run(ctx); err != nil {
+			slog.Error("processor failed", slog.Any("error", err))
+			atomic.StoreInt32(&exitCode, 1)
+			cancel()
+			return
+		}
+		slog.Info("processor shut down gracefully")
+	}()
+
+	wg.Wait()
+
+	ec := int(atomic.LoadInt32(&exitCode))
+	if ec != 0 {
+		slog.Error("accrual failed", slog.Int("exit_code", ec))
+		os.Exit(ec)
+	}
+
+	slog.Info("accrual shut down successfully")
+}
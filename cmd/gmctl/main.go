@@ -0,0 +1,246 @@
+// gmctl утилита обслуживания gophermart: просмотр и возврат в очередь
+// заказов, отложенных коллектором accrual для ручного разбора, просмотр и
+// снятие блокировок входа, назначение ролей.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/fragpit/gophermart/internal/storage/postgresql"
+)
+
+const usage = `usage: gmctl [-d database_uri] <command> [args]
+
+commands:
+  parked                   list orders parked for manual review
+  requeue <number>...      return parked orders to the accrual poll queue
+  requeue -all             return all parked orders to the queue
+  lockouts [-all]          list active (or all) login lockouts
+  unlock <login>           unlock login after failed attempts
+  unlock -ip <address>     unlock client ip after failed attempts
+  unlock -mfa <login>      unlock two-factor code checks after failed attempts
+  role <login> [role]...   set user roles (admin, support), none to clear
+`
+
+func getenvOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func main() {
+	databaseURI := flag.String(
+		"d",
+		getenvOr("DATABASE_URI", ""),
+		"database connection string",
+	)
+	flag.Usage = func() {
+		_, _ = fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *databaseURI == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(
+		context.Background(),
+		syscall.SIGTERM,
+		syscall.SIGINT,
+	)
+	defer cancel()
+
+	st, err := postgresql.NewStorage(ctx, *databaseURI)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize storage: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(ctx, st, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, st *postgresql.Repositories, args []string) error {
+	switch args[0] {
+	case "parked":
+		return listParked(ctx, st.Parked)
+	case "requeue":
+		return requeue(ctx, st.Parked, args[1:])
+	case "lockouts":
+		return listLockouts(ctx, st.Throttle, args[1:])
+	case "unlock":
+		return unlock(ctx, st.Throttle, args[1:])
+	case "role":
+		return setRoles(ctx, st.Users, args[1:])
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func listParked(ctx context.Context, repo model.ParkedOrdersRepository) error {
+	orders, err := repo.GetParkedOrders(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(
+		tw,
+		"NUMBER\tUSER\tSTATUS\tATTEMPTS\tFAILURES\tPARKED AT\tREASON\tLAST ERROR",
+	)
+	for _, o := range orders {
+		_, _ = fmt.Fprintf(
+			tw,
+			"%s\t%d\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			o.Number,
+			o.UserID,
+			o.Status,
+			o.Attempts,
+			o.Failures,
+			o.ParkedAt.Format(time.RFC3339),
+			o.ParkReason,
+			o.LastError,
+		)
+	}
+	return tw.Flush()
+}
+
+func requeue(
+	ctx context.Context,
+	repo model.ParkedOrdersRepository,
+	args []string,
+) error {
+	if len(args) == 0 {
+		return errors.New("requeue: order number or -all required")
+	}
+
+	if len(args) == 1 && args[0] == "-all" {
+		n, err := repo.RequeueAll(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("requeued %d orders\n", n)
+		return nil
+	}
+
+	var failed bool
+	for _, number := range args {
+		if err := repo.RequeueOrder(ctx, number); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", number, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: requeued\n", number)
+	}
+	if failed {
+		return errors.New("some orders were not requeued")
+	}
+	return nil
+}
+
+func listLockouts(
+	ctx context.Context,
+	repo model.LoginThrottleRepository,
+	args []string,
+) error {
+	all := len(args) == 1 && args[0] == "-all"
+	if len(args) > 0 && !all {
+		return errors.New("lockouts: only -all flag is supported")
+	}
+
+	lockouts, err := repo.GetLockouts(ctx, !all)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(
+		tw,
+		"ID\tSCOPE\tSUBJECT\tFAILURES\tLOCKED AT\tLOCKED UNTIL\tUNLOCKED AT",
+	)
+	for _, l := range lockouts {
+		unlockedAt := "-"
+		if l.UnlockedAt != nil {
+			unlockedAt = l.UnlockedAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(
+			tw,
+			"%d\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			l.ID,
+			l.Scope,
+			l.Subject,
+			l.Failures,
+			l.CreatedAt.Format(time.RFC3339),
+			l.LockedUntil.Format(time.RFC3339),
+			unlockedAt,
+		)
+	}
+	return tw.Flush()
+}
+
+func unlock(
+	ctx context.Context,
+	repo model.LoginThrottleRepository,
+	args []string,
+) error {
+	key := model.LoginThrottleKey{Scope: model.ThrottleScopeLogin}
+	switch {
+	case len(args) == 1 && args[0] != "-ip" && args[0] != "-mfa":
+		key.Subject = args[0]
+	case len(args) == 2 && args[0] == "-ip":
+		key.Scope, key.Subject = model.ThrottleScopeIP, args[1]
+	case len(args) == 2 && args[0] == "-mfa":
+		key.Scope, key.Subject = model.ThrottleScopeMFA, args[1]
+	default:
+		return errors.New("unlock: login, -ip address or -mfa login required")
+	}
+
+	ok, err := repo.UnlockLogin(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Printf("%s: not locked, failure counters reset\n", key)
+		return nil
+	}
+	fmt.Printf("%s: unlocked\n", key)
+	return nil
+}
+
+func setRoles(
+	ctx context.Context,
+	repo model.UsersRepository,
+	args []string,
+) error {
+	if len(args) == 0 {
+		return errors.New("role: login required")
+	}
+
+	login, roles := args[0], args[1:]
+	for _, r := range roles {
+		if !model.IsValidRole(r) {
+			return fmt.Errorf("role: unknown role %q", r)
+		}
+	}
+
+	if err := repo.SetRoles(ctx, login, roles); err != nil {
+		return err
+	}
+	fmt.Printf("%s: roles set to %v, sessions revoked\n", login, roles)
+	return nil
+}
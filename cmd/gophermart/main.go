@@ -10,16 +10,22 @@ import (
 	"sync"
 	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/fragpit/gophermart/internal/api/router"
 	"github.com/fragpit/gophermart/internal/config"
 	collector "github.com/fragpit/gophermart/internal/service/accrual-collector"
+	"github.com/fragpit/gophermart/internal/service/admin"
 	"github.com/fragpit/gophermart/internal/service/auth"
 	"github.com/fragpit/gophermart/internal/service/balance"
 	"github.com/fragpit/gophermart/internal/service/healthcheck"
+	"github.com/fragpit/gophermart/internal/service/ledger"
 	"github.com/fragpit/gophermart/internal/service/orders"
 	"github.com/fragpit/gophermart/internal/service/withdrawals"
 	"github.com/fragpit/gophermart/internal/storage/postgresql"
+	"github.com/fragpit/gophermart/internal/telemetry"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 func main() {
@@ -61,13 +67,73 @@ func main() {
 
 	slog.Info("starting app")
 
+	shutdownTracing, err := telemetry.Setup(
+		ctx,
+		"gophermart",
+		cfg.TraceExporter,
+		cfg.OTLPEndpoint,
+	)
+	if err != nil {
+		slog.Error("failed to initialize tracing", slog.Any("error", err))
+		os.Exit(1)
+	}
+
 	pgStorage, err := postgresql.NewStorage(ctx, cfg.DatabaseURI)
 	if err != nil {
 		slog.Error("failed to initialize storage", slog.Any("error", err))
 		os.Exit(1)
 	}
 
-	routerDeps := buildRouterDeps(cfg, pgStorage)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		pgStorage.PoolStats,
+	)
+
+	provider, err := buildAccrualProvider(cfg)
+	if err != nil {
+		slog.Error("failed to initialize accrual provider", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	collector := collector.NewCollector(
+		provider,
+		cfg.AccrualPollInterval,
+		pgStorage.Collector,
+		collector.WithPollPolicy(collector.PollPolicy{
+			BaseDelay:   cfg.AccrualBackoffBase,
+			MaxDelay:    cfg.AccrualBackoffMax,
+			MaxAttempts: cfg.AccrualMaxAttempts,
+			MaxAge:      cfg.AccrualMaxOrderAge,
+		}),
+		collector.WithRateLimit(cfg.AccrualRateLimit),
+		collector.WithLeaderLock(pgStorage.Leader),
+		collector.WithOrdersListener(pgStorage.Listener),
+		collector.WithMetrics(registry),
+		collector.WithBreakerPolicy(collector.BreakerPolicy{
+			FailureRatio:   cfg.BreakerFailureRatio,
+			MinRequests:    cfg.BreakerMinRequests,
+			Window:         1 * time.Minute,
+			CoolDown:       cfg.BreakerCoolDown,
+			HalfOpenProbes: 1,
+		}),
+	)
+
+	keys, err := buildKeySet(cfg)
+	if err != nil {
+		slog.Error("failed to load jwt keys", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	policy, err := buildPasswordPolicy(cfg)
+	if err != nil {
+		slog.Error("failed to load password policy", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	routerDeps := buildRouterDeps(cfg, pgStorage, collector, keys, policy)
+	routerDeps.Metrics = registry
 	router := router.NewRouter(routerDeps)
 
 	wg := &sync.WaitGroup{}
@@ -86,12 +152,6 @@ func main() {
 		slog.Info("api shut down gracefully")
 	}()
 
-	collector := collector.NewCollector(
-		cfg.AccrualSystemAddress,
-		cfg.AccrualPollInterval,
-		pgStorage.Collector,
-	)
-
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -110,6 +170,16 @@ func main() {
 
 	wg.Wait()
 
+	// os.Exit не выполняет defer, поэтому сбрасываем спаны явно
+	flushCtx, flushCancel := context.WithTimeout(
+		context.Background(),
+		5*time.Second,
+	)
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("failed to flush traces", slog.Any("error", err))
+	}
+	flushCancel()
+
 	ec := int(atomic.LoadInt32(&exitCode))
 	if ec != 0 {
 		slog.Error("app failed", slog.Int("exit_code", ec))
@@ -119,27 +189,146 @@ func main() {
 	slog.Info("app shut down successfully")
 }
 
+func buildAccrualProvider(
+	cfg *config.Config,
+) (collector.AccrualProvider, error) {
+	switch cfg.AccrualProvider {
+	case config.AccrualProviderFile:
+		return collector.NewFileProvider(cfg.AccrualProviderFile)
+	case config.AccrualProviderMemory:
+		return collector.NewMemoryProvider(), nil
+	default:
+		return collector.NewHTTPProvider(cfg.AccrualSystemAddress), nil
+	}
+}
+
+// buildKeySet первый ключ из JWT_KEYS подписывает токены, остальные
+// принимаются до своего времени вывода, JWT_SECRET (токены HS256 до
+// перехода на ключи) - до JWT_SECRET_RETIRE_AT.
+func buildKeySet(cfg *config.Config) (*auth.KeySet, error) {
+	if len(cfg.JWTKeys) == 0 {
+		return auth.NewKeySet(auth.NewHMACKey(cfg.JWTSecret), nil)
+	}
+
+	keys := make([]auth.PreviousKey, 0, len(cfg.JWTKeys)+1)
+	for _, k := range cfg.JWTKeys {
+		key, err := auth.LoadPEMKey(k.ID, k.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load jwt key %q: %w", k.ID, err)
+		}
+		keys = append(keys, auth.PreviousKey{Key: key, RetireAt: k.RetireAt})
+	}
+	if cfg.JWTSecret != "" {
+		keys = append(keys, auth.PreviousKey{
+			Key:      auth.NewHMACKey(cfg.JWTSecret),
+			RetireAt: cfg.JWTSecretRetireAt,
+		})
+	}
+
+	return auth.NewKeySet(keys[0].Key, keys[1:])
+}
+
+// buildPasswordPolicy длина 12-64 символа, пароль без логина, классы
+// символов и список запрещённых паролей - если заданы.
+func buildPasswordPolicy(cfg *config.Config) (*auth.PasswordPolicy, error) {
+	rules := []auth.PasswordRule{
+		auth.LengthRule(auth.MinPasswordLength, auth.MaxPasswordLength),
+		auth.NoLoginRule(),
+	}
+	if cfg.PasswordMinClasses > 0 {
+		rules = append(rules, auth.CharClassesRule(cfg.PasswordMinClasses))
+	}
+	if cfg.PasswordDenyList != "" {
+		passwords, err := auth.LoadDenyList(cfg.PasswordDenyList)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, auth.DenyListRule(passwords))
+	}
+
+	return auth.NewPasswordPolicy(rules...), nil
+}
+
 func buildRouterDeps(
 	cfg *config.Config,
 	st *postgresql.Repositories,
+	coll *collector.Collector,
+	keys *auth.KeySet,
+	policy *auth.PasswordPolicy,
 ) router.StorageDeps {
 	healthSvc := healthcheck.NewHealthcheckService(st.Health)
+	healthSvc.AddComponent("accrual_breaker", func() string {
+		return coll.BreakerState().String()
+	})
+	healthSvc.AddComponent("accrual_collector", coll.Role)
+	healthSvc.AddCheck(healthcheck.Check{
+		Name:    "accrual",
+		Timeout: 2 * time.Second,
+		Func:    coll.CheckAccrual,
+	})
+	healthSvc.AddCheck(healthcheck.Check{
+		Name:    "collector",
+		Timeout: 500 * time.Millisecond,
+		Func:    coll.CheckCycle,
+	})
+	healthSvc.AddCheck(healthcheck.Check{
+		Name:    "collector_pause",
+		Timeout: 500 * time.Millisecond,
+		Func:    coll.CheckPause,
+	})
+	argon2Params := auth.DefaultArgon2Params()
+	argon2Params.Memory = cfg.Argon2Memory
+	argon2Params.Iterations = cfg.Argon2Iterations
+	argon2Params.Parallelism = cfg.Argon2Parallelism
+
 	authSvc := auth.NewAuthService(
 		st.Users,
-		cfg.JWTSecret,
-		cfg.JWTTTL,
+		st.Sessions,
+		auth.JWTConfig{
+			Keys:        keys,
+			Issuer:      cfg.JWTIssuer,
+			Audience:    cfg.JWTAudience,
+			TTL:         cfg.JWTTTL,
+			Leeway:      cfg.JWTLeeway,
+			LegacyUntil: cfg.JWTLegacyUntil,
+		},
+		cfg.JWTRefreshTTL,
+		auth.WithPasswordHasher(
+			auth.NewPasswordHasher(argon2Params, cfg.PasswordHashLimit),
+		),
+		auth.WithPasswordPolicy(policy),
+		auth.WithLoginThrottle(st.Throttle, auth.LoginThrottle{
+			MaxLoginFailures: cfg.LoginMaxFailures,
+			MaxIPFailures:    cfg.LoginIPMaxFailures,
+			Window:           cfg.LoginFailureWindow,
+			Lockout:          cfg.LoginLockout,
+			MaxLockout:       cfg.LoginMaxLockout,
+		}),
+		auth.WithMFA(st.MFA),
 	)
 	ordersSvc := orders.NewOrdersService(st.Orders)
 	balanceSvc := balance.NewBalanceService(st.Balance)
 	withdrawalsSvc := withdrawals.NewWithdrawalsService(
 		st.Withdrawals,
 	)
+	ledgerSvc := ledger.NewLedgerService(st.Ledger)
+	apiKeysSvc := auth.NewAPIKeyService(st.APIKeys)
+	adminSvc := admin.NewAdminService(st.Admin, st.Orders, st.Withdrawals)
 	return router.StorageDeps{
-		JWTSecret:          cfg.JWTSecret,
+		TokenVerifier:      authSvc,
+		APIKeyVerifier:     apiKeysSvc,
+		StepUpVerifier:     authSvc,
+		JWKS:               keys,
+		TrustedProxies:     cfg.TrustedProxies,
 		HealthService:      healthSvc,
 		AuthService:        authSvc,
+		AccountService:     authSvc,
+		MFAService:         authSvc,
 		OrdersService:      ordersSvc,
 		BalanceService:     balanceSvc,
 		WithdrawalsService: withdrawalsSvc,
+		LedgerService:      ledgerSvc,
+		APIKeysService:     apiKeysSvc,
+		AdminService:       adminSvc,
 	}
 }
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/fragpit/gophermart/internal/accrual/model"
+)
+
+//go:generate mockgen -destination ./mocks/accrual_mock.go . AccrualService
+type AccrualService interface {
+	RegisterReward(ctx context.Context, reward *model.Reward) error
+	RegisterOrder(ctx context.Context, number string, goods []model.Good) error
+	GetOrder(ctx context.Context, number string) (*model.Order, error)
+}
+
+type rewardRequest struct {
+	Match      string           `json:"match"`
+	Reward     model.Kopek      `json:"reward"`
+	RewardType model.RewardType `json:"reward_type"`
+}
+
+type orderRegisterRequest struct {
+	Order string       `json:"order"`
+	Goods []model.Good `json:"goods"`
+}
+
+type orderResponse struct {
+	Order   string            `json:"order"`
+	Status  model.OrderStatus `json:"status"`
+	Accrual model.Kopek       `json:"accrual,omitempty"`
+}
+
+func NewGoodsHandler(svc AccrualService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rewardRequest
+		if !decodeJSONRequest(w, r, &req) {
+			return
+		}
+
+		reward := &model.Reward{
+			Match:      req.Match,
+			Reward:     req.Reward,
+			RewardType: req.RewardType,
+		}
+		if err := svc.RegisterReward(r.Context(), reward); err != nil {
+			slog.Warn("failed to register reward", slog.Any("error", err))
+			switch {
+			case errors.Is(err, model.ErrBadReward):
+				http.Error(w, "bad reward format", http.StatusBadRequest)
+			case errors.Is(err, model.ErrRewardExists):
+				http.Error(
+					w,
+					"match already registered",
+					http.StatusConflict,
+				)
+			default:
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func NewOrderRegisterHandler(svc AccrualService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req orderRegisterRequest
+		if !decodeJSONRequest(w, r, &req) {
+			return
+		}
+
+		number := strings.TrimSpace(req.Order)
+		if err := svc.RegisterOrder(r.Context(), number, req.Goods); err != nil {
+			slog.Warn(
+				"failed to register order",
+				slog.String("number", number),
+				slog.Any("error", err),
+			)
+			switch {
+			case errors.Is(err, model.ErrBadOrderNumber):
+				http.Error(w, "bad order number", http.StatusBadRequest)
+			case errors.Is(err, model.ErrOrderExists):
+				http.Error(
+					w,
+					"order already registered",
+					http.StatusConflict,
+				)
+			default:
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+func NewOrderGetHandler(svc AccrualService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		number := r.PathValue("number")
+
+		order, err := svc.GetOrder(r.Context(), number)
+		if err != nil {
+			if errors.Is(err, model.ErrOrderNotFound) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			slog.Error("failed to get order", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		resp := orderResponse{
+			Order:  order.Number,
+			Status: order.Status,
+		}
+		if order.Status == model.StatusProcessed {
+			resp.Accrual = order.Accrual
+		}
+
+		b, err := json.Marshal(resp)
+		if err != nil {
+			slog.Warn("failed to marshal json response", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(b); err != nil {
+			slog.Warn("failed to write response", slog.Any("error", err))
+			return
+		}
+	})
+}
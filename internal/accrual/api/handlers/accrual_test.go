@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mock_handlers "github.com/fragpit/gophermart/internal/accrual/api/handlers/mocks"
+	"github.com/fragpit/gophermart/internal/accrual/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGoodsHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name     string
+		body     string
+		mockErr  error
+		wantCall bool
+		wantCode int
+	}{
+		{
+			name:     "success",
+			body:     `{"match":"Bork","reward":10,"reward_type":"%"}`,
+			wantCall: true,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "unknown reward type",
+			body:     `{"match":"Bork","reward":10,"reward_type":"rub"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "bad reward",
+			body:     `{"match":"","reward":10,"reward_type":"pt"}`,
+			mockErr:  model.ErrBadReward,
+			wantCall: true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "duplicate match",
+			body:     `{"match":"Bork","reward":10,"reward_type":"pt"}`,
+			mockErr:  model.ErrRewardExists,
+			wantCall: true,
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_handlers.NewMockAccrualService(ctrl)
+			if tc.wantCall {
+				m.EXPECT().
+					RegisterReward(gomock.Any(), gomock.Any()).
+					Return(tc.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/goods",
+				strings.NewReader(tc.body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+
+			NewGoodsHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestOrderRegisterHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name     string
+		body     string
+		mockErr  error
+		wantCall bool
+		wantCode int
+	}{
+		{
+			name: "success",
+			body: `{"order":"79927398713",` +
+				`"goods":[{"description":"Чайник Bork","price":7000}]}`,
+			wantCall: true,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:     "invalid json",
+			body:     `{"order":`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "bad number",
+			body:     `{"order":"123","goods":[]}`,
+			mockErr:  model.ErrBadOrderNumber,
+			wantCall: true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "already registered",
+			body:     `{"order":"79927398713","goods":[]}`,
+			mockErr:  model.ErrOrderExists,
+			wantCall: true,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "internal error",
+			body:     `{"order":"79927398713","goods":[]}`,
+			mockErr:  errors.New("db down"),
+			wantCall: true,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_handlers.NewMockAccrualService(ctrl)
+			if tc.wantCall {
+				m.EXPECT().
+					RegisterOrder(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(tc.mockErr)
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/orders",
+				strings.NewReader(tc.body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+
+			NewOrderRegisterHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestOrderGetHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name     string
+		order    *model.Order
+		mockErr  error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "processed",
+			order: &model.Order{
+				Number:  "79927398713",
+				Status:  model.StatusProcessed,
+				Accrual: 50000,
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"order":"79927398713","status":"PROCESSED","accrual":500}`,
+		},
+		{
+			name: "registered has no accrual",
+			order: &model.Order{
+				Number: "79927398713",
+				Status: model.StatusRegistered,
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"order":"79927398713","status":"REGISTERED"}`,
+		},
+		{
+			name:     "not registered",
+			mockErr:  model.ErrOrderNotFound,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "internal error",
+			mockErr:  errors.New("db down"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_handlers.NewMockAccrualService(ctrl)
+			m.EXPECT().
+				GetOrder(gomock.Any(), "79927398713").
+				Return(tc.order, tc.mockErr)
+
+			mux := http.NewServeMux()
+			mux.Handle("GET /api/orders/{number}", NewOrderGetHandler(m))
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(
+				http.MethodGet,
+				"/api/orders/79927398713",
+				nil,
+			)
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
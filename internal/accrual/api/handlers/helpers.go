@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// decodeJSONRequest разбирает тело запроса и сам отвечает клиенту при
+// ошибке. Возвращает false, если обработку запроса нужно прекратить.
+func decodeJSONRequest(w http.ResponseWriter, r *http.Request, data any) bool {
+	if r.Header.Get("Content-Type") != "application/json" {
+		slog.Error(
+			"request with an empty or unsupported content type",
+			slog.String("content_type", r.Header.Get("Content-Type")),
+		)
+		http.Error(w, "wrong content type", http.StatusBadRequest)
+		return false
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	defer func() { _ = r.Body.Close() }()
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(data); err != nil {
+		var mberr *http.MaxBytesError
+		slog.Warn("invalid JSON", slog.Any("error", err))
+		if errors.As(err, &mberr) {
+			http.Error(
+				w,
+				"request body too large",
+				http.StatusRequestEntityTooLarge,
+			)
+			return false
+		}
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return false
+	}
+
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		slog.Warn("invalid JSON", slog.Any("error", err))
+		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
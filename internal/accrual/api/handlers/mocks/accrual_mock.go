@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/accrual/api/handlers (interfaces: AccrualService)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/accrual_mock.go . AccrualService
+//
+
+// Package mock_handlers is a generated GoMock package.
+package mock_handlers
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/fragpit/gophermart/internal/accrual/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAccrualService is a mock of AccrualService interface.
+type MockAccrualService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccrualServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAccrualServiceMockRecorder is the mock recorder for MockAccrualService.
+type MockAccrualServiceMockRecorder struct {
+	mock *MockAccrualService
+}
+
+// NewMockAccrualService creates a new mock instance.
+func NewMockAccrualService(ctrl *gomock.Controller) *MockAccrualService {
+	mock := &MockAccrualService{ctrl: ctrl}
+	mock.recorder = &MockAccrualServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccrualService) EXPECT() *MockAccrualServiceMockRecorder {
+	return m.recorder
+}
+
+// GetOrder mocks base method.
+func (m *MockAccrualService) GetOrder(ctx context.Context, number string) (*model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrder", ctx, number)
+	ret0, _ := ret[0].(*model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrder indicates an expected call of GetOrder.
+func (mr *MockAccrualServiceMockRecorder) GetOrder(ctx, number any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrder", reflect.TypeOf((*MockAccrualService)(nil).GetOrder), ctx, number)
+}
+
+// RegisterOrder mocks base method.
+func (m *MockAccrualService) RegisterOrder(ctx context.Context, number string, goods []model.Good) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterOrder", ctx, number, goods)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterOrder indicates an expected call of RegisterOrder.
+func (mr *MockAccrualServiceMockRecorder) RegisterOrder(ctx, number, goods any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterOrder", reflect.TypeOf((*MockAccrualService)(nil).RegisterOrder), ctx, number, goods)
+}
+
+// RegisterReward mocks base method.
+func (m *MockAccrualService) RegisterReward(ctx context.Context, reward *model.Reward) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterReward", ctx, reward)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterReward indicates an expected call of RegisterReward.
+func (mr *MockAccrualServiceMockRecorder) RegisterReward(ctx, reward any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterReward", reflect.TypeOf((*MockAccrualService)(nil).RegisterReward), ctx, reward)
+}
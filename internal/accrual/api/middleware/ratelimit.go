@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit ограничивает число запросов в минуту (фиксированное окно).
+// При превышении отвечает 429 с Retry-After до начала следующего окна.
+// limit <= 0 отключает ограничение.
+func RateLimit(limit int) func(http.Handler) http.Handler {
+	return rateLimit(limit, time.Now)
+}
+
+func rateLimit(
+	limit int,
+	now func() time.Time,
+) func(http.Handler) http.Handler {
+	var (
+		mu          sync.Mutex
+		windowStart time.Time
+		count       int
+	)
+
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t := now()
+
+			mu.Lock()
+			if t.Sub(windowStart) >= time.Minute {
+				windowStart = t.Truncate(time.Minute)
+				count = 0
+			}
+			count++
+			allowed := count <= limit
+			reset := windowStart.Add(time.Minute).Sub(t)
+			mu.Unlock()
+
+			if !allowed {
+				retryAfter := int(math.Ceil(reset.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				slog.Warn(
+					"rate limit exceeded",
+					slog.String("remote_addr", r.RemoteAddr),
+					slog.Int("retry_after", retryAfter),
+				)
+				w.Header().Set("Content-Type", "text/plain")
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = fmt.Fprintf(
+					w,
+					"No more than %d requests per minute allowed",
+					limit,
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package router
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/accrual/api/handlers"
+	accmw "github.com/fragpit/gophermart/internal/accrual/api/middleware"
+	"github.com/fragpit/gophermart/internal/api/middleware"
+)
+
+const apiShutdownTimeout = 5 * time.Second
+
+type Deps struct {
+	RateLimit      int
+	AccrualService handlers.AccrualService
+}
+
+type Router struct {
+	router http.Handler
+}
+
+func NewRouter(deps Deps) *Router {
+	mux := http.NewServeMux()
+
+	logMW := middleware.Log()
+	limitMW := accmw.RateLimit(deps.RateLimit)
+
+	mux.Handle(
+		"GET /api/orders/{number}",
+		limitMW(handlers.NewOrderGetHandler(deps.AccrualService)),
+	)
+
+	mux.Handle(
+		"POST /api/orders",
+		limitMW(handlers.NewOrderRegisterHandler(deps.AccrualService)),
+	)
+
+	mux.Handle(
+		"POST /api/goods",
+		handlers.NewGoodsHandler(deps.AccrualService),
+	)
+
+	return &Router{
+		router: logMW(mux),
+	}
+}
+
+func (r *Router) Run(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: r.router,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("failed to start api", slog.Any("error", err))
+			errChan <- err
+			return
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		ctx, cancel := context.WithTimeout(ctx, apiShutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			slog.Error(
+				"failed to shutdown api gracefully",
+				slog.Any("error", err),
+			)
+			return err
+		}
+	}
+
+	return nil
+}
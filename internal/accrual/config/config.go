@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrParameterNotSet = errors.New("config parameter is not set")
+)
+
+type Config struct {
+	LogLevel        string
+	RunAddress      string
+	DatabaseURI     string
+	RateLimit       int
+	ProcessInterval time.Duration
+}
+
+func getenvOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func NewConfig() (*Config, error) {
+	logLevel := flag.String(
+		"log-level",
+		getenvOr("LOG_LEVEL", "info"),
+		"log level (default: info)",
+	)
+	runAddress := flag.String(
+		"a",
+		getenvOr("RUN_ADDRESS", ":8080"),
+		"listen address",
+	)
+	databaseURI := flag.String(
+		"d",
+		getenvOr("DATABASE_URI", ""),
+		"database connection string",
+	)
+	rateLimit := flag.String(
+		"rate-limit",
+		getenvOr("RATE_LIMIT", "0"),
+		"max order requests per minute, 0 - unlimited (default: 0)",
+	)
+	processInterval := flag.String(
+		"process-interval",
+		getenvOr("PROCESS_INTERVAL", "1s"),
+		"order processing interval (default: 1s)",
+	)
+
+	flag.Parse()
+
+	if *databaseURI == "" {
+		return nil, fmt.Errorf("database URI error %w", ErrParameterNotSet)
+	}
+
+	rateLimitNum, err := strconv.Atoi(*rateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate limit %q: %w", *rateLimit, err)
+	}
+
+	processIntervalDuration, err := time.ParseDuration(*processInterval)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid process interval %q: %w",
+			*processInterval,
+			err,
+		)
+	}
+
+	return &Config{
+		LogLevel:        *logLevel,
+		RunAddress:      *runAddress,
+		DatabaseURI:     *databaseURI,
+		RateLimit:       rateLimitNum,
+		ProcessInterval: processIntervalDuration,
+	}, nil
+}
+
+func (c *Config) String() string {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stdout, err)
+		os.Exit(0)
+	}
+	return string(b)
+}
@@ -0,0 +1,207 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	gmmodel "github.com/fragpit/gophermart/internal/model"
+	"github.com/fragpit/gophermart/internal/utils/luhn"
+)
+
+var (
+	ErrRewardExists   = errors.New("reward match already registered")
+	ErrOrderExists    = errors.New("order already registered")
+	ErrOrderNotFound  = errors.New("order not found")
+	ErrBadReward      = errors.New("bad reward format")
+	ErrBadOrderNumber = errors.New("bad order number format")
+)
+
+type Kopek = gmmodel.Kopek
+
+type Good = gmmodel.Good
+
+type RewardsRepository interface {
+	AddReward(ctx context.Context, reward *Reward) error
+	GetRewards(ctx context.Context) ([]Reward, error)
+}
+
+type OrdersRepository interface {
+	AddOrder(ctx context.Context, order *Order) error
+	GetOrder(ctx context.Context, number string) (*Order, error)
+	ClaimOrders(ctx context.Context, batchSize int) ([]Order, error)
+	SetResult(
+		ctx context.Context,
+		number string,
+		status OrderStatus,
+		accrual Kopek,
+	) error
+}
+
+type RewardType int
+
+const (
+	RewardPercent RewardType = iota
+	RewardPoints
+)
+
+func (t RewardType) String() string {
+	switch t {
+	case RewardPercent:
+		return "%"
+	case RewardPoints:
+		return "pt"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (t RewardType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *RewardType) UnmarshalText(text []byte) error {
+	return t.fromString(string(text))
+}
+
+func (t *RewardType) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return t.fromString(v)
+	case []byte:
+		return t.fromString(string(v))
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+}
+
+func (t *RewardType) fromString(v string) error {
+	switch v {
+	case "%":
+		*t = RewardPercent
+	case "pt":
+		*t = RewardPoints
+	default:
+		return fmt.Errorf("unknown reward type %q", v)
+	}
+	return nil
+}
+
+// Reward механика вознаграждения. Reward хранится в сотых долях: для "%"
+// это сотые доли процента, для "pt" - копейки.
+type Reward struct {
+	Match      string
+	Reward     Kopek
+	RewardType RewardType
+}
+
+func ValidateReward(r *Reward) error {
+	if strings.TrimSpace(r.Match) == "" || r.Reward <= 0 {
+		return ErrBadReward
+	}
+	return nil
+}
+
+// Apply возвращает вознаграждение за один товар.
+func (r *Reward) Apply(g Good) Kopek {
+	if !strings.Contains(g.Description, r.Match) {
+		return 0
+	}
+
+	switch r.RewardType {
+	case RewardPercent:
+		return Kopek(int64(g.Price) * int64(r.Reward) / 10000)
+	case RewardPoints:
+		return r.Reward
+	default:
+		return 0
+	}
+}
+
+// CalculateAccrual начисляет сумму совпадений всех механик по всем товарам.
+func CalculateAccrual(goods []Good, rewards []Reward) Kopek {
+	var sum Kopek
+	for _, g := range goods {
+		for i := range rewards {
+			sum += rewards[i].Apply(g)
+		}
+	}
+	return sum
+}
+
+type OrderStatus int
+
+const (
+	StatusRegistered OrderStatus = iota
+	StatusProcessing
+	StatusProcessed
+	StatusInvalid
+)
+
+func (s OrderStatus) String() string {
+	switch s {
+	case StatusRegistered:
+		return "REGISTERED"
+	case StatusProcessing:
+		return "PROCESSING"
+	case StatusProcessed:
+		return "PROCESSED"
+	case StatusInvalid:
+		return "INVALID"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (s OrderStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *OrderStatus) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return s.fromString(v)
+	case []byte:
+		return s.fromString(string(v))
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+}
+
+func (s *OrderStatus) fromString(v string) error {
+	switch v {
+	case "REGISTERED":
+		*s = StatusRegistered
+	case "PROCESSING":
+		*s = StatusProcessing
+	case "PROCESSED":
+		*s = StatusProcessed
+	case "INVALID":
+		*s = StatusInvalid
+	default:
+		return fmt.Errorf("unknown status %q", v)
+	}
+	return nil
+}
+
+type Order struct {
+	Number       string
+	Status       OrderStatus
+	Accrual      Kopek
+	Goods        []Good
+	RegisteredAt time.Time
+}
+
+func NewOrder(number string, goods []Good) *Order {
+	return &Order{
+		Number: number,
+		Status: StatusRegistered,
+		Goods:  goods,
+	}
+}
+
+func ValidateNumber(number string) bool {
+	return luhn.ValidateNumber(number)
+}
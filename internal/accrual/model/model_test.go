@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateAccrual(t *testing.T) {
+	rewards := []Reward{
+		{Match: "Bork", Reward: 1000, RewardType: RewardPercent},
+		{Match: "Чайник", Reward: 1500, RewardType: RewardPoints},
+	}
+
+	tests := []struct {
+		name  string
+		goods []Good
+		want  Kopek
+	}{
+		{
+			name:  "no goods",
+			goods: nil,
+			want:  0,
+		},
+		{
+			name:  "no matches",
+			goods: []Good{{Description: "Утюг Philips", Price: 500000}},
+			want:  0,
+		},
+		{
+			name:  "percent reward",
+			goods: []Good{{Description: "Миксер Bork", Price: 700000}},
+			want:  70000,
+		},
+		{
+			name:  "points reward",
+			goods: []Good{{Description: "Чайник Tefal", Price: 300000}},
+			want:  1500,
+		},
+		{
+			name: "all matches are summed",
+			goods: []Good{
+				{Description: "Чайник Bork", Price: 700000},
+				{Description: "Миксер Bork", Price: 2730},
+			},
+			want: 70000 + 1500 + 273,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CalculateAccrual(tt.goods, rewards))
+		})
+	}
+}
+
+func TestValidateReward(t *testing.T) {
+	assert.NoError(t, ValidateReward(&Reward{Match: "Bork", Reward: 1}))
+	assert.ErrorIs(t, ValidateReward(&Reward{Match: " ", Reward: 1}), ErrBadReward)
+	assert.ErrorIs(t, ValidateReward(&Reward{Match: "Bork"}), ErrBadReward)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fragpit/gophermart/internal/accrual/api/handlers"
+	"github.com/fragpit/gophermart/internal/accrual/model"
+)
+
+var _ handlers.AccrualService = (*AccrualService)(nil)
+
+type AccrualService struct {
+	rewards model.RewardsRepository
+	orders  model.OrdersRepository
+}
+
+func NewAccrualService(
+	rewards model.RewardsRepository,
+	orders model.OrdersRepository,
+) *AccrualService {
+	return &AccrualService{
+		rewards: rewards,
+		orders:  orders,
+	}
+}
+
+func (a *AccrualService) RegisterReward(
+	ctx context.Context,
+	reward *model.Reward,
+) error {
+	reward.Match = strings.TrimSpace(reward.Match)
+	if err := model.ValidateReward(reward); err != nil {
+		return err
+	}
+
+	return a.rewards.AddReward(ctx, reward)
+}
+
+func (a *AccrualService) RegisterOrder(
+	ctx context.Context,
+	number string,
+	goods []model.Good,
+) error {
+	if number == "" || !model.ValidateNumber(number) {
+		return model.ErrBadOrderNumber
+	}
+
+	return a.orders.AddOrder(ctx, model.NewOrder(number, goods))
+}
+
+func (a *AccrualService) GetOrder(
+	ctx context.Context,
+	number string,
+) (*model.Order, error) {
+	return a.orders.GetOrder(ctx, number)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/accrual/model"
+)
+
+// Processor в фоне рассчитывает вознаграждения по зарегистрированным
+// заказам.
+type Processor struct {
+	Interval  time.Duration
+	BatchSize int
+
+	rewards model.RewardsRepository
+	orders  model.OrdersRepository
+}
+
+func NewProcessor(
+	interval time.Duration,
+	rewards model.RewardsRepository,
+	orders model.OrdersRepository,
+) *Processor {
+	return &Processor{
+		Interval:  interval,
+		BatchSize: 100,
+		rewards:   rewards,
+		orders:    orders,
+	}
+}
+
+func (p *Processor) Run(ctx context.Context) error {
+	tick := time.NewTicker(p.Interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+			if err := p.processOrders(ctx); err != nil {
+				slog.Error("processor cycle failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+func (p *Processor) processOrders(ctx context.Context) error {
+	orders, err := p.orders.ClaimOrders(ctx, p.BatchSize)
+	if err != nil {
+		return err
+	}
+	if len(orders) == 0 {
+		return nil
+	}
+
+	rewards, err := p.rewards.GetRewards(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range orders {
+		status, accrual := Calculate(&o, rewards)
+		if err := p.orders.SetResult(ctx, o.Number, status, accrual); err != nil {
+			return fmt.Errorf("failed to save order %s: %w", o.Number, err)
+		}
+		slog.Info(
+			"order processed",
+			slog.String("number", o.Number),
+			slog.String("status", status.String()),
+			slog.Int("accrual", int(accrual)),
+		)
+	}
+
+	return nil
+}
+
+// Calculate заказ без состава чека не принимается к расчёту, для остальных
+// начисляется сумма совпадений (возможно нулевая).
+func Calculate(
+	order *model.Order,
+	rewards []model.Reward,
+) (model.OrderStatus, model.Kopek) {
+	if len(order.Goods) == 0 {
+		return model.StatusInvalid, 0
+	}
+	return model.StatusProcessed, model.CalculateAccrual(order.Goods, rewards)
+}
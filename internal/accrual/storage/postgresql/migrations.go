@@ -0,0 +1,65 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/tern/v2/migrate"
+)
+
+// таблицы accrual имеют префикс, т.к. в автотестах gophermart и accrual
+// используют одну базу данных.
+func runMigrations(ctx context.Context, conn *pgxpool.Pool) error {
+	poolConn, err := conn.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("error creating pool connection: %w", err)
+	}
+	defer poolConn.Release()
+
+	m, err := migrate.NewMigrator(ctx, poolConn.Conn(), "accrual_migrations")
+	if err != nil {
+		return fmt.Errorf("error migrations init: %w", err)
+	}
+
+	m.Migrations = []*migrate.Migration{
+		{
+			Sequence: 1,
+			Name:     "init",
+			UpSQL: `
+			CREATE TABLE IF NOT EXISTS accrual_rewards (
+				id SERIAL PRIMARY KEY,
+				match VARCHAR(255) UNIQUE NOT NULL,
+				reward BIGINT NOT NULL, -- hundredths of percent or kopeks
+				reward_type VARCHAR(2) NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+			);
+
+			CREATE TABLE IF NOT EXISTS accrual_orders (
+				id SERIAL PRIMARY KEY,
+				number VARCHAR(255) UNIQUE NOT NULL,
+				status VARCHAR(20) NOT NULL DEFAULT 'REGISTERED',
+				accrual BIGINT NOT NULL DEFAULT 0, -- stored in kopeks
+				goods JSONB NOT NULL DEFAULT '[]',
+				registered_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+				updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_accrual_orders_status
+			ON accrual_orders (status, updated_at);
+			`,
+			DownSQL: `
+			DROP INDEX IF EXISTS idx_accrual_orders_status;
+
+			DROP TABLE IF EXISTS accrual_orders;
+			DROP TABLE IF EXISTS accrual_rewards;
+			`,
+		},
+	}
+
+	if err := m.Migrate(ctx); err != nil {
+		return fmt.Errorf("error applying migrations: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fragpit/gophermart/internal/accrual/model"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var _ model.OrdersRepository = (*OrdersRepo)(nil)
+
+// заказ в PROCESSING дольше этого времени снова выдаётся обработчику
+// (например, после падения процесса).
+const processingLease = "1 minute"
+
+type OrdersRepo struct {
+	baseRepo
+}
+
+func (r *OrdersRepo) AddOrder(ctx context.Context, order *model.Order) error {
+	q := `
+		INSERT INTO accrual_orders (number, status, goods)
+		VALUES (@number, @status, @goods)
+	`
+
+	goods := order.Goods
+	if goods == nil {
+		goods = []model.Good{}
+	}
+
+	args := pgx.NamedArgs{
+		"number": order.Number,
+		"status": order.Status,
+		"goods":  goods,
+	}
+	if _, err := r.db.Exec(ctx, q, args); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return model.ErrOrderExists
+		}
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OrdersRepo) GetOrder(
+	ctx context.Context,
+	number string,
+) (*model.Order, error) {
+	q := `
+		SELECT number, status, accrual, registered_at
+		FROM accrual_orders
+		WHERE number = $1
+	`
+
+	var o model.Order
+	err := r.db.QueryRow(ctx, q, number).Scan(
+		&o.Number,
+		&o.Status,
+		&o.Accrual,
+		&o.RegisteredAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	return &o, nil
+}
+
+func (r *OrdersRepo) ClaimOrders(
+	ctx context.Context,
+	batchSize int,
+) ([]model.Order, error) {
+	q := `
+		UPDATE accrual_orders AS o
+		SET status = 'PROCESSING',
+			updated_at = NOW()
+		WHERE o.id IN (
+			SELECT id FROM accrual_orders
+			WHERE status = 'REGISTERED'
+				OR (
+					status = 'PROCESSING'
+					AND updated_at < NOW() - INTERVAL '` + processingLease + `'
+				)
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING o.number, o.status, o.accrual, o.goods, o.registered_at
+	`
+
+	rows, err := r.db.Query(ctx, q, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []model.Order
+	for rows.Next() {
+		var o model.Order
+		if err := rows.Scan(
+			&o.Number,
+			&o.Status,
+			&o.Accrual,
+			&o.Goods,
+			&o.RegisteredAt,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (r *OrdersRepo) SetResult(
+	ctx context.Context,
+	number string,
+	status model.OrderStatus,
+	accrual model.Kopek,
+) error {
+	q := `
+		UPDATE accrual_orders
+		SET status = $1,
+			accrual = $2,
+			updated_at = NOW()
+		WHERE number = $3
+	`
+
+	if _, err := r.db.Exec(ctx, q, status, accrual, number); err != nil {
+		return fmt.Errorf("failed to set order result: %w", err)
+	}
+
+	return nil
+}
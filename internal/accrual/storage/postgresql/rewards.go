@@ -0,0 +1,71 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fragpit/gophermart/internal/accrual/model"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var _ model.RewardsRepository = (*RewardsRepo)(nil)
+
+type RewardsRepo struct {
+	baseRepo
+}
+
+func (r *RewardsRepo) AddReward(
+	ctx context.Context,
+	reward *model.Reward,
+) error {
+	q := `
+		INSERT INTO accrual_rewards (match, reward, reward_type)
+		VALUES (@match, @reward, @rewardType)
+	`
+
+	args := pgx.NamedArgs{
+		"match":      reward.Match,
+		"reward":     reward.Reward,
+		"rewardType": reward.RewardType,
+	}
+	if _, err := r.db.Exec(ctx, q, args); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return model.ErrRewardExists
+		}
+		return fmt.Errorf("failed to create reward: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RewardsRepo) GetRewards(ctx context.Context) ([]model.Reward, error) {
+	q := `
+		SELECT match, reward, reward_type
+		FROM accrual_rewards
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("rewards query error: %w", err)
+	}
+	defer rows.Close()
+
+	var rewards []model.Reward
+	for rows.Next() {
+		var rw model.Reward
+		if err := rows.Scan(&rw.Match, &rw.Reward, &rw.RewardType); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		rewards = append(rewards, rw)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return rewards, nil
+}
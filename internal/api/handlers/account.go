@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+//go:generate mockgen -destination ./mocks/account_mock.go . AccountService
+type AccountService interface {
+	ExportData(
+		ctx context.Context,
+		p *model.Principal,
+	) (*model.UserExport, error)
+	DeleteAccount(ctx context.Context, p *model.Principal, password string) error
+}
+
+type exportResponse struct {
+	ExportedAt  string                `json:"exported_at"`
+	Profile     exportProfileResponse `json:"profile"`
+	Orders      []ordersGetResponse   `json:"orders"`
+	Withdrawals []WithdrawalsResponse `json:"withdrawals"`
+	Sessions    []sessionResponse     `json:"sessions"`
+}
+
+type exportProfileResponse struct {
+	ID        int      `json:"id"`
+	Login     string   `json:"login"`
+	Roles     []string `json:"roles"`
+	Status    string   `json:"status"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type sessionResponse struct {
+	FamilyID  string `json:"session_id"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+	UsedAt    string `json:"used_at,omitempty"`
+	RevokedAt string `json:"revoked_at,omitempty"`
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+// NewExportHandler GET /api/user/export - все данные пользователя одним
+// JSON файлом.
+func NewExportHandler(svc AccountService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		export, err := svc.ExportData(r.Context(), p)
+		if err != nil {
+			slog.Error(
+				"failed to export user data",
+				slog.Int("user_id", p.UserID),
+				slog.Any("error", err),
+			)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(
+			"Content-Disposition",
+			fmt.Sprintf(`attachment; filename="gophermart-export-%d.json"`, p.UserID),
+		)
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(
+			newExportResponse(export),
+		); err != nil {
+			slog.Error("encode export error", slog.Any("error", err))
+		}
+	})
+}
+
+// NewDeleteAccountHandler DELETE /api/user, пароль подтверждает удаление.
+func NewDeleteAccountHandler(svc AccountService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		var req deleteAccountRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		if err := svc.DeleteAccount(r.Context(), p, req.Password); err != nil {
+			slog.Warn(
+				"failed to delete account",
+				slog.Int("user_id", p.UserID),
+				slog.Any("error", err),
+			)
+			var throttled *model.LoginThrottledError
+			switch {
+			case errors.As(err, &throttled):
+				loginThrottledResponse(w, throttled)
+			case errors.Is(err, model.ErrInvalidCredentials):
+				http.Error(w, "wrong password", http.StatusForbidden)
+			case errors.Is(err, model.ErrPasswordHasherBusy):
+				passwordHasherBusyResponse(w)
+			default:
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func newExportResponse(export *model.UserExport) exportResponse {
+	roles := export.User.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+
+	sessions := make([]sessionResponse, 0, len(export.Sessions))
+	for _, s := range export.Sessions {
+		session := sessionResponse{
+			FamilyID:  s.FamilyID,
+			CreatedAt: s.CreatedAt.Format(time.RFC3339),
+			ExpiresAt: s.ExpiresAt.Format(time.RFC3339),
+		}
+		if s.UsedAt != nil {
+			session.UsedAt = s.UsedAt.Format(time.RFC3339)
+		}
+		if s.RevokedAt != nil {
+			session.RevokedAt = s.RevokedAt.Format(time.RFC3339)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return exportResponse{
+		ExportedAt: time.Now().Format(time.RFC3339),
+		Profile: exportProfileResponse{
+			ID:        export.User.ID,
+			Login:     export.User.Login,
+			Roles:     roles,
+			Status:    string(export.User.Status),
+			CreatedAt: export.User.CreatedAt.Format(time.RFC3339),
+		},
+		Orders:      newOrdersGetResponse(export.Orders),
+		Withdrawals: newWithdrawalsResponse(export.Withdrawals),
+		Sessions:    sessions,
+	}
+}
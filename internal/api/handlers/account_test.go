@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mock_handlers "github.com/fragpit/gophermart/internal/api/handlers/mocks"
+	"github.com/fragpit/gophermart/internal/api/middleware"
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+	revoked := time.Now()
+
+	tests := []struct {
+		name           string
+		export         *model.UserExport
+		err            error
+		wantCode       int
+		wantBodySubstr []string
+	}{
+		{
+			name: "exported",
+			export: &model.UserExport{
+				User: model.User{
+					ID:     7,
+					Login:  "alice",
+					Status: model.UserStatusActive,
+				},
+				Orders: []model.Order{{
+					Number: "79927398713",
+					Status: model.StatusProcessed,
+				}},
+				Sessions: []model.Session{{
+					FamilyID:  "fam",
+					RevokedAt: &revoked,
+				}},
+			},
+			wantCode: http.StatusOK,
+			wantBodySubstr: []string{
+				`"login":"alice","roles":[],"status":"active"`,
+				`"number":"79927398713"`,
+				`"withdrawals":[]`,
+				`"session_id":"fam"`,
+				`"revoked_at"`,
+			},
+		},
+		{
+			name:     "internal error",
+			err:      errors.New("db error"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockAccountService(ctrl)
+			m.EXPECT().ExportData(gomock.Any(), p).Return(tc.export, tc.err)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxPrincipalKey, p)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodGet,
+				"/api/user/export",
+				nil,
+			)
+			rec := httptest.NewRecorder()
+
+			NewExportHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			for _, substr := range tc.wantBodySubstr {
+				assert.Contains(t, rec.Body.String(), substr)
+			}
+			if tc.err == nil {
+				assert.Contains(
+					t,
+					rec.Header().Get("Content-Disposition"),
+					"gophermart-export-7.json",
+				)
+			}
+		})
+	}
+}
+
+func TestDeleteAccountHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+
+	tests := []struct {
+		name           string
+		err            error
+		wantCode       int
+		wantRetryAfter string
+	}{
+		{
+			name:     "deleted",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "wrong password",
+			err:      model.ErrInvalidCredentials,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:           "too many attempts",
+			err:            &model.LoginThrottledError{RetryAfter: time.Minute},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "60",
+		},
+		{
+			name:     "internal error",
+			err:      errors.New("db error"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockAccountService(ctrl)
+			m.EXPECT().DeleteAccount(gomock.Any(), p, "secret").Return(tc.err)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxPrincipalKey, p)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodDelete,
+				"/api/user",
+				strings.NewReader(`{"password": "secret"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			NewDeleteAccountHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
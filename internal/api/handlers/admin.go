@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+//go:generate mockgen -destination ./mocks/admin_mock.go . AdminService
+type AdminService interface {
+	SearchUsers(
+		ctx context.Context,
+		query string,
+		limit int,
+	) ([]model.User, error)
+	GetUserOrders(ctx context.Context, userID int) ([]model.Order, error)
+	GetUserWithdrawals(
+		ctx context.Context,
+		userID int,
+	) ([]model.Withdrawal, error)
+	AdjustBalance(
+		ctx context.Context,
+		actor *model.Principal,
+		userID int,
+		amount model.Kopek,
+		reason string,
+	) (*model.AuditRecord, error)
+	ChangeUserStatus(
+		ctx context.Context,
+		actor *model.Principal,
+		userID int,
+		status model.UserStatus,
+		settlement model.BalanceSettlement,
+		reason string,
+	) (*model.AuditRecord, error)
+	GetAuditRecords(
+		ctx context.Context,
+		userID int,
+		limit int,
+	) ([]model.AuditRecord, error)
+}
+
+type adminUserResponse struct {
+	ID        int      `json:"id"`
+	Login     string   `json:"login"`
+	Roles     []string `json:"roles"`
+	Status    string   `json:"status"`
+	CreatedAt string   `json:"created_at"`
+}
+
+type balanceAdjustmentRequest struct {
+	// Amount положительная сумма зачисляется, отрицательная списывается.
+	Amount model.Kopek `json:"amount"`
+	Reason string      `json:"reason"`
+}
+
+type userStatusRequest struct {
+	Status model.UserStatus `json:"status"`
+	Reason string           `json:"reason"`
+	// Balance при закрытии: forfeit - остаток сгорает, settle - выплачен.
+	Balance model.BalanceSettlement `json:"balance"`
+}
+
+type auditRecordResponse struct {
+	ID           int         `json:"id"`
+	ActorID      int         `json:"actor_id"`
+	Action       string      `json:"action"`
+	TargetUserID int         `json:"user_id"`
+	Amount       model.Kopek `json:"amount"`
+	Reason       string      `json:"reason"`
+	CreatedAt    string      `json:"created_at"`
+}
+
+// NewAdminUsersSearchHandler GET /api/admin/users?login=<часть логина>&limit=N.
+func NewAdminUsersSearchHandler(svc AdminService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, ok := queryInt(w, r, "limit")
+		if !ok {
+			return
+		}
+
+		users, err := svc.SearchUsers(
+			r.Context(),
+			r.URL.Query().Get("login"),
+			limit,
+		)
+		if err != nil {
+			slog.Error("users search error", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(users) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		response := make([]adminUserResponse, 0, len(users))
+		for _, u := range users {
+			roles := u.Roles
+			if roles == nil {
+				roles = []string{}
+			}
+			response = append(response, adminUserResponse{
+				ID:        u.ID,
+				Login:     u.Login,
+				Roles:     roles,
+				Status:    string(u.Status),
+				CreatedAt: u.CreatedAt.Format(time.RFC3339),
+			})
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.Error("encode users error", slog.Any("error", err))
+		}
+	})
+}
+
+func NewAdminUserOrdersHandler(svc AdminService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := pathUserID(w, r)
+		if !ok {
+			return
+		}
+
+		orders, err := svc.GetUserOrders(r.Context(), userID)
+		if err != nil {
+			slog.Error("orders request error", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(orders) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(
+			newOrdersGetResponse(orders),
+		); err != nil {
+			slog.Error("encode orders error", slog.Any("error", err))
+		}
+	})
+}
+
+func NewAdminUserWithdrawalsHandler(svc AdminService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := pathUserID(w, r)
+		if !ok {
+			return
+		}
+
+		withdrawals, err := svc.GetUserWithdrawals(r.Context(), userID)
+		if err != nil {
+			slog.Error("withdrawals request error", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(withdrawals) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(
+			newWithdrawalsResponse(withdrawals),
+		); err != nil {
+			slog.Error("encode withdrawals error", slog.Any("error", err))
+		}
+	})
+}
+
+func NewAdminBalanceAdjustHandler(svc AdminService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		userID, ok := pathUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var req balanceAdjustmentRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		rec, err := svc.AdjustBalance(
+			r.Context(),
+			p,
+			userID,
+			req.Amount,
+			req.Reason,
+		)
+		if err != nil {
+			slog.Warn(
+				"failed to adjust balance",
+				slog.Int("admin_id", p.UserID),
+				slog.Int("user_id", userID),
+				slog.Any("error", err),
+			)
+			switch {
+			case errors.Is(err, model.ErrInvalidAdjustment):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, model.ErrUserNotFound):
+				http.Error(w, "user not found", http.StatusNotFound)
+			case errors.Is(err, model.ErrAccountClosed):
+				http.Error(w, "account closed", http.StatusConflict)
+			case errors.Is(err, model.ErrInsufficientPoints):
+				http.Error(w, "insufficient points", http.StatusConflict)
+			default:
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(
+			newAuditRecordResponse(rec),
+		); err != nil {
+			slog.Error("encode audit record error", slog.Any("error", err))
+		}
+	})
+}
+
+// NewAdminUserStatusHandler PUT /api/admin/users/{id}/status.
+func NewAdminUserStatusHandler(svc AdminService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		userID, ok := pathUserID(w, r)
+		if !ok {
+			return
+		}
+
+		var req userStatusRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		rec, err := svc.ChangeUserStatus(
+			r.Context(),
+			p,
+			userID,
+			req.Status,
+			req.Balance,
+			req.Reason,
+		)
+		if err != nil {
+			slog.Warn(
+				"failed to change user status",
+				slog.Int("admin_id", p.UserID),
+				slog.Int("user_id", userID),
+				slog.Any("error", err),
+			)
+			switch {
+			case errors.Is(err, model.ErrInvalidStatusChange):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case errors.Is(err, model.ErrUserNotFound):
+				http.Error(w, "user not found", http.StatusNotFound)
+			case errors.Is(err, model.ErrInvalidStatusTransition):
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(
+			newAuditRecordResponse(rec),
+		); err != nil {
+			slog.Error("encode audit record error", slog.Any("error", err))
+		}
+	})
+}
+
+// NewAdminAuditHandler GET /api/admin/audit?user_id=N&limit=N, без user_id -
+// по всем пользователям.
+func NewAdminAuditHandler(svc AdminService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := queryInt(w, r, "user_id")
+		if !ok {
+			return
+		}
+		limit, ok := queryInt(w, r, "limit")
+		if !ok {
+			return
+		}
+
+		records, err := svc.GetAuditRecords(r.Context(), userID, limit)
+		if err != nil {
+			slog.Error("audit request error", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(records) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		response := make([]auditRecordResponse, 0, len(records))
+		for i := range records {
+			response = append(response, newAuditRecordResponse(&records[i]))
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.Error("encode audit records error", slog.Any("error", err))
+		}
+	})
+}
+
+func newAuditRecordResponse(rec *model.AuditRecord) auditRecordResponse {
+	return auditRecordResponse{
+		ID:           rec.ID,
+		ActorID:      rec.ActorID,
+		Action:       rec.Action,
+		TargetUserID: rec.TargetUserID,
+		Amount:       rec.Amount,
+		Reason:       rec.Reason,
+		CreatedAt:    rec.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// pathUserID id пользователя из {id} в пути, при ошибке отвечает 400.
+func pathUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// queryInt необязательный неотрицательный числовой параметр запроса,
+// отсутствующий - 0. При ошибке отвечает 400.
+func queryInt(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		http.Error(w, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return n, true
+}
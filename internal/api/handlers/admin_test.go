@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mock_handlers "github.com/fragpit/gophermart/internal/api/handlers/mocks"
+	"github.com/fragpit/gophermart/internal/api/middleware"
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminUsersSearchHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name           string
+		target         string
+		prepare        func(*mock_handlers.MockAdminService)
+		wantCode       int
+		wantBodySubstr string
+	}{
+		{
+			name:   "found",
+			target: "/api/admin/users?login=us&limit=10",
+			prepare: func(m *mock_handlers.MockAdminService) {
+				m.EXPECT().SearchUsers(gomock.Any(), "us", 10).
+					Return([]model.User{
+						{
+							ID:        7,
+							Login:     "user",
+							Status:    model.UserStatusActive,
+							CreatedAt: time.Now(),
+						},
+					}, nil)
+			},
+			wantCode:       http.StatusOK,
+			wantBodySubstr: `"login":"user","roles":[],"status":"active"`,
+		},
+		{
+			name:   "not found",
+			target: "/api/admin/users?login=nobody",
+			prepare: func(m *mock_handlers.MockAdminService) {
+				m.EXPECT().SearchUsers(gomock.Any(), "nobody", 0).
+					Return(nil, nil)
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "invalid limit",
+			target:   "/api/admin/users?limit=-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:   "internal error",
+			target: "/api/admin/users",
+			prepare: func(m *mock_handlers.MockAdminService) {
+				m.EXPECT().SearchUsers(gomock.Any(), "", 0).
+					Return(nil, errors.New("db error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockAdminService(ctrl)
+			if tc.prepare != nil {
+				tc.prepare(m)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			rec := httptest.NewRecorder()
+
+			NewAdminUsersSearchHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantBodySubstr != "" {
+				assert.Contains(t, rec.Body.String(), tc.wantBodySubstr)
+			}
+		})
+	}
+}
+
+func TestAdminUserOrdersHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	m := mock_handlers.NewMockAdminService(ctrl)
+	m.EXPECT().GetUserOrders(gomock.Any(), 7).Return([]model.Order{
+		{Number: orderNumByLuhn, Status: model.StatusProcessed, Accrual: 500},
+	}, nil)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/admin/users/{id}/orders", NewAdminUserOrdersHandler(m))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(
+		rec,
+		httptest.NewRequest(http.MethodGet, "/api/admin/users/7/orders", nil),
+	)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"number":"`+orderNumByLuhn+`"`)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(
+		rec,
+		httptest.NewRequest(http.MethodGet, "/api/admin/users/x/orders", nil),
+	)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAdminBalanceAdjustHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	admin := &model.Principal{UserID: 1, Roles: []string{model.RoleAdmin}}
+
+	tests := []struct {
+		name           string
+		body           string
+		err            error
+		wantCode       int
+		wantBodySubstr string
+	}{
+		{
+			name:           "credited",
+			body:           `{"amount": 10.5, "reason": "goodwill"}`,
+			wantCode:       http.StatusCreated,
+			wantBodySubstr: `"amount":10.5,"reason":"goodwill"`,
+		},
+		{
+			name:     "invalid",
+			body:     `{"amount": 10.5, "reason": ""}`,
+			err:      model.ErrInvalidAdjustment,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "user not found",
+			body:     `{"amount": 10.5, "reason": "goodwill"}`,
+			err:      model.ErrUserNotFound,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "account closed",
+			body:     `{"amount": 10.5, "reason": "goodwill"}`,
+			err:      model.ErrAccountClosed,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "insufficient points",
+			body:     `{"amount": -10.5, "reason": "duplicate accrual"}`,
+			err:      model.ErrInsufficientPoints,
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockAdminService(ctrl)
+			m.EXPECT().
+				AdjustBalance(gomock.Any(), admin, 7, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(
+					_ context.Context,
+					_ *model.Principal,
+					userID int,
+					amount model.Kopek,
+					reason string,
+				) (*model.AuditRecord, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.AuditRecord{
+						ID:           11,
+						ActorID:      1,
+						Action:       model.AuditBalanceAdjustment,
+						TargetUserID: userID,
+						Amount:       amount,
+						Reason:       reason,
+						CreatedAt:    time.Now(),
+					}, nil
+				})
+
+			mux := http.NewServeMux()
+			mux.Handle(
+				"POST /api/admin/users/{id}/balance/adjustments",
+				NewAdminBalanceAdjustHandler(m),
+			)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxPrincipalKey, admin)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/api/admin/users/7/balance/adjustments",
+				strings.NewReader(tc.body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantBodySubstr != "" {
+				assert.Contains(t, rec.Body.String(), tc.wantBodySubstr)
+			}
+		})
+	}
+}
+
+func TestAdminUserStatusHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	admin := &model.Principal{UserID: 1, Roles: []string{model.RoleAdmin}}
+
+	tests := []struct {
+		name           string
+		body           string
+		err            error
+		wantCode       int
+		wantBodySubstr string
+	}{
+		{
+			name:           "closed",
+			body:           `{"status": "closed", "balance": "forfeit", "reason": "fraud"}`,
+			wantCode:       http.StatusOK,
+			wantBodySubstr: `"action":"user_closed","user_id":7,"amount":-15`,
+		},
+		{
+			name:     "invalid",
+			body:     `{"status": "closed", "reason": "fraud"}`,
+			err:      model.ErrInvalidStatusChange,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "user not found",
+			body:     `{"status": "suspended", "reason": "fraud"}`,
+			err:      model.ErrUserNotFound,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "transition not allowed",
+			body:     `{"status": "active", "reason": "reopen"}`,
+			err:      model.ErrInvalidStatusTransition,
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockAdminService(ctrl)
+			m.EXPECT().
+				ChangeUserStatus(
+					gomock.Any(),
+					admin,
+					7,
+					gomock.Any(),
+					gomock.Any(),
+					gomock.Any(),
+				).
+				DoAndReturn(func(
+					_ context.Context,
+					_ *model.Principal,
+					userID int,
+					_ model.UserStatus,
+					_ model.BalanceSettlement,
+					reason string,
+				) (*model.AuditRecord, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &model.AuditRecord{
+						ID:           11,
+						ActorID:      1,
+						Action:       model.AuditUserClosed,
+						TargetUserID: userID,
+						Amount:       -1500,
+						Reason:       reason,
+						CreatedAt:    time.Now(),
+					}, nil
+				})
+
+			mux := http.NewServeMux()
+			mux.Handle(
+				"PUT /api/admin/users/{id}/status",
+				NewAdminUserStatusHandler(m),
+			)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxPrincipalKey, admin)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodPut,
+				"/api/admin/users/7/status",
+				strings.NewReader(tc.body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantBodySubstr != "" {
+				assert.Contains(t, rec.Body.String(), tc.wantBodySubstr)
+			}
+		})
+	}
+}
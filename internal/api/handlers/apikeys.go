@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+//go:generate mockgen -destination ./mocks/apikeys_mock.go . APIKeysService
+type APIKeysService interface {
+	CreateAPIKey(
+		ctx context.Context,
+		userID int,
+		name string,
+		scopes []string,
+		expiresAt time.Time,
+	) (*model.APIKey, string, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID, id int) error
+}
+
+type createAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresAt необязателен, по умолчанию ключ действует 90 дней.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APIKeyResponse struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+	// Key сам ключ, только в ответе на создание.
+	Key        string   `json:"key,omitempty"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+func NewAPIKeyCreateHandler(svc APIKeysService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := UserIDFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		var req createAPIKeyRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		var expiresAt time.Time
+		if req.ExpiresAt != nil {
+			expiresAt = *req.ExpiresAt
+		}
+
+		k, raw, err := svc.CreateAPIKey(
+			r.Context(),
+			userID,
+			req.Name,
+			req.Scopes,
+			expiresAt,
+		)
+		if err != nil {
+			if errors.Is(err, model.ErrInvalidAPIKeyParams) {
+				slog.Warn("invalid api key request", slog.Any("error", err))
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			slog.Error(
+				"failed to create api key",
+				slog.Int("user_id", userID),
+				slog.Any("error", err),
+			)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		resp := newAPIKeyResponse(k)
+		resp.Key = raw
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			slog.Error("encode api key error", slog.Any("error", err))
+		}
+	})
+}
+
+func NewAPIKeysGetHandler(svc APIKeysService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := UserIDFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		keys, err := svc.GetAPIKeys(r.Context(), userID)
+		if err != nil {
+			slog.Error("api keys request error", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		response := make([]APIKeyResponse, 0, len(keys))
+		for i := range keys {
+			response = append(response, newAPIKeyResponse(&keys[i]))
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.Error("encode api keys error", slog.Any("error", err))
+		}
+	})
+}
+
+func NewAPIKeyDeleteHandler(svc APIKeysService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := UserIDFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id <= 0 {
+			http.Error(w, "invalid api key id", http.StatusBadRequest)
+			return
+		}
+
+		if err := svc.DeleteAPIKey(r.Context(), userID, id); err != nil {
+			if errors.Is(err, model.ErrAPIKeyNotFound) {
+				http.Error(
+					w,
+					http.StatusText(http.StatusNotFound),
+					http.StatusNotFound,
+				)
+				return
+			}
+			slog.Error(
+				"failed to delete api key",
+				slog.Int("user_id", userID),
+				slog.Int("key_id", id),
+				slog.Any("error", err),
+			)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func newAPIKeyResponse(k *model.APIKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		ExpiresAt: k.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if k.LastUsedAt != nil {
+		resp.LastUsedAt = k.LastUsedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mock_handlers "github.com/fragpit/gophermart/internal/api/handlers/mocks"
+	"github.com/fragpit/gophermart/internal/api/middleware"
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAPIKeyCreateHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		body       string
+		authUserID int
+		prepare    func(*mock_handlers.MockAPIKeysService)
+		wantCode   int
+		wantKey    string
+	}{
+		{
+			name:       "created",
+			body:       `{"name":"pos","scopes":["orders:write"],"expires_at":"2030-01-02T03:04:05Z"}`,
+			authUserID: 7,
+			prepare: func(m *mock_handlers.MockAPIKeysService) {
+				m.EXPECT().CreateAPIKey(
+					gomock.Any(),
+					7,
+					"pos",
+					[]string{model.ScopeOrdersWrite},
+					expiresAt,
+				).Return(&model.APIKey{
+					ID:        3,
+					Name:      "pos",
+					Prefix:    "gm_abcdefgh",
+					Scopes:    []string{model.ScopeOrdersWrite},
+					ExpiresAt: expiresAt,
+				}, "gm_abcdefghsecret", nil)
+			},
+			wantCode: http.StatusCreated,
+			wantKey:  "gm_abcdefghsecret",
+		},
+		{
+			name:       "invalid params",
+			body:       `{"name":"pos","scopes":["admin"]}`,
+			authUserID: 7,
+			prepare: func(m *mock_handlers.MockAPIKeysService) {
+				m.EXPECT().CreateAPIKey(
+					gomock.Any(),
+					7,
+					"pos",
+					[]string{"admin"},
+					time.Time{},
+				).Return(nil, "", model.ErrInvalidAPIKeyParams)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:       "internal error",
+			body:       `{"name":"pos","scopes":["orders:write"]}`,
+			authUserID: 7,
+			prepare: func(m *mock_handlers.MockAPIKeysService) {
+				m.EXPECT().CreateAPIKey(
+					gomock.Any(),
+					gomock.Any(),
+					gomock.Any(),
+					gomock.Any(),
+					gomock.Any(),
+				).Return(nil, "", errors.New("db error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "unauthenticated",
+			body:     `{}`,
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockAPIKeysService(ctrl)
+			if tc.prepare != nil {
+				tc.prepare(m)
+			}
+
+			ctx := context.Background()
+			if tc.authUserID != 0 {
+				ctx = context.WithValue(ctx, middleware.CtxUserIDKey, tc.authUserID)
+			}
+
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/api/user/keys",
+				strings.NewReader(tc.body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			NewAPIKeyCreateHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantKey != "" {
+				var resp APIKeyResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				assert.Equal(t, tc.wantKey, resp.Key)
+				assert.Equal(t, "2030-01-02T03:04:05Z", resp.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestAPIKeysGetHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	usedAt := time.Now()
+
+	tests := []struct {
+		name     string
+		keys     []model.APIKey
+		err      error
+		wantCode int
+	}{
+		{
+			name: "success",
+			keys: []model.APIKey{
+				{
+					ID:         3,
+					Name:       "pos",
+					Prefix:     "gm_abcdefgh",
+					KeyHash:    "hash",
+					Scopes:     []string{model.ScopeOrdersWrite},
+					ExpiresAt:  time.Now().Add(time.Hour),
+					LastUsedAt: &usedAt,
+				},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "empty",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "internal error",
+			err:      errors.New("db error"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockAPIKeysService(ctrl)
+			m.EXPECT().GetAPIKeys(gomock.Any(), 7).Return(tc.keys, tc.err)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxUserIDKey, 7)
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+
+			NewAPIKeysGetHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			// ключ и его хеш в списке не отдаются
+			assert.NotContains(t, rec.Body.String(), "hash")
+			assert.NotContains(t, rec.Body.String(), `"key"`)
+		})
+	}
+}
+
+func TestAPIKeyDeleteHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name     string
+		id       string
+		err      error
+		call     bool
+		wantCode int
+	}{
+		{
+			name:     "deleted",
+			id:       "3",
+			call:     true,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "not found",
+			id:       "3",
+			call:     true,
+			err:      model.ErrAPIKeyNotFound,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "invalid id",
+			id:       "abc",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockAPIKeysService(ctrl)
+			if tc.call {
+				m.EXPECT().DeleteAPIKey(gomock.Any(), 7, 3).Return(tc.err)
+			}
+
+			mux := http.NewServeMux()
+			mux.Handle("DELETE /api/user/keys/{id}", NewAPIKeyDeleteHandler(m))
+
+			ctx := context.WithValue(t.Context(), middleware.CtxUserIDKey, 7)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodDelete,
+				"/api/user/keys/"+tc.id,
+				nil,
+			)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
@@ -6,15 +6,35 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/fragpit/gophermart/internal/model"
 )
 
 //go:generate mockgen -destination ./mocks/auth_mock.go . AuthService
 type AuthService interface {
-	Register(ctx context.Context, login, password string) (string, error)
-	Login(ctx context.Context, login, password string) (string, error)
+	Register(
+		ctx context.Context,
+		login, password string,
+	) (*model.TokenPair, error)
+	Login(
+		ctx context.Context,
+		login, password, clientIP string,
+	) (*model.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error)
+	Logout(
+		ctx context.Context,
+		p *model.Principal,
+		refreshToken string,
+		all bool,
+	) error
+	ChangePassword(
+		ctx context.Context,
+		p *model.Principal,
+		current, next string,
+	) (*model.TokenPair, error)
 }
 
 type authRequest struct {
@@ -23,15 +43,39 @@ type authRequest struct {
 }
 
 type authResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type passwordPolicyResponse struct {
+	Error      string                    `json:"error"`
+	Violations []model.PasswordViolation `json:"violations"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+	// All завершить все сессии пользователя.
+	All bool `json:"all"`
 }
 
 func NewAuthRegisterHandler(svc AuthService) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var authReq authRequest
-		ValidateParseJSONRequest(w, r, &authReq)
+		if !ValidateParseJSONRequest(w, r, &authReq) {
+			return
+		}
 
-		token, err := svc.Register(r.Context(), authReq.Login, authReq.Password)
+		tokens, err := svc.Register(r.Context(), authReq.Login, authReq.Password)
 		if err != nil {
 			slog.Error(
 				"failed to register user",
@@ -42,11 +86,9 @@ func NewAuthRegisterHandler(svc AuthService) http.Handler {
 			case errors.Is(err, model.ErrUserExists):
 				http.Error(w, http.StatusText(http.StatusConflict), http.StatusConflict)
 			case errors.Is(err, model.ErrPasswordPolicyViolated):
-				http.Error(
-					w,
-					"password policy violated",
-					http.StatusBadRequest,
-				)
+				passwordPolicyJSONResponse(w, err)
+			case errors.Is(err, model.ErrPasswordHasherBusy):
+				passwordHasherBusyResponse(w)
 			default:
 				http.Error(
 					w,
@@ -57,25 +99,44 @@ func NewAuthRegisterHandler(svc AuthService) http.Handler {
 			return
 		}
 
-		authJSONResponse(w, r, token)
+		authJSONResponse(w, r, tokens)
 	})
 }
 
 func NewAuthLoginHandler(svc AuthService) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var authReq authRequest
-		ValidateParseJSONRequest(w, r, &authReq)
+		if !ValidateParseJSONRequest(w, r, &authReq) {
+			return
+		}
 
-		token, err := svc.Login(r.Context(), authReq.Login, authReq.Password)
+		tokens, err := svc.Login(
+			r.Context(),
+			authReq.Login,
+			authReq.Password,
+			ClientIP(r),
+		)
+		var challenge *model.MFAChallengeError
+		if errors.As(err, &challenge) {
+			mfaChallengeResponse(w, challenge)
+			return
+		}
 		if err != nil {
 			slog.Error(
-				"failed to register user",
+				"failed to login user",
 				slog.String("user", authReq.Login),
 				slog.Any("error", err),
 			)
+			var throttled *model.LoginThrottledError
 			switch {
+			case errors.As(err, &throttled):
+				loginThrottledResponse(w, throttled)
 			case errors.Is(err, model.ErrInvalidCredentials):
 				http.Error(w, "wrong username or password", http.StatusUnauthorized)
+			case errors.Is(err, model.ErrAccountClosed):
+				http.Error(w, "account closed", http.StatusForbidden)
+			case errors.Is(err, model.ErrPasswordHasherBusy):
+				passwordHasherBusyResponse(w)
 			default:
 				http.Error(
 					w,
@@ -86,17 +147,196 @@ func NewAuthLoginHandler(svc AuthService) http.Handler {
 			return
 		}
 
-		authJSONResponse(w, r, token)
+		authJSONResponse(w, r, tokens)
 	})
 }
 
+func NewAuthRefreshHandler(svc AuthService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req refreshRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		tokens, err := svc.Refresh(r.Context(), req.RefreshToken)
+		if err != nil {
+			switch {
+			case errors.Is(err, model.ErrInvalidRefreshToken),
+				errors.Is(err, model.ErrRefreshTokenReused):
+				slog.Warn("failed to refresh token", slog.Any("error", err))
+				http.Error(w, "invalid refresh token", http.StatusUnauthorized)
+			default:
+				slog.Error("failed to refresh token", slog.Any("error", err))
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		authJSONResponse(w, r, tokens)
+	})
+}
+
+func NewAuthLogoutHandler(svc AuthService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		// тело необязательно: без него отзывается только access токен
+		var req logoutRequest
+		if r.ContentLength != 0 && !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		if err := svc.Logout(
+			r.Context(),
+			p,
+			req.RefreshToken,
+			req.All,
+		); err != nil {
+			slog.Error(
+				"failed to logout",
+				slog.Int("user_id", p.UserID),
+				slog.Any("error", err),
+			)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// NewChangePasswordHandler меняет пароль и отзывает все сессии
+// пользователя, в ответе - новая пара токенов для текущего клиента.
+func NewChangePasswordHandler(svc AuthService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		var req changePasswordRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		tokens, err := svc.ChangePassword(
+			r.Context(),
+			p,
+			req.CurrentPassword,
+			req.NewPassword,
+		)
+		if err != nil {
+			slog.Warn(
+				"failed to change password",
+				slog.Int("user_id", p.UserID),
+				slog.Any("error", err),
+			)
+			var throttled *model.LoginThrottledError
+			switch {
+			case errors.As(err, &throttled):
+				loginThrottledResponse(w, throttled)
+			case errors.Is(err, model.ErrInvalidCredentials):
+				http.Error(w, "wrong current password", http.StatusForbidden)
+			case errors.Is(err, model.ErrPasswordPolicyViolated):
+				passwordPolicyJSONResponse(w, err)
+			case errors.Is(err, model.ErrPasswordHasherBusy):
+				passwordHasherBusyResponse(w)
+			default:
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		authJSONResponse(w, r, tokens)
+	})
+}
+
+func loginThrottledResponse(
+	w http.ResponseWriter,
+	throttled *model.LoginThrottledError,
+) {
+	retryAfter := max(int(math.Ceil(throttled.RetryAfter.Seconds())), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	http.Error(
+		w,
+		"too many failed login attempts",
+		http.StatusTooManyRequests,
+	)
+}
+
+// passwordHasherBusyResponse 503: все слоты хеширования паролей заняты.
+func passwordHasherBusyResponse(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	http.Error(
+		w,
+		http.StatusText(http.StatusServiceUnavailable),
+		http.StatusServiceUnavailable,
+	)
+}
+
+// passwordPolicyJSONResponse 400 со списком нарушенных правил.
+func passwordPolicyJSONResponse(w http.ResponseWriter, err error) {
+	resp := passwordPolicyResponse{
+		Error:      model.ErrPasswordPolicyViolated.Error(),
+		Violations: []model.PasswordViolation{},
+	}
+	var policyErr *model.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		resp.Violations = policyErr.Violations
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		slog.Warn("failed to marshal json response", slog.Any("error", err))
+		http.Error(
+			w,
+			http.StatusText(http.StatusInternalServerError),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if _, err := w.Write(b); err != nil {
+		slog.Warn("failed to write response", slog.Any("error", err))
+	}
+}
+
 func authJSONResponse(
 	w http.ResponseWriter,
 	r *http.Request,
-	token string,
+	tokens *model.TokenPair,
 ) {
 	authResp := &authResponse{
-		Token: token,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    int64(tokens.ExpiresIn.Seconds()),
 	}
 
 	b, err := json.Marshal(authResp)
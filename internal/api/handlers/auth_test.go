@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -8,9 +9,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	mock_handlers "github.com/fragpit/gophermart/internal/api/handlers/mocks"
+	"github.com/fragpit/gophermart/internal/api/middleware"
 	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
 
@@ -109,7 +113,7 @@ func TestNewAuthRegisterHandler(t *testing.T) {
 
 			m.EXPECT().
 				Register(gomock.Any(), gomock.Any(), gomock.Any()).
-				Return(tc.mockData.token, tc.mockData.err).AnyTimes()
+				Return(tokenPair(tc.mockData.token), tc.mockData.err).AnyTimes()
 			handler := NewAuthRegisterHandler(m)
 			rec := httptest.NewRecorder()
 
@@ -182,6 +186,7 @@ func TestNewAuthLoginHandler(t *testing.T) {
 		wantCode       int
 		wantBodySubstr string
 		wantAuthHeader string
+		wantRetryAfter string
 	}{
 		{
 			name: "success",
@@ -210,6 +215,65 @@ func TestNewAuthLoginHandler(t *testing.T) {
 			wantCode:       http.StatusUnauthorized,
 			wantBodySubstr: "wrong username or password",
 		},
+		{
+			name: "account closed",
+			mockData: &mockData{
+				token: "",
+				err:   model.ErrAccountClosed,
+			},
+			reqBody: &authRequest{
+				Login:    "u",
+				Password: "p",
+			},
+			wantCode:       http.StatusForbidden,
+			wantBodySubstr: "account closed",
+		},
+		{
+			name: "two-factor code required",
+			mockData: &mockData{
+				token: "",
+				err: &model.MFAChallengeError{
+					Token:     "mfa123",
+					ExpiresIn: 5 * time.Minute,
+				},
+			},
+			reqBody: &authRequest{
+				Login:    "u",
+				Password: "p",
+			},
+			wantCode:       http.StatusAccepted,
+			wantBodySubstr: `{"mfa_required":true,"mfa_token":"mfa123","expires_in":300}`,
+		},
+		{
+			name: "too many attempts",
+			mockData: &mockData{
+				token: "",
+				err: &model.LoginThrottledError{
+					RetryAfter: 1500 * time.Millisecond,
+				},
+			},
+			reqBody: &authRequest{
+				Login:    "u",
+				Password: "p",
+			},
+			wantCode:       http.StatusTooManyRequests,
+			wantBodySubstr: "too many failed login attempts",
+			wantRetryAfter: "2",
+		},
+		{
+			name: "password hasher busy",
+			mockData: &mockData{
+				token: "",
+				err:   model.ErrPasswordHasherBusy,
+			},
+			reqBody: &authRequest{
+				Login:    "u",
+				Password: "p",
+			},
+			wantCode:       http.StatusServiceUnavailable,
+			wantBodySubstr: http.StatusText(http.StatusServiceUnavailable),
+			wantRetryAfter: "1",
+		},
 		{
 			name: "internal error",
 			mockData: &mockData{
@@ -247,8 +311,8 @@ func TestNewAuthLoginHandler(t *testing.T) {
 			m := mock_handlers.NewMockAuthService(ctrl)
 
 			m.EXPECT().
-				Login(gomock.Any(), gomock.Any(), gomock.Any()).
-				Return(tc.mockData.token, tc.mockData.err).AnyTimes()
+				Login(gomock.Any(), gomock.Any(), gomock.Any(), "192.0.2.1").
+				Return(tokenPair(tc.mockData.token), tc.mockData.err).AnyTimes()
 			handler := NewAuthLoginHandler(m)
 			rec := httptest.NewRecorder()
 
@@ -283,6 +347,14 @@ func TestNewAuthLoginHandler(t *testing.T) {
 				)
 			}
 
+			if got := rec.Header().Get("Retry-After"); got != tc.wantRetryAfter {
+				t.Fatalf(
+					"unexpected Retry-After header: got %q want %q",
+					got,
+					tc.wantRetryAfter,
+				)
+			}
+
 			if tc.wantAuthHeader != "" {
 				got := rec.Header().Get("Authorization")
 				if got != tc.wantAuthHeader {
@@ -304,3 +376,260 @@ func TestNewAuthLoginHandler(t *testing.T) {
 		})
 	}
 }
+
+func tokenPair(token string) *model.TokenPair {
+	return &model.TokenPair{AccessToken: token}
+}
+
+func TestNewAuthRefreshHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name     string
+		tokens   *model.TokenPair
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "success",
+			tokens: &model.TokenPair{
+				AccessToken:  "access",
+				RefreshToken: "refresh",
+				ExpiresIn:    15 * time.Minute,
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"token":"access","refresh_token":"refresh","expires_in":900}`,
+		},
+		{
+			name:     "invalid token",
+			err:      model.ErrInvalidRefreshToken,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "reused token",
+			err:      model.ErrRefreshTokenReused,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "internal error",
+			err:      fmt.Errorf("db down"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_handlers.NewMockAuthService(ctrl)
+			m.EXPECT().Refresh(gomock.Any(), "old").Return(tc.tokens, tc.err)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(`{"refresh_token":"old"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			NewAuthRefreshHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, rec.Body.String())
+				assert.Equal(t, "Bearer access", rec.Header().Get("Authorization"))
+			}
+		})
+	}
+}
+
+func TestNewAuthLogoutHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7, TokenID: "jti"}
+
+	tests := []struct {
+		name        string
+		principal   *model.Principal
+		body        string
+		badBody     bool
+		wantRefresh string
+		wantAll     bool
+		err         error
+		wantCode    int
+	}{
+		{
+			name:      "without body",
+			principal: p,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:        "with refresh token",
+			principal:   p,
+			body:        `{"refresh_token":"refresh"}`,
+			wantRefresh: "refresh",
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:      "all sessions",
+			principal: p,
+			body:      `{"all":true}`,
+			wantAll:   true,
+			wantCode:  http.StatusOK,
+		},
+		{
+			name:      "internal error",
+			principal: p,
+			err:       fmt.Errorf("db down"),
+			wantCode:  http.StatusInternalServerError,
+		},
+		{
+			name:      "invalid body",
+			principal: p,
+			body:      `{"all":`,
+			badBody:   true,
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name:     "no principal",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_handlers.NewMockAuthService(ctrl)
+			if tc.principal != nil && !tc.badBody {
+				m.EXPECT().
+					Logout(gomock.Any(), tc.principal, tc.wantRefresh, tc.wantAll).
+					Return(tc.err)
+			}
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/",
+				strings.NewReader(tc.body),
+			)
+			if tc.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			if tc.principal != nil {
+				req = req.WithContext(context.WithValue(
+					req.Context(),
+					middleware.CtxPrincipalKey,
+					tc.principal,
+				))
+			}
+			rec := httptest.NewRecorder()
+
+			NewAuthLogoutHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestNewChangePasswordHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7, TokenID: "jti"}
+	body := `{"current_password":"old_password","new_password":"new_password"}`
+
+	tests := []struct {
+		name           string
+		principal      *model.Principal
+		err            error
+		wantCode       int
+		wantBodySubstr string
+		wantRetryAfter string
+	}{
+		{
+			name:           "success",
+			principal:      p,
+			wantCode:       http.StatusOK,
+			wantBodySubstr: `"token":"tok123"`,
+		},
+		{
+			name:           "wrong current password",
+			principal:      p,
+			err:            model.ErrInvalidCredentials,
+			wantCode:       http.StatusForbidden,
+			wantBodySubstr: "wrong current password",
+		},
+		{
+			name:      "policy violated",
+			principal: p,
+			err: &model.PasswordPolicyError{
+				Violations: []model.PasswordViolation{
+					{Rule: "length", Message: "too short"},
+					{Rule: "contains_login", Message: "contains login"},
+				},
+			},
+			wantCode: http.StatusBadRequest,
+			wantBodySubstr: `{"error":"password policy violated","violations":[` +
+				`{"rule":"length","message":"too short"},` +
+				`{"rule":"contains_login","message":"contains login"}]}`,
+		},
+		{
+			name:           "too many attempts",
+			principal:      p,
+			err:            &model.LoginThrottledError{RetryAfter: time.Minute},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "60",
+		},
+		{
+			name:      "internal error",
+			principal: p,
+			err:       fmt.Errorf("db down"),
+			wantCode:  http.StatusInternalServerError,
+		},
+		{
+			name:     "no principal",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_handlers.NewMockAuthService(ctrl)
+			if tc.principal != nil {
+				var tokens *model.TokenPair
+				if tc.err == nil {
+					tokens = tokenPair("tok123")
+				}
+				m.EXPECT().
+					ChangePassword(gomock.Any(), tc.principal, "old_password", "new_password").
+					Return(tokens, tc.err)
+			}
+
+			req := httptest.NewRequest(
+				http.MethodPut,
+				"/",
+				strings.NewReader(body),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			if tc.principal != nil {
+				req = req.WithContext(context.WithValue(
+					req.Context(),
+					middleware.CtxPrincipalKey,
+					tc.principal,
+				))
+			}
+			rec := httptest.NewRecorder()
+
+			NewChangePasswordHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), tc.wantBodySubstr)
+			assert.Equal(t, tc.wantRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
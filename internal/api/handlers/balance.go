@@ -101,7 +101,7 @@ type balanceWithdrawRequest struct {
 
 func NewBalanceWithdrawHandler(svc BalanceService) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Header.Get("Content-Type") != "application/json" {
+		if mediaType(r) != "application/json" {
 			slog.Error(
 				"request with an empty or unsupported content type",
 				slog.String("content_type", r.Header.Get("Content-Type")),
@@ -2,13 +2,23 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
+
+	"github.com/fragpit/gophermart/internal/model"
 )
 
 //go:generate mockgen -destination ./mocks/health_mock.go . HealthService
 type HealthService interface {
 	Check(ctx context.Context) error
+	Components() map[string]string
+	Ready(ctx context.Context) *model.HealthReport
+}
+
+type healthResponse struct {
+	Status     string            `json:"status"`
+	Components map[string]string `json:"components,omitempty"`
 }
 
 func NewHealthHandler(
@@ -24,6 +34,74 @@ func NewHealthHandler(
 			)
 			return
 		}
+
+		resp := &healthResponse{
+			Status:     "ok",
+			Components: svc.Components(),
+		}
+
+		b, err := json.Marshal(resp)
+		if err != nil {
+			slog.Warn("failed to marshal json response", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(b); err != nil {
+			slog.Warn("failed to write response", slog.Any("error", err))
+			return
+		}
+	})
+}
+
+// NewLivenessHandler /livez: процесс жив и обслуживает запросы, внешние
+// зависимости не проверяются.
+func NewLivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(`{"status":"ok"}`)); err != nil {
+			slog.Warn("failed to write response", slog.Any("error", err))
+		}
+	})
+}
+
+// NewReadinessHandler /readyz: отчёт по проверкам, 503 при неуспехе
+// критичной проверки.
+func NewReadinessHandler(
+	svc HealthService,
+) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := svc.Ready(r.Context())
+
+		b, err := json.Marshal(report)
+		if err != nil {
+			slog.Warn("failed to marshal json response", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		code := http.StatusOK
+		if report.Status == model.HealthFail {
+			slog.Warn("readiness check failed", slog.String("report", string(b)))
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if _, err := w.Write(b); err != nil {
+			slog.Warn("failed to write response", slog.Any("error", err))
+			return
+		}
 	})
 }
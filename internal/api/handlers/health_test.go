@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	mock_handlers "github.com/fragpit/gophermart/internal/api/handlers/mocks"
+	"github.com/fragpit/gophermart/internal/model"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
@@ -23,12 +24,22 @@ func TestHealthHandler(t *testing.T) {
 	tests := []struct {
 		name        string
 		returnError error
+		components  map[string]string
 		wantCode    int
+		wantBody    string
 	}{
 		{
 			name:        "success",
 			returnError: nil,
 			wantCode:    http.StatusOK,
+			wantBody:    `{"status":"ok"}`,
+		},
+		{
+			name:        "success with components",
+			returnError: nil,
+			components:  map[string]string{"accrual_breaker": "open"},
+			wantCode:    http.StatusOK,
+			wantBody:    `{"status":"ok","components":{"accrual_breaker":"open"}}`,
 		},
 		{
 			name:        "fail",
@@ -40,6 +51,9 @@ func TestHealthHandler(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			m.EXPECT().Check(context.Background()).Return(tc.returnError)
+			if tc.returnError == nil {
+				m.EXPECT().Components().Return(tc.components)
+			}
 
 			handler := NewHealthHandler(m)
 			rec := httptest.NewRecorder()
@@ -47,6 +61,98 @@ func TestHealthHandler(t *testing.T) {
 			handler.ServeHTTP(rec, req)
 
 			assert.Equal(t, tc.wantCode, rec.Code)
+			if tc.wantBody != "" {
+				assert.JSONEq(t, tc.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestLivenessHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/livez", nil)
+	NewLivenessHandler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
+
+func TestReadinessHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mock_handlers.NewMockHealthService(ctrl)
+
+	tests := []struct {
+		name     string
+		report   *model.HealthReport
+		wantCode int
+		wantBody string
+	}{
+		{
+			name: "ready",
+			report: &model.HealthReport{
+				Status: model.HealthOK,
+				Checks: map[string]model.HealthCheck{
+					"database": {
+						Status:   model.HealthOK,
+						Critical: true,
+						Duration: "1ms",
+					},
+				},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"ok","checks":{"database":` +
+				`{"status":"ok","critical":true,"duration":"1ms"}}}`,
+		},
+		{
+			name: "degraded",
+			report: &model.HealthReport{
+				Status: model.HealthDegraded,
+				Checks: map[string]model.HealthCheck{
+					"accrual": {
+						Status:   model.HealthFail,
+						Duration: "2s",
+						Error:    "accrual is unreachable",
+					},
+				},
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"status":"degraded","checks":{"accrual":` +
+				`{"status":"fail","critical":false,"duration":"2s",` +
+				`"error":"accrual is unreachable"}}}`,
+		},
+		{
+			name: "not ready",
+			report: &model.HealthReport{
+				Status: model.HealthFail,
+				Checks: map[string]model.HealthCheck{
+					"migrations": {
+						Status:   model.HealthFail,
+						Critical: true,
+						Duration: "3ms",
+						Details:  map[string]any{"current": 4, "latest": 5},
+					},
+				},
+			},
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: `{"status":"fail","checks":{"migrations":` +
+				`{"status":"fail","critical":true,"duration":"3ms",` +
+				`"details":{"current":4,"latest":5}}}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m.EXPECT().Ready(gomock.Any()).Return(tc.report)
+
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+			NewReadinessHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.JSONEq(t, tc.wantBody, rec.Body.String())
 		})
 	}
 }
@@ -6,9 +6,12 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"mime"
+	"net"
 	"net/http"
 
 	"github.com/fragpit/gophermart/internal/api/middleware"
+	"github.com/fragpit/gophermart/internal/model"
 )
 
 func UserIDFromContext(ctx context.Context) (int, bool) {
@@ -20,13 +23,45 @@ func UserIDFromContext(ctx context.Context) (int, bool) {
 	return id, ok
 }
 
+func PrincipalFromContext(ctx context.Context) (*model.Principal, bool) {
+	p, ok := ctx.Value(middleware.CtxPrincipalKey).(*model.Principal)
+	return p, ok && p != nil
+}
+
+// ClientIP адрес клиента, определённый middleware.RealIP, иначе адрес
+// соединения. X-Forwarded-For учитывается только от доверенных прокси:
+// клиент может подставить в него что угодно.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(middleware.CtxClientIPKey).(string); ok {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// mediaType тип содержимого запроса без параметров (charset и т.п.),
+// пустой - заголовка нет или он некорректен.
+func mediaType(r *http.Request) string {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+// ValidateParseJSONRequest разбирает JSON тело запроса в data. false -
+// ответ с ошибкой уже записан, обработчик должен завершиться.
 func ValidateParseJSONRequest(
 	w http.ResponseWriter,
 	r *http.Request,
 	data any,
-) {
+) bool {
 	// validate header
-	if r.Header.Get("Content-Type") != "application/json" {
+	if mediaType(r) != "application/json" {
 		slog.Error(
 			"request with an empty or unsupported content type",
 			slog.String("content_type", r.Header.Get("Content-Type")),
@@ -36,7 +71,7 @@ func ValidateParseJSONRequest(
 			http.StatusText(http.StatusUnsupportedMediaType),
 			http.StatusUnsupportedMediaType,
 		)
-		return
+		return false
 	}
 
 	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
@@ -54,15 +89,16 @@ func ValidateParseJSONRequest(
 				"request body too large",
 				http.StatusRequestEntityTooLarge,
 			)
-			return
+			return false
 		}
 		http.Error(w, "invalid JSON", http.StatusBadRequest)
-		return
+		return false
 	}
 
 	if err := dec.Decode(&struct{}{}); err != io.EOF {
 		slog.Warn("invalid JSON", slog.Any("error", err))
 		http.Error(w, "invalid JSON", http.StatusBadRequest)
-		return
+		return false
 	}
+	return true
 }
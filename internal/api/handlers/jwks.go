@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+//go:generate mockgen -destination ./mocks/jwks_mock.go . JWKSProvider
+type JWKSProvider interface {
+	JWKS() *model.JWKSet
+}
+
+// NewJWKSHandler публичные ключи для проверки токенов другими сервисами.
+func NewJWKSHandler(p JWKSProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := json.Marshal(p.JWKS())
+		if err != nil {
+			slog.Warn("failed to marshal json response", slog.Any("error", err))
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		// ключи меняются только при ротации, клиентам достаточно
+		// перечитывать набор раз в несколько минут
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if _, err := w.Write(b); err != nil {
+			slog.Warn("failed to write response", slog.Any("error", err))
+			return
+		}
+	})
+}
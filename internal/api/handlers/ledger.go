@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+//go:generate mockgen -destination ./mocks/ledger_mock.go . LedgerService
+type LedgerService interface {
+	GetEntriesByUser(
+		ctx context.Context,
+		userID int,
+	) ([]model.LedgerEntry, error)
+}
+
+type ledgerEntryResponse struct {
+	Kind      model.LedgerEntryKind `json:"kind"`
+	Amount    model.Kopek           `json:"amount"`
+	Reference string                `json:"reference"`
+	CreatedAt string                `json:"created_at"`
+}
+
+func NewLedgerHandler(svc LedgerService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var userID int
+		var ok bool
+		ctx := r.Context()
+		if userID, ok = UserIDFromContext(ctx); !ok {
+			slog.Error(
+				"ledger request error",
+				slog.String("error", "failed to get user id from context"),
+			)
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		entries, err := svc.GetEntriesByUser(ctx, userID)
+		if err != nil {
+			slog.Error(
+				"ledger request error",
+				slog.Any("error", err),
+			)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(entries) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var response []ledgerEntryResponse
+		for _, e := range entries {
+			r := ledgerEntryResponse{
+				Kind:      e.Kind,
+				Amount:    e.Amount,
+				Reference: e.Reference,
+				CreatedAt: e.CreatedAt.Format(time.RFC3339),
+			}
+			response = append(response, r)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.Error("encode ledger error", slog.Any("error", err))
+		}
+	})
+}
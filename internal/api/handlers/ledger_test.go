@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mock_handlers "github.com/fragpit/gophermart/internal/api/handlers/mocks"
+	"github.com/fragpit/gophermart/internal/api/middleware"
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLedgerHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	type mockData struct {
+		entries []model.LedgerEntry
+		err     error
+	}
+
+	tests := []struct {
+		name           string
+		mockData       mockData
+		authUserID     int
+		wantCode       int
+		wantBodySubstr string
+	}{
+		{
+			name: "success",
+			mockData: mockData{
+				entries: []model.LedgerEntry{
+					{
+						ID:        2,
+						UserID:    1,
+						Kind:      model.EntryWithdrawal,
+						Amount:    -150,
+						Reference: orderNumByLuhn,
+						CreatedAt: time.Now(),
+					},
+					{
+						ID:        1,
+						UserID:    1,
+						Kind:      model.EntryAccrual,
+						Amount:    500,
+						Reference: orderNumByLuhn,
+						CreatedAt: time.Now(),
+					},
+				},
+				err: nil,
+			},
+			authUserID:     1,
+			wantCode:       http.StatusOK,
+			wantBodySubstr: `"kind":"WITHDRAWAL","amount":-1.5`,
+		},
+		{
+			name: "success empty",
+			mockData: mockData{
+				entries: []model.LedgerEntry{},
+				err:     nil,
+			},
+			authUserID: 1,
+			wantCode:   http.StatusNoContent,
+		},
+		{
+			name:       "fail unauthenticated",
+			mockData:   mockData{},
+			authUserID: 0,
+			wantCode:   http.StatusUnauthorized,
+		},
+		{
+			name: "fail internal",
+			mockData: mockData{
+				err: errors.New("db error"),
+			},
+			authUserID: 1,
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mock_handlers.NewMockLedgerService(ctrl)
+			m.EXPECT().
+				GetEntriesByUser(gomock.Any(), gomock.Any()).
+				Return(tc.mockData.entries, tc.mockData.err).
+				AnyTimes()
+
+			handler := NewLedgerHandler(m)
+			rec := httptest.NewRecorder()
+
+			var ctx context.Context
+			if tc.authUserID != 0 {
+				ctx = context.WithValue(
+					t.Context(),
+					middleware.CtxUserIDKey,
+					tc.authUserID,
+				)
+			} else {
+				ctx = context.Background()
+			}
+
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.True(t, strings.Contains(rec.Body.String(), tc.wantBodySubstr))
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+//go:generate mockgen -destination ./mocks/mfa_mock.go . MFAService
+type MFAService interface {
+	EnrollTOTP(
+		ctx context.Context,
+		p *model.Principal,
+	) (*model.MFAEnrollment, error)
+	ConfirmTOTP(
+		ctx context.Context,
+		p *model.Principal,
+		code string,
+	) ([]string, error)
+	DisableTOTP(
+		ctx context.Context,
+		p *model.Principal,
+		code, password string,
+	) error
+	SetWithdrawMFA(
+		ctx context.Context,
+		p *model.Principal,
+		required bool,
+		code, password string,
+	) error
+	LoginMFA(
+		ctx context.Context,
+		token, code, clientIP string,
+	) (*model.TokenPair, error)
+}
+
+type mfaChallengeJSON struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type mfaLoginRequest struct {
+	MFAToken string `json:"mfa_token"`
+	// Code код из приложения или код восстановления.
+	Code string `json:"code"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type mfaEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type mfaDisableRequest struct {
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+type mfaWithdrawRequest struct {
+	Required bool   `json:"required"`
+	Code     string `json:"code"`
+	// Password нужен только для отключения.
+	Password string `json:"password"`
+}
+
+// NewMFALoginHandler POST /api/user/login/mfa - второй шаг входа по
+// mfa_token из ответа /api/user/login.
+func NewMFALoginHandler(svc MFAService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req mfaLoginRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		tokens, err := svc.LoginMFA(
+			r.Context(),
+			req.MFAToken,
+			req.Code,
+			ClientIP(r),
+		)
+		if err != nil {
+			slog.Warn("failed to complete mfa login", slog.Any("error", err))
+			var throttled *model.LoginThrottledError
+			switch {
+			case errors.As(err, &throttled):
+				loginThrottledResponse(w, throttled)
+			case errors.Is(err, model.ErrInvalidMFAChallenge):
+				http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+			case errors.Is(err, model.ErrInvalidMFACode):
+				http.Error(w, "invalid two-factor code", http.StatusUnauthorized)
+			case errors.Is(err, model.ErrAccountClosed):
+				http.Error(w, "account closed", http.StatusForbidden)
+			default:
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+			}
+			return
+		}
+
+		authJSONResponse(w, r, tokens)
+	})
+}
+
+// NewMFAEnrollHandler POST /api/user/mfa/totp - секрет и otpauth://
+// ссылка, второй фактор заработает после подтверждения кодом.
+func NewMFAEnrollHandler(svc MFAService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		enrollment, err := svc.EnrollTOTP(r.Context(), p)
+		if err != nil {
+			if errors.Is(err, model.ErrMFAAlreadyEnabled) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			slog.Error(
+				"failed to enroll totp",
+				slog.Int("user_id", p.UserID),
+				slog.Any("error", err),
+			)
+			http.Error(
+				w,
+				http.StatusText(http.StatusInternalServerError),
+				http.StatusInternalServerError,
+			)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(mfaEnrollResponse{
+			Secret: enrollment.Secret,
+			URI:    enrollment.URI,
+		}); err != nil {
+			slog.Error("encode mfa enrollment error", slog.Any("error", err))
+		}
+	})
+}
+
+// NewMFAConfirmHandler POST /api/user/mfa/totp/confirm - подтверждение
+// первым кодом, в ответе коды восстановления.
+func NewMFAConfirmHandler(svc MFAService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		var req mfaCodeRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		codes, err := svc.ConfirmTOTP(r.Context(), p, req.Code)
+		if err != nil {
+			slog.Warn(
+				"failed to confirm totp",
+				slog.Int("user_id", p.UserID),
+				slog.Any("error", err),
+			)
+			mfaErrorResponse(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(
+			recoveryCodesResponse{RecoveryCodes: codes},
+		); err != nil {
+			slog.Error("encode recovery codes error", slog.Any("error", err))
+		}
+	})
+}
+
+// NewMFADisableHandler DELETE /api/user/mfa/totp, подтверждается паролем
+// и кодом из приложения или кодом восстановления.
+func NewMFADisableHandler(svc MFAService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		var req mfaDisableRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		if err := svc.DisableTOTP(
+			r.Context(),
+			p,
+			req.Code,
+			req.Password,
+		); err != nil {
+			slog.Warn(
+				"failed to disable totp",
+				slog.Int("user_id", p.UserID),
+				slog.Any("error", err),
+			)
+			mfaErrorResponse(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// NewMFAWithdrawHandler PUT /api/user/mfa/withdraw - требовать ли код
+// второго фактора (X-MFA-Code) при списании баллов.
+func NewMFAWithdrawHandler(svc MFAService) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := PrincipalFromContext(r.Context())
+		if !ok {
+			http.Error(
+				w,
+				http.StatusText(http.StatusUnauthorized),
+				http.StatusUnauthorized,
+			)
+			return
+		}
+
+		var req mfaWithdrawRequest
+		if !ValidateParseJSONRequest(w, r, &req) {
+			return
+		}
+
+		if err := svc.SetWithdrawMFA(
+			r.Context(),
+			p,
+			req.Required,
+			req.Code,
+			req.Password,
+		); err != nil {
+			slog.Warn(
+				"failed to update withdraw mfa setting",
+				slog.Int("user_id", p.UserID),
+				slog.Any("error", err),
+			)
+			mfaErrorResponse(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func mfaChallengeResponse(
+	w http.ResponseWriter,
+	challenge *model.MFAChallengeError,
+) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(mfaChallengeJSON{
+		MFARequired: true,
+		MFAToken:    challenge.Token,
+		ExpiresIn:   int64(challenge.ExpiresIn.Seconds()),
+	}); err != nil {
+		slog.Error("encode mfa challenge error", slog.Any("error", err))
+	}
+}
+
+func mfaErrorResponse(w http.ResponseWriter, err error) {
+	var throttled *model.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		loginThrottledResponse(w, throttled)
+	case errors.Is(err, model.ErrInvalidMFACode):
+		http.Error(w, "invalid two-factor code", http.StatusForbidden)
+	case errors.Is(err, model.ErrInvalidCredentials):
+		http.Error(w, "wrong password", http.StatusForbidden)
+	case errors.Is(err, model.ErrPasswordHasherBusy):
+		passwordHasherBusyResponse(w)
+	case errors.Is(err, model.ErrMFANotEnrolled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, model.ErrMFAAlreadyEnabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(
+			w,
+			http.StatusText(http.StatusInternalServerError),
+			http.StatusInternalServerError,
+		)
+	}
+}
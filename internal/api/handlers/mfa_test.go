@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mock_handlers "github.com/fragpit/gophermart/internal/api/handlers/mocks"
+	"github.com/fragpit/gophermart/internal/api/middleware"
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMFALoginHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name           string
+		err            error
+		wantCode       int
+		wantAuthHeader string
+		wantRetryAfter string
+	}{
+		{
+			name:           "success",
+			wantCode:       http.StatusOK,
+			wantAuthHeader: "Bearer tok123",
+		},
+		{
+			name:     "expired token",
+			err:      model.ErrInvalidMFAChallenge,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "wrong code",
+			err:      model.ErrInvalidMFACode,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:           "too many attempts",
+			err:            &model.LoginThrottledError{RetryAfter: time.Minute},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "60",
+		},
+		{
+			name:     "account closed",
+			err:      model.ErrAccountClosed,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "internal error",
+			err:      errors.New("db error"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockMFAService(ctrl)
+			var tokens *model.TokenPair
+			if tc.err == nil {
+				tokens = tokenPair("tok123")
+			}
+			m.EXPECT().
+				LoginMFA(gomock.Any(), "mfa123", "123456", "192.0.2.1").
+				Return(tokens, tc.err)
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/user/login/mfa",
+				strings.NewReader(`{"mfa_token": "mfa123", "code": "123456"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			NewMFALoginHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantAuthHeader, rec.Header().Get("Authorization"))
+			assert.Equal(t, tc.wantRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestMFAEnrollHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+
+	tests := []struct {
+		name           string
+		enrollment     *model.MFAEnrollment
+		err            error
+		wantCode       int
+		wantBodySubstr string
+	}{
+		{
+			name: "enrolled",
+			enrollment: &model.MFAEnrollment{
+				Secret: "JBSWY3DPEHPK3PXP",
+				URI:    "otpauth://totp/gophermart:alice?secret=JBSWY3DPEHPK3PXP",
+			},
+			wantCode:       http.StatusOK,
+			wantBodySubstr: `"secret":"JBSWY3DPEHPK3PXP","uri":"otpauth://totp/`,
+		},
+		{
+			name:     "already enabled",
+			err:      model.ErrMFAAlreadyEnabled,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "internal error",
+			err:      errors.New("db error"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockMFAService(ctrl)
+			m.EXPECT().EnrollTOTP(gomock.Any(), p).Return(tc.enrollment, tc.err)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxPrincipalKey, p)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/api/user/mfa/totp",
+				nil,
+			)
+			rec := httptest.NewRecorder()
+
+			NewMFAEnrollHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), tc.wantBodySubstr)
+		})
+	}
+}
+
+func TestMFAConfirmHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+
+	tests := []struct {
+		name           string
+		codes          []string
+		err            error
+		wantCode       int
+		wantBodySubstr string
+	}{
+		{
+			name:           "confirmed",
+			codes:          []string{"abcde-fghij", "klmno-pqrst"},
+			wantCode:       http.StatusOK,
+			wantBodySubstr: `{"recovery_codes":["abcde-fghij","klmno-pqrst"]}`,
+		},
+		{
+			name:     "wrong code",
+			err:      model.ErrInvalidMFACode,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "not enrolled",
+			err:      model.ErrMFANotEnrolled,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "internal error",
+			err:      errors.New("db error"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockMFAService(ctrl)
+			m.EXPECT().ConfirmTOTP(gomock.Any(), p, "123456").Return(tc.codes, tc.err)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxPrincipalKey, p)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodPost,
+				"/api/user/mfa/totp/confirm",
+				strings.NewReader(`{"code": "123456"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			NewMFAConfirmHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), tc.wantBodySubstr)
+		})
+	}
+}
+
+func TestMFADisableHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+
+	tests := []struct {
+		name           string
+		err            error
+		wantCode       int
+		wantRetryAfter string
+	}{
+		{
+			name:     "disabled",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "wrong code",
+			err:      model.ErrInvalidMFACode,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "wrong password",
+			err:      model.ErrInvalidCredentials,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:           "too many attempts",
+			err:            &model.LoginThrottledError{RetryAfter: time.Minute},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "60",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockMFAService(ctrl)
+			m.EXPECT().
+				DisableTOTP(gomock.Any(), p, "abcde-fghij", "secret").
+				Return(tc.err)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxPrincipalKey, p)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodDelete,
+				"/api/user/mfa/totp",
+				strings.NewReader(`{"code": "abcde-fghij", "password": "secret"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			NewMFADisableHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestMFAWithdrawHandler(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{
+			name:     "updated",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "not enabled",
+			err:      model.ErrMFANotEnrolled,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "wrong code",
+			err:      model.ErrInvalidMFACode,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			m := mock_handlers.NewMockMFAService(ctrl)
+			m.EXPECT().
+				SetWithdrawMFA(gomock.Any(), p, true, "123456", "").
+				Return(tc.err)
+
+			ctx := context.WithValue(t.Context(), middleware.CtxPrincipalKey, p)
+			req, _ := http.NewRequestWithContext(
+				ctx,
+				http.MethodPut,
+				"/api/user/mfa/withdraw",
+				strings.NewReader(`{"required": true, "code": "123456"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			NewMFAWithdrawHandler(m).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
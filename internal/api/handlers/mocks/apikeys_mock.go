@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/api/handlers (interfaces: APIKeysService)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/apikeys_mock.go . APIKeysService
+//
+
+// Package mock_handlers is a generated GoMock package.
+package mock_handlers
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAPIKeysService is a mock of APIKeysService interface.
+type MockAPIKeysService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeysServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeysServiceMockRecorder is the mock recorder for MockAPIKeysService.
+type MockAPIKeysServiceMockRecorder struct {
+	mock *MockAPIKeysService
+}
+
+// NewMockAPIKeysService creates a new mock instance.
+func NewMockAPIKeysService(ctrl *gomock.Controller) *MockAPIKeysService {
+	mock := &MockAPIKeysService{ctrl: ctrl}
+	mock.recorder = &MockAPIKeysServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeysService) EXPECT() *MockAPIKeysServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeysService) CreateAPIKey(ctx context.Context, userID int, name string, scopes []string, expiresAt time.Time) (*model.APIKey, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, userID, name, scopes, expiresAt)
+	ret0, _ := ret[0].(*model.APIKey)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeysServiceMockRecorder) CreateAPIKey(ctx, userID, name, scopes, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeysService)(nil).CreateAPIKey), ctx, userID, name, scopes, expiresAt)
+}
+
+// DeleteAPIKey mocks base method.
+func (m *MockAPIKeysService) DeleteAPIKey(ctx context.Context, userID, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAPIKey", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAPIKey indicates an expected call of DeleteAPIKey.
+func (mr *MockAPIKeysServiceMockRecorder) DeleteAPIKey(ctx, userID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAPIKey", reflect.TypeOf((*MockAPIKeysService)(nil).DeleteAPIKey), ctx, userID, id)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockAPIKeysService) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", ctx, userID)
+	ret0, _ := ret[0].([]model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockAPIKeysServiceMockRecorder) GetAPIKeys(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockAPIKeysService)(nil).GetAPIKeys), ctx, userID)
+}
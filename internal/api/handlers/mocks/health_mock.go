@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	model "github.com/fragpit/gophermart/internal/model"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -53,3 +54,31 @@ func (mr *MockHealthServiceMockRecorder) Check(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockHealthService)(nil).Check), ctx)
 }
+
+// Components mocks base method.
+func (m *MockHealthService) Components() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Components")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// Components indicates an expected call of Components.
+func (mr *MockHealthServiceMockRecorder) Components() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Components", reflect.TypeOf((*MockHealthService)(nil).Components))
+}
+
+// Ready mocks base method.
+func (m *MockHealthService) Ready(ctx context.Context) *model.HealthReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ready", ctx)
+	ret0, _ := ret[0].(*model.HealthReport)
+	return ret0
+}
+
+// Ready indicates an expected call of Ready.
+func (mr *MockHealthServiceMockRecorder) Ready(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ready", reflect.TypeOf((*MockHealthService)(nil).Ready), ctx)
+}
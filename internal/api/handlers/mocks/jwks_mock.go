@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/api/handlers (interfaces: JWKSProvider)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/jwks_mock.go . JWKSProvider
+//
+
+// Package mock_handlers is a generated GoMock package.
+package mock_handlers
+
+import (
+	reflect "reflect"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockJWKSProvider is a mock of JWKSProvider interface.
+type MockJWKSProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockJWKSProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockJWKSProviderMockRecorder is the mock recorder for MockJWKSProvider.
+type MockJWKSProviderMockRecorder struct {
+	mock *MockJWKSProvider
+}
+
+// NewMockJWKSProvider creates a new mock instance.
+func NewMockJWKSProvider(ctrl *gomock.Controller) *MockJWKSProvider {
+	mock := &MockJWKSProvider{ctrl: ctrl}
+	mock.recorder = &MockJWKSProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJWKSProvider) EXPECT() *MockJWKSProviderMockRecorder {
+	return m.recorder
+}
+
+// JWKS mocks base method.
+func (m *MockJWKSProvider) JWKS() *model.JWKSet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(*model.JWKSet)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockJWKSProviderMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockJWKSProvider)(nil).JWKS))
+}
@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/api/handlers (interfaces: MFAService)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/mfa_mock.go . MFAService
+//
+
+// Package mock_handlers is a generated GoMock package.
+package mock_handlers
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMFAService is a mock of MFAService interface.
+type MockMFAService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFAServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockMFAServiceMockRecorder is the mock recorder for MockMFAService.
+type MockMFAServiceMockRecorder struct {
+	mock *MockMFAService
+}
+
+// NewMockMFAService creates a new mock instance.
+func NewMockMFAService(ctrl *gomock.Controller) *MockMFAService {
+	mock := &MockMFAService{ctrl: ctrl}
+	mock.recorder = &MockMFAServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFAService) EXPECT() *MockMFAServiceMockRecorder {
+	return m.recorder
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockMFAService) ConfirmTOTP(ctx context.Context, p *model.Principal, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", ctx, p, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockMFAServiceMockRecorder) ConfirmTOTP(ctx, p, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockMFAService)(nil).ConfirmTOTP), ctx, p, code)
+}
+
+// DisableTOTP mocks base method.
+func (m *MockMFAService) DisableTOTP(ctx context.Context, p *model.Principal, code, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTOTP", ctx, p, code, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableTOTP indicates an expected call of DisableTOTP.
+func (mr *MockMFAServiceMockRecorder) DisableTOTP(ctx, p, code, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTOTP", reflect.TypeOf((*MockMFAService)(nil).DisableTOTP), ctx, p, code, password)
+}
+
+// EnrollTOTP mocks base method.
+func (m *MockMFAService) EnrollTOTP(ctx context.Context, p *model.Principal) (*model.MFAEnrollment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollTOTP", ctx, p)
+	ret0, _ := ret[0].(*model.MFAEnrollment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollTOTP indicates an expected call of EnrollTOTP.
+func (mr *MockMFAServiceMockRecorder) EnrollTOTP(ctx, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollTOTP", reflect.TypeOf((*MockMFAService)(nil).EnrollTOTP), ctx, p)
+}
+
+// LoginMFA mocks base method.
+func (m *MockMFAService) LoginMFA(ctx context.Context, token, code, clientIP string) (*model.TokenPair, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginMFA", ctx, token, code, clientIP)
+	ret0, _ := ret[0].(*model.TokenPair)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginMFA indicates an expected call of LoginMFA.
+func (mr *MockMFAServiceMockRecorder) LoginMFA(ctx, token, code, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginMFA", reflect.TypeOf((*MockMFAService)(nil).LoginMFA), ctx, token, code, clientIP)
+}
+
+// SetWithdrawMFA mocks base method.
+func (m *MockMFAService) SetWithdrawMFA(ctx context.Context, p *model.Principal, required bool, code, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWithdrawMFA", ctx, p, required, code, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWithdrawMFA indicates an expected call of SetWithdrawMFA.
+func (mr *MockMFAServiceMockRecorder) SetWithdrawMFA(ctx, p, required, code, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithdrawMFA", reflect.TypeOf((*MockMFAService)(nil).SetWithdrawMFA), ctx, p, required, code, password)
+}
@@ -42,17 +42,17 @@ func (m *MockOrdersService) EXPECT() *MockOrdersServiceMockRecorder {
 }
 
 // AddOrder mocks base method.
-func (m *MockOrdersService) AddOrder(ctx context.Context, userID int, orderNumber string) error {
+func (m *MockOrdersService) AddOrder(ctx context.Context, userID int, orderNumber string, goods []model.Good) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddOrder", ctx, userID, orderNumber)
+	ret := m.ctrl.Call(m, "AddOrder", ctx, userID, orderNumber, goods)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddOrder indicates an expected call of AddOrder.
-func (mr *MockOrdersServiceMockRecorder) AddOrder(ctx, userID, orderNumber any) *gomock.Call {
+func (mr *MockOrdersServiceMockRecorder) AddOrder(ctx, userID, orderNumber, goods any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrder", reflect.TypeOf((*MockOrdersService)(nil).AddOrder), ctx, userID, orderNumber)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrder", reflect.TypeOf((*MockOrdersService)(nil).AddOrder), ctx, userID, orderNumber, goods)
 }
 
 // GetOrdersByUser mocks base method.
@@ -20,6 +20,7 @@ type OrdersService interface {
 		ctx context.Context,
 		userID int,
 		orderNumber string,
+		goods []model.Good,
 	) error
 }
 
@@ -68,27 +69,90 @@ func NewOrdersGetHandler(svc OrdersService) http.Handler {
 			return
 		}
 
-		var response []ordersGetResponse
-		for _, order := range orders {
-			r := ordersGetResponse{
-				Number:     order.Number,
-				Status:     order.Status,
-				Accrual:    order.Accrual,
-				UploadedAt: order.UploadedAt.Format(time.RFC3339),
-			}
-			response = append(response, r)
-		}
-
 		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
+		if err := json.NewEncoder(w).Encode(
+			newOrdersGetResponse(orders),
+		); err != nil {
 			slog.Error("encode orders error", slog.Any("error", err))
 		}
 	})
 }
 
+func newOrdersGetResponse(orders []model.Order) []ordersGetResponse {
+	response := make([]ordersGetResponse, 0, len(orders))
+	for _, order := range orders {
+		response = append(response, ordersGetResponse{
+			Number:     order.Number,
+			Status:     order.Status,
+			Accrual:    order.Accrual,
+			UploadedAt: order.UploadedAt.Format(time.RFC3339),
+		})
+	}
+	return response
+}
+
+type ordersPostRequest struct {
+	Order string       `json:"order"`
+	Goods []model.Good `json:"goods"`
+}
+
 func NewOrdersPostHandler(svc OrdersService) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Header.Get("Content-Type") != "text/plain" {
+		var (
+			orderNumber string
+			goods       []model.Good
+		)
+
+		switch mediaType(r) {
+		case "text/plain":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				slog.Error("failed to read body", slog.Any("error", err))
+				http.Error(w, "invalid order number", http.StatusBadRequest)
+				return
+			}
+			orderNumber = strings.TrimSpace(string(body))
+		case "application/json":
+			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+			defer func() { _ = r.Body.Close() }()
+
+			dec := json.NewDecoder(r.Body)
+			dec.DisallowUnknownFields()
+
+			var orderRequest ordersPostRequest
+			if err := dec.Decode(&orderRequest); err != nil {
+				var mberr *http.MaxBytesError
+				slog.Warn("invalid JSON", slog.Any("error", err))
+				if errors.As(err, &mberr) {
+					http.Error(
+						w,
+						"request body too large",
+						http.StatusRequestEntityTooLarge,
+					)
+					return
+				}
+				http.Error(w, "invalid JSON", http.StatusBadRequest)
+				return
+			}
+
+			if err := dec.Decode(&struct{}{}); err != io.EOF {
+				slog.Warn("invalid JSON", slog.Any("error", err))
+				http.Error(w, "invalid JSON", http.StatusBadRequest)
+				return
+			}
+
+			if !model.ValidateGoods(orderRequest.Goods) {
+				slog.Error(
+					"failed to validate goods",
+					slog.String("error", "empty description or negative price"),
+				)
+				http.Error(w, "invalid goods", http.StatusBadRequest)
+				return
+			}
+
+			orderNumber = strings.TrimSpace(orderRequest.Order)
+			goods = orderRequest.Goods
+		default:
 			slog.Error(
 				"request with an empty or unsupported content type",
 				slog.String("content_type", r.Header.Get("Content-Type")),
@@ -97,13 +161,6 @@ func NewOrdersPostHandler(svc OrdersService) http.Handler {
 			return
 		}
 
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			slog.Error("failed to read body", slog.Any("error", err))
-			http.Error(w, "invalid order number", http.StatusBadRequest)
-			return
-		}
-		orderNumber := strings.TrimSpace(string(body))
 		if orderNumber == "" {
 			slog.Error(
 				"failed to read body",
@@ -142,7 +199,7 @@ func NewOrdersPostHandler(svc OrdersService) http.Handler {
 			return
 		}
 
-		if err := svc.AddOrder(ctx, userID, orderNumber); err != nil {
+		if err := svc.AddOrder(ctx, userID, orderNumber, goods); err != nil {
 			if errors.Is(err, model.ErrOrderAlreadyExist) {
 				slog.Info("order already added")
 				http.Error(w, "order already added", http.StatusOK)
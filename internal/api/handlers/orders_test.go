@@ -134,6 +134,8 @@ func TestOrdersPostHandler(t *testing.T) {
 		mockData    mockData
 		reqBody     *ordersGetResponse
 		orderNumber string
+		jsonBody    string
+		contentType string
 		authUserID  int
 		wantCode    int
 	}{
@@ -194,6 +196,61 @@ func TestOrdersPostHandler(t *testing.T) {
 			authUserID:  1,
 			wantCode:    http.StatusInternalServerError,
 		},
+		{
+			name: "success json with goods",
+			mockData: mockData{
+				err: nil,
+			},
+			jsonBody: `{"order":"` + orderNumByLuhn +
+				`","goods":[{"description":"Чайник Bork","price":7000}]}`,
+			authUserID: 1,
+			wantCode:   http.StatusAccepted,
+		},
+		{
+			name:       "error json invalid goods",
+			mockData:   mockData{},
+			jsonBody:   `{"order":"` + orderNumByLuhn + `","goods":[{"price":10}]}`,
+			authUserID: 1,
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "error json unknown field",
+			mockData:   mockData{},
+			jsonBody:   `{"number":"` + orderNumByLuhn + `"}`,
+			authUserID: 1,
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "error json invalid order number",
+			mockData:   mockData{},
+			jsonBody:   `{"order":"123123","goods":[]}`,
+			authUserID: 1,
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "success text with charset",
+			mockData:    mockData{},
+			orderNumber: orderNumByLuhn,
+			contentType: "text/plain; charset=utf-8",
+			authUserID:  1,
+			wantCode:    http.StatusAccepted,
+		},
+		{
+			name:        "success json with charset",
+			mockData:    mockData{},
+			jsonBody:    `{"order":"` + orderNumByLuhn + `"}`,
+			contentType: "Application/JSON; charset=UTF-8",
+			authUserID:  1,
+			wantCode:    http.StatusAccepted,
+		},
+		{
+			name:        "error unsupported content type",
+			mockData:    mockData{},
+			orderNumber: orderNumByLuhn,
+			contentType: "text/html; charset=utf-8",
+			authUserID:  1,
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
 	}
 
 	for _, tc := range tests {
@@ -204,7 +261,7 @@ func TestOrdersPostHandler(t *testing.T) {
 			m := mock_handlers.NewMockOrdersService(ctrl)
 
 			m.EXPECT().
-				AddOrder(gomock.Any(), gomock.Any(), gomock.Any()).
+				AddOrder(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 				Return(tc.mockData.err).AnyTimes()
 			handler := NewOrdersPostHandler(m)
 			rec := httptest.NewRecorder()
@@ -220,9 +277,17 @@ func TestOrdersPostHandler(t *testing.T) {
 				ctx = context.Background()
 			}
 
+			contentType := "text/plain"
 			data := strings.NewReader(tc.orderNumber)
+			if tc.jsonBody != "" {
+				contentType = "application/json"
+				data = strings.NewReader(tc.jsonBody)
+			}
+			if tc.contentType != "" {
+				contentType = tc.contentType
+			}
 			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "/", data)
-			req.Header.Set("Content-Type", "text/plain")
+			req.Header.Set("Content-Type", contentType)
 
 			handler.ServeHTTP(rec, req)
 
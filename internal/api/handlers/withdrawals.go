@@ -62,19 +62,25 @@ func NewWithdrawalsHandler(svc WithdrawalsService) http.Handler {
 			return
 		}
 
-		var response []WithdrawalsResponse
-		for _, wd := range withdrawals {
-			r := WithdrawalsResponse{
-				OrderNumber:  wd.OrderNum,
-				SumWithdrawn: wd.Sum,
-				ProcessedAt:  wd.ProcessedAt.Format(time.RFC3339),
-			}
-			response = append(response, r)
-		}
-
 		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(response); err != nil {
+		if err := json.NewEncoder(w).Encode(
+			newWithdrawalsResponse(withdrawals),
+		); err != nil {
 			slog.Error("encode orders error", slog.Any("error", err))
 		}
 	})
 }
+
+func newWithdrawalsResponse(
+	withdrawals []model.Withdrawal,
+) []WithdrawalsResponse {
+	response := make([]WithdrawalsResponse, 0, len(withdrawals))
+	for _, wd := range withdrawals {
+		response = append(response, WithdrawalsResponse{
+			OrderNumber:  wd.OrderNum,
+			SumWithdrawn: wd.Sum,
+			ProcessedAt:  wd.ProcessedAt.Format(time.RFC3339),
+		})
+	}
+	return response
+}
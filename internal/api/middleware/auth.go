@@ -2,25 +2,65 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
-	"github.com/fragpit/gophermart/internal/service/auth"
+	"github.com/fragpit/gophermart/internal/model"
 )
 
 type ctxKey string
 
-const CtxUserIDKey ctxKey = "user_id"
+const (
+	CtxUserIDKey    ctxKey = "user_id"
+	CtxPrincipalKey ctxKey = "principal"
+)
+
+//go:generate mockgen -destination ./mocks/token_verifier.go . TokenVerifier
+type TokenVerifier interface {
+	// VerifyAccessToken проверяет токен, включая отзыв (jti и версия
+	// токенов пользователя).
+	VerifyAccessToken(ctx context.Context, token string) (*model.Principal, error)
+}
+
+//go:generate mockgen -destination ./mocks/api_key_verifier.go . APIKeyVerifier
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*model.Principal, error)
+}
+
+//go:generate mockgen -destination ./mocks/step_up_verifier.go . StepUpVerifier
+type StepUpVerifier interface {
+	// VerifyStepUp nil, если пользователь не требует код или code верный.
+	VerifyStepUp(ctx context.Context, p *model.Principal, code string) error
+}
+
+// MFACodeHeader заголовок со свежим кодом второго фактора для операций,
+// защищённых RequireStepUp.
+const MFACodeHeader = "X-MFA-Code"
+
+// RequireJWT только access токен пользователя. Управление сессиями, паролем
+// и ключами API ключом недоступно.
+func RequireJWT(verifier TokenVerifier) func(http.Handler) http.Handler {
+	return RequireAuth(verifier, nil)
+}
 
-func RequireJWT(secret string) func(http.Handler) http.Handler {
+// RequireAuth access токен или API ключ (заголовок X-API-Key либо
+// Authorization: Bearer gm_...). keys nil - только access токен. Права
+// ключа проверяет RequireScope.
+func RequireAuth(
+	tokens TokenVerifier,
+	keys APIKeyVerifier,
+) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authz := r.Header.Get("Authorization")
-			if authz == "" {
+			credential, isKey, ok := credentialFromRequest(r)
+			if !ok || (isKey && keys == nil) {
 				slog.Error(
 					"authentication error",
-					slog.String("error", "header not set"),
+					slog.String("error", "credentials not set"),
 				)
 				http.Error(
 					w,
@@ -30,9 +70,37 @@ func RequireJWT(secret string) func(http.Handler) http.Handler {
 				return
 			}
 
-			parts := strings.SplitN(authz, " ", 2)
-			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") ||
-				parts[1] == "" {
+			var (
+				p   *model.Principal
+				err error
+			)
+			if isKey {
+				p, err = keys.VerifyAPIKey(r.Context(), credential)
+			} else {
+				p, err = tokens.VerifyAccessToken(r.Context(), credential)
+			}
+			if err != nil && !errors.Is(err, model.ErrInvalidToken) &&
+				!errors.Is(err, model.ErrTokenRevoked) &&
+				!errors.Is(err, model.ErrInvalidAPIKey) {
+				slog.Error("failed to verify credentials", slog.Any("error", err))
+				http.Error(
+					w,
+					http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError,
+				)
+				return
+			}
+			if err != nil {
+				reason := "unknown"
+				if kind, ok := model.AuthErrorKindOf(err); ok {
+					reason = kind.String()
+				}
+				slog.Warn(
+					"invalid credentials",
+					slog.Bool("api_key", isKey),
+					slog.String("reason", reason),
+					slog.Any("error", err),
+				)
 				http.Error(
 					w,
 					http.StatusText(http.StatusUnauthorized),
@@ -41,10 +109,20 @@ func RequireJWT(secret string) func(http.Handler) http.Handler {
 				return
 			}
 
-			token := parts[1]
-			userID, err := auth.GetUserIDFromJWTToken(secret, token)
-			if err != nil {
-				slog.Warn("invalid jwt", slog.Any("error", err))
+			ctx := context.WithValue(r.Context(), CtxUserIDKey, p.UserID)
+			ctx = context.WithValue(ctx, CtxPrincipalKey, p)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope пропускает запрос, если у аутентифицированного субъекта есть
+// право scope. Ставится после RequireAuth.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := r.Context().Value(CtxPrincipalKey).(*model.Principal)
+			if !ok || p == nil {
 				http.Error(
 					w,
 					http.StatusText(http.StatusUnauthorized),
@@ -53,8 +131,155 @@ func RequireJWT(secret string) func(http.Handler) http.Handler {
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), CtxUserIDKey, userID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			if !p.HasScope(scope) {
+				slog.Warn(
+					"api key scope missing",
+					slog.Int("key_id", p.APIKeyID),
+					slog.String("scope", scope),
+				)
+				http.Error(w, "missing scope "+scope, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// RequireRole пропускает запрос, если у пользователя есть хотя бы одна из
+// ролей. Ставится после RequireJWT: у API ключей ролей нет.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := r.Context().Value(CtxPrincipalKey).(*model.Principal)
+			if !ok || p == nil {
+				http.Error(
+					w,
+					http.StatusText(http.StatusUnauthorized),
+					http.StatusUnauthorized,
+				)
+				return
+			}
+
+			if p.APIKeyID != 0 || !p.HasRole(roles...) {
+				slog.Warn(
+					"role required",
+					slog.Int("user_id", p.UserID),
+					slog.Any("roles", roles),
+					slog.String("path", r.URL.Path),
+				)
+				http.Error(
+					w,
+					http.StatusText(http.StatusForbidden),
+					http.StatusForbidden,
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireActive отклоняет изменяющие запросы приостановленных
+// пользователей: им доступно только чтение. Ставится после RequireAuth.
+func RequireActive() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := r.Context().Value(CtxPrincipalKey).(*model.Principal)
+			if !ok || p == nil {
+				http.Error(
+					w,
+					http.StatusText(http.StatusUnauthorized),
+					http.StatusUnauthorized,
+				)
+				return
+			}
+
+			if p.Status == model.UserStatusSuspended {
+				slog.Warn(
+					"account suspended",
+					slog.Int("user_id", p.UserID),
+					slog.String("path", r.URL.Path),
+				)
+				http.Error(w, "account suspended", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireStepUp требует код второго фактора в заголовке X-MFA-Code, если
+// пользователь включил такую проверку. Ставится после RequireAuth.
+func RequireStepUp(verifier StepUpVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := r.Context().Value(CtxPrincipalKey).(*model.Principal)
+			if !ok || p == nil {
+				http.Error(
+					w,
+					http.StatusText(http.StatusUnauthorized),
+					http.StatusUnauthorized,
+				)
+				return
+			}
+
+			err := verifier.VerifyStepUp(
+				r.Context(),
+				p,
+				r.Header.Get(MFACodeHeader),
+			)
+			if err != nil {
+				slog.Warn(
+					"step-up verification failed",
+					slog.Int("user_id", p.UserID),
+					slog.String("path", r.URL.Path),
+					slog.Any("error", err),
+				)
+				var throttled *model.LoginThrottledError
+				switch {
+				case errors.Is(err, model.ErrMFARequired):
+					http.Error(w, "two-factor code required", http.StatusForbidden)
+				case errors.Is(err, model.ErrInvalidMFACode):
+					http.Error(w, "invalid two-factor code", http.StatusForbidden)
+				case errors.As(err, &throttled):
+					retryAfter := max(int(math.Ceil(throttled.RetryAfter.Seconds())), 1)
+					w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+					http.Error(
+						w,
+						"too many failed attempts",
+						http.StatusTooManyRequests,
+					)
+				default:
+					http.Error(
+						w,
+						http.StatusText(http.StatusInternalServerError),
+						http.StatusInternalServerError,
+					)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// credentialFromRequest возвращает токен или ключ и признак, что это API
+// ключ.
+func credentialFromRequest(r *http.Request) (string, bool, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, true, true
+	}
+
+	authz := r.Header.Get("Authorization")
+	parts := strings.SplitN(authz, " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") ||
+		parts[1] == "" {
+		return "", false, false
+	}
+
+	return parts[1], strings.HasPrefix(parts[1], model.APIKeyPrefix), true
+}
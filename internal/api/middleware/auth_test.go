@@ -0,0 +1,454 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mock_middleware "github.com/fragpit/gophermart/internal/api/middleware/mocks"
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRequireJWT(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name       string
+		header     string
+		principal  *model.Principal
+		err        error
+		wantCode   int
+		wantUserID int
+	}{
+		{
+			name:     "no header",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "not bearer",
+			header:   "Basic abc",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "invalid token",
+			header:   "Bearer tok",
+			err:      model.ErrInvalidToken,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "revoked token",
+			header:   "Bearer tok",
+			err:      model.ErrTokenRevoked,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "revocation check failed",
+			header:   "Bearer tok",
+			err:      fmt.Errorf("db down"),
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name:     "api key not accepted",
+			header:   "Bearer gm_key",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "valid token",
+			header:     "Bearer tok",
+			principal:  &model.Principal{UserID: 7, TokenID: "jti"},
+			wantCode:   http.StatusOK,
+			wantUserID: 7,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			v := mock_middleware.NewMockTokenVerifier(ctrl)
+			if tc.principal != nil || tc.err != nil {
+				v.EXPECT().VerifyAccessToken(gomock.Any(), "tok").
+					Return(tc.principal, tc.err)
+			}
+
+			var gotUserID int
+			var gotPrincipal *model.Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUserID, _ = r.Context().Value(CtxUserIDKey).(int)
+				gotPrincipal, _ = r.Context().Value(CtxPrincipalKey).(*model.Principal)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			RequireJWT(v)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantUserID, gotUserID)
+			assert.Equal(t, tc.principal, gotPrincipal)
+		})
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	keyPrincipal := &model.Principal{
+		UserID:   7,
+		APIKeyID: 3,
+		Scopes:   []string{model.ScopeOrdersWrite},
+	}
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		prepare  func(*mock_middleware.MockTokenVerifier, *mock_middleware.MockAPIKeyVerifier)
+		wantCode int
+	}{
+		{
+			name:     "no credentials",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "jwt",
+			header: "Authorization",
+			value:  "Bearer tok",
+			prepare: func(
+				tv *mock_middleware.MockTokenVerifier,
+				_ *mock_middleware.MockAPIKeyVerifier,
+			) {
+				tv.EXPECT().VerifyAccessToken(gomock.Any(), "tok").
+					Return(&model.Principal{UserID: 7, TokenID: "jti"}, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "api key header",
+			header: "X-API-Key",
+			value:  "gm_key",
+			prepare: func(
+				_ *mock_middleware.MockTokenVerifier,
+				kv *mock_middleware.MockAPIKeyVerifier,
+			) {
+				kv.EXPECT().VerifyAPIKey(gomock.Any(), "gm_key").
+					Return(keyPrincipal, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "api key as bearer",
+			header: "Authorization",
+			value:  "Bearer gm_key",
+			prepare: func(
+				_ *mock_middleware.MockTokenVerifier,
+				kv *mock_middleware.MockAPIKeyVerifier,
+			) {
+				kv.EXPECT().VerifyAPIKey(gomock.Any(), "gm_key").
+					Return(keyPrincipal, nil)
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:   "invalid api key",
+			header: "X-API-Key",
+			value:  "gm_key",
+			prepare: func(
+				_ *mock_middleware.MockTokenVerifier,
+				kv *mock_middleware.MockAPIKeyVerifier,
+			) {
+				kv.EXPECT().VerifyAPIKey(gomock.Any(), "gm_key").
+					Return(nil, model.ErrInvalidAPIKey)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "api key check failed",
+			header: "X-API-Key",
+			value:  "gm_key",
+			prepare: func(
+				_ *mock_middleware.MockTokenVerifier,
+				kv *mock_middleware.MockAPIKeyVerifier,
+			) {
+				kv.EXPECT().VerifyAPIKey(gomock.Any(), "gm_key").
+					Return(nil, fmt.Errorf("db down"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			tv := mock_middleware.NewMockTokenVerifier(ctrl)
+			kv := mock_middleware.NewMockAPIKeyVerifier(ctrl)
+			if tc.prepare != nil {
+				tc.prepare(tv, kv)
+			}
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set(tc.header, tc.value)
+			}
+			rec := httptest.NewRecorder()
+
+			RequireAuth(tv, kv)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name      string
+		principal *model.Principal
+		wantCode  int
+	}{
+		{
+			name:     "no principal",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:      "access token has all scopes",
+			principal: &model.Principal{UserID: 7, TokenID: "jti"},
+			wantCode:  http.StatusOK,
+		},
+		{
+			name: "api key with scope",
+			principal: &model.Principal{
+				UserID:   7,
+				APIKeyID: 3,
+				Scopes:   []string{model.ScopeBalanceRead, model.ScopeOrdersWrite},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "api key without scope",
+			principal: &model.Principal{
+				UserID:   7,
+				APIKeyID: 3,
+				Scopes:   []string{model.ScopeBalanceRead},
+			},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.principal != nil {
+				req = req.WithContext(
+					context.WithValue(req.Context(), CtxPrincipalKey, tc.principal),
+				)
+			}
+			rec := httptest.NewRecorder()
+
+			RequireScope(model.ScopeOrdersWrite)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name      string
+		principal *model.Principal
+		wantCode  int
+	}{
+		{
+			name:     "no principal",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:      "no roles",
+			principal: &model.Principal{UserID: 7},
+			wantCode:  http.StatusForbidden,
+		},
+		{
+			name: "other role",
+			principal: &model.Principal{
+				UserID: 7,
+				Roles:  []string{"auditor"},
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "one of roles",
+			principal: &model.Principal{
+				UserID: 7,
+				Roles:  []string{model.RoleSupport},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "api key",
+			principal: &model.Principal{
+				UserID:   7,
+				APIKeyID: 3,
+				Roles:    []string{model.RoleAdmin},
+			},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			if tc.principal != nil {
+				req = req.WithContext(
+					context.WithValue(req.Context(), CtxPrincipalKey, tc.principal),
+				)
+			}
+			rec := httptest.NewRecorder()
+
+			RequireRole(model.RoleAdmin, model.RoleSupport)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestRequireActive(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name      string
+		principal *model.Principal
+		wantCode  int
+	}{
+		{
+			name:     "no principal",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "active",
+			principal: &model.Principal{
+				UserID: 7,
+				Status: model.UserStatusActive,
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "suspended",
+			principal: &model.Principal{
+				UserID: 7,
+				Status: model.UserStatusSuspended,
+			},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/orders", nil)
+			if tc.principal != nil {
+				req = req.WithContext(
+					context.WithValue(req.Context(), CtxPrincipalKey, tc.principal),
+				)
+			}
+			rec := httptest.NewRecorder()
+
+			RequireActive()(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestRequireStepUp(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+
+	tests := []struct {
+		name           string
+		code           string
+		err            error
+		wantCode       int
+		wantRetryAfter string
+	}{
+		{
+			name:     "not required or valid",
+			code:     "123456",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "code missing",
+			err:      model.ErrMFARequired,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "invalid code",
+			code:     "000000",
+			err:      model.ErrInvalidMFACode,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:           "throttled",
+			code:           "000000",
+			err:            &model.LoginThrottledError{RetryAfter: time.Minute},
+			wantCode:       http.StatusTooManyRequests,
+			wantRetryAfter: "60",
+		},
+		{
+			name:     "internal error",
+			code:     "123456",
+			err:      fmt.Errorf("db down"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			v := mock_middleware.NewMockStepUpVerifier(ctrl)
+			v.EXPECT().VerifyStepUp(gomock.Any(), p, tc.code).Return(tc.err)
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			req := httptest.NewRequest(
+				http.MethodPost,
+				"/api/user/balance/withdraw",
+				nil,
+			)
+			if tc.code != "" {
+				req.Header.Set(MFACodeHeader, tc.code)
+			}
+			req = req.WithContext(
+				context.WithValue(req.Context(), CtxPrincipalKey, p),
+			)
+			rec := httptest.NewRecorder()
+
+			RequireStepUp(v)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantRetryAfter, rec.Header().Get("Retry-After"))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics считает запросы, коды ответов и время обработки по маршрутам.
+// Маршрут берётся из шаблона ServeMux, поэтому middleware должен оборачивать
+// mux снаружи.
+func Metrics(reg prometheus.Registerer) func(http.Handler) http.Handler {
+	requests := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "gophermart",
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route, method and status code.",
+		},
+		[]string{"route", "method", "code"},
+	)
+	duration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "gophermart",
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"route", "method"},
+	)
+	reg.MustRegister(requests, duration)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := &responseWriter{ResponseWriter: w, statusCode: 200}
+
+			next.ServeHTTP(ww, r)
+
+			// шаблон маршрута, а не путь: номера заказов не раздувают
+			// число временных рядов
+			route := r.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+
+			requests.
+				WithLabelValues(route, r.Method, strconv.Itoa(ww.statusCode)).
+				Inc()
+			duration.
+				WithLabelValues(route, r.Method).
+				Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/orders/{number}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := Metrics(reg)(mux)
+
+	for _, path := range []string{"/api/orders/1", "/api/orders/2", "/nope"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expected := `
+# HELP gophermart_http_requests_total Number of HTTP requests by route, method and status code.
+# TYPE gophermart_http_requests_total counter
+gophermart_http_requests_total{code="204",method="GET",route="GET /api/orders/{number}"} 2
+gophermart_http_requests_total{code="404",method="GET",route="unmatched"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(
+		reg,
+		strings.NewReader(expected),
+		"gophermart_http_requests_total",
+	))
+	assert.Equal(t, 2, testutil.CollectAndCount(reg, "gophermart_http_request_duration_seconds"))
+}
@@ -0,0 +1,57 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/api/middleware (interfaces: APIKeyVerifier)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/api_key_verifier.go . APIKeyVerifier
+//
+
+// Package mock_middleware is a generated GoMock package.
+package mock_middleware
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAPIKeyVerifier is a mock of APIKeyVerifier interface.
+type MockAPIKeyVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyVerifierMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyVerifierMockRecorder is the mock recorder for MockAPIKeyVerifier.
+type MockAPIKeyVerifierMockRecorder struct {
+	mock *MockAPIKeyVerifier
+}
+
+// NewMockAPIKeyVerifier creates a new mock instance.
+func NewMockAPIKeyVerifier(ctrl *gomock.Controller) *MockAPIKeyVerifier {
+	mock := &MockAPIKeyVerifier{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyVerifier) EXPECT() *MockAPIKeyVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifyAPIKey mocks base method.
+func (m *MockAPIKeyVerifier) VerifyAPIKey(ctx context.Context, key string) (*model.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAPIKey", ctx, key)
+	ret0, _ := ret[0].(*model.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAPIKey indicates an expected call of VerifyAPIKey.
+func (mr *MockAPIKeyVerifierMockRecorder) VerifyAPIKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAPIKey", reflect.TypeOf((*MockAPIKeyVerifier)(nil).VerifyAPIKey), ctx, key)
+}
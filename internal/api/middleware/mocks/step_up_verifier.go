@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/api/middleware (interfaces: StepUpVerifier)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/step_up_verifier.go . StepUpVerifier
+//
+
+// Package mock_middleware is a generated GoMock package.
+package mock_middleware
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStepUpVerifier is a mock of StepUpVerifier interface.
+type MockStepUpVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockStepUpVerifierMockRecorder
+	isgomock struct{}
+}
+
+// MockStepUpVerifierMockRecorder is the mock recorder for MockStepUpVerifier.
+type MockStepUpVerifierMockRecorder struct {
+	mock *MockStepUpVerifier
+}
+
+// NewMockStepUpVerifier creates a new mock instance.
+func NewMockStepUpVerifier(ctrl *gomock.Controller) *MockStepUpVerifier {
+	mock := &MockStepUpVerifier{ctrl: ctrl}
+	mock.recorder = &MockStepUpVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStepUpVerifier) EXPECT() *MockStepUpVerifierMockRecorder {
+	return m.recorder
+}
+
+// VerifyStepUp mocks base method.
+func (m *MockStepUpVerifier) VerifyStepUp(ctx context.Context, p *model.Principal, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyStepUp", ctx, p, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyStepUp indicates an expected call of VerifyStepUp.
+func (mr *MockStepUpVerifierMockRecorder) VerifyStepUp(ctx, p, code any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyStepUp", reflect.TypeOf((*MockStepUpVerifier)(nil).VerifyStepUp), ctx, p, code)
+}
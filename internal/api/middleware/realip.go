@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// CtxClientIPKey адрес клиента, определённый RealIP.
+const CtxClientIPKey ctxKey = "client_ip"
+
+// RealIP определяет адрес клиента за доверенными прокси. X-Forwarded-For
+// читается, только если соединение пришло с адреса из trusted: цепочка
+// разбирается справа налево до первого недоверенного адреса. Без trusted
+// адрес клиента - адрес соединения.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, ok := remoteIP(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if isTrusted(trusted, ip) {
+				ip = forwardedIP(trusted, ip, r.Header.Values("X-Forwarded-For"))
+			}
+
+			ctx := context.WithValue(r.Context(), CtxClientIPKey, ip.String())
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func remoteIP(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+// forwardedIP самый правый адрес цепочки, добавленный не доверенным
+// прокси. Некорректный адрес прерывает разбор: левее него заголовку нельзя
+// верить.
+func forwardedIP(
+	trusted []netip.Prefix,
+	ip netip.Addr,
+	headers []string,
+) netip.Addr {
+	var hops []string
+	for _, h := range headers {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = hop.Unmap()
+		if !isTrusted(trusted, ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+func isTrusted(trusted []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("::1/128"),
+	}
+
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{
+			name:       "no trusted proxies",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"203.0.113.7"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "untrusted peer",
+			trusted:    trusted,
+			remoteAddr: "198.51.100.2:1234",
+			xff:        []string{"203.0.113.7"},
+			want:       "198.51.100.2",
+		},
+		{
+			name:       "trusted proxy",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed left hops ignored",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"1.2.3.4, 203.0.113.7", "10.0.0.2"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "malformed hop",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"203.0.113.7, junk"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "trusted proxy without header",
+			trusted:    trusted,
+			remoteAddr: "[::1]:1234",
+			want:       "::1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(tc.trusted)(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					got, _ = r.Context().Value(CtxClientIPKey).(string)
+				},
+			))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/login", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, v := range tc.xff {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/fragpit/gophermart/internal/api")
+
+// Trace создаёт серверный спан на запрос, продолжая входящий контекст
+// трассировки из заголовков. Как и Metrics, должен оборачивать mux снаружи.
+func Trace() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(
+				r.Context(),
+				propagation.HeaderCarrier(r.Header),
+			)
+			ctx, span := tracer.Start(
+				ctx,
+				r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			ww := &responseWriter{ResponseWriter: w, statusCode: 200}
+			// mux записывает шаблон маршрута в переданный ему запрос
+			req := r.WithContext(ctx)
+
+			next.ServeHTTP(ww, req)
+
+			if req.Pattern != "" {
+				span.SetName(req.Pattern)
+				span.SetAttributes(attribute.String("http.route", req.Pattern))
+			}
+			span.SetAttributes(
+				attribute.Int("http.response.status_code", ww.statusCode),
+			)
+			if ww.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(ww.statusCode))
+			}
+		})
+	}
+}
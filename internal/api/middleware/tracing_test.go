@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestTrace(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/user/balance/withdraw", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler := Trace()(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", nil)
+	req.Header.Set(
+		"traceparent",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+
+	span := spans[0]
+	assert.Equal(t, "POST /api/user/balance/withdraw", span.Name)
+	assert.Equal(
+		t,
+		"4bf92f3577b34da6a3ce929d0e0e4736",
+		span.SpanContext.TraceID().String(),
+	)
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Contains(
+		t,
+		span.Attributes,
+		attribute.Int("http.response.status_code", 500),
+	)
+}
@@ -4,22 +4,42 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/fragpit/gophermart/internal/api/handlers"
 	"github.com/fragpit/gophermart/internal/api/middleware" // Keeping middleware import
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const apiShutdownTimeout = 5 * time.Second
 
 type StorageDeps struct {
-	JWTSecret string
+	// TokenVerifier проверяет access токены в RequireJWT.
+	TokenVerifier middleware.TokenVerifier
+	// APIKeyVerifier проверяет API ключи машинных клиентов.
+	APIKeyVerifier middleware.APIKeyVerifier
+	// StepUpVerifier проверяет код второго фактора перед списанием.
+	StepUpVerifier middleware.StepUpVerifier
+	// JWKS публичные ключи подписи токенов.
+	JWKS handlers.JWKSProvider
+	// Metrics реестр метрик для /metrics, nil - метрики отключены.
+	Metrics *prometheus.Registry
+	// TrustedProxies сети прокси, которым доверяется X-Forwarded-For.
+	TrustedProxies []netip.Prefix
 
 	HealthService      handlers.HealthService
 	AuthService        handlers.AuthService
+	AccountService     handlers.AccountService
+	MFAService         handlers.MFAService
 	OrdersService      handlers.OrdersService
 	BalanceService     handlers.BalanceService
 	WithdrawalsService handlers.WithdrawalsService
+	LedgerService      handlers.LedgerService
+	APIKeysService     handlers.APIKeysService
+	AdminService       handlers.AdminService
 }
 
 type Router struct {
@@ -29,13 +49,36 @@ type Router struct {
 func NewRouter(deps StorageDeps) *Router {
 	mux := http.NewServeMux()
 
-	authMW := middleware.RequireJWT(deps.JWTSecret)
+	authMW := middleware.RequireJWT(deps.TokenVerifier)
+	keyAuthMW := middleware.RequireAuth(deps.TokenVerifier, deps.APIKeyVerifier)
+	// scopedMW маршрут доступен и по API ключу с правом scope
+	scopedMW := func(scope string, h http.Handler) http.Handler {
+		return keyAuthMW(middleware.RequireScope(scope)(h))
+	}
+	// activeMW изменяющие маршруты, недоступные приостановленным аккаунтам
+	activeMW := middleware.RequireActive()
+	// stepUpMW свежий код второго фактора, если пользователь его включил
+	stepUpMW := middleware.RequireStepUp(deps.StepUpVerifier)
+	// adminMW маршрут /api/admin для пользователей с одной из ролей
+	adminMW := func(h http.Handler, roles ...string) http.Handler {
+		return authMW(middleware.RequireRole(roles...)(h))
+	}
 	logMW := middleware.Log()
 
 	mux.Handle(
 		"GET /health",
 		handlers.NewHealthHandler(deps.HealthService),
 	)
+	mux.Handle("GET /livez", handlers.NewLivenessHandler())
+	mux.Handle(
+		"GET /readyz",
+		handlers.NewReadinessHandler(deps.HealthService),
+	)
+
+	mux.Handle(
+		"GET /.well-known/jwks.json",
+		handlers.NewJWKSHandler(deps.JWKS),
+	)
 
 	mux.Handle(
 		"POST /api/user/register",
@@ -45,36 +88,174 @@ func NewRouter(deps StorageDeps) *Router {
 		"POST /api/user/login",
 		handlers.NewAuthLoginHandler(deps.AuthService),
 	)
+	mux.Handle(
+		"POST /api/user/login/mfa",
+		handlers.NewMFALoginHandler(deps.MFAService),
+	)
+	mux.Handle(
+		"POST /api/user/token/refresh",
+		handlers.NewAuthRefreshHandler(deps.AuthService),
+	)
+	mux.Handle(
+		"POST /api/user/logout",
+		authMW(handlers.NewAuthLogoutHandler(deps.AuthService)),
+	)
+
+	mux.Handle(
+		"PUT /api/user/password",
+		authMW(handlers.NewChangePasswordHandler(deps.AuthService)),
+	)
+
+	mux.Handle(
+		"POST /api/user/mfa/totp",
+		authMW(handlers.NewMFAEnrollHandler(deps.MFAService)),
+	)
+	mux.Handle(
+		"POST /api/user/mfa/totp/confirm",
+		authMW(handlers.NewMFAConfirmHandler(deps.MFAService)),
+	)
+	mux.Handle(
+		"DELETE /api/user/mfa/totp",
+		authMW(handlers.NewMFADisableHandler(deps.MFAService)),
+	)
+	mux.Handle(
+		"PUT /api/user/mfa/withdraw",
+		authMW(handlers.NewMFAWithdrawHandler(deps.MFAService)),
+	)
+
+	mux.Handle(
+		"GET /api/user/export",
+		authMW(handlers.NewExportHandler(deps.AccountService)),
+	)
+	mux.Handle(
+		"DELETE /api/user",
+		authMW(activeMW(handlers.NewDeleteAccountHandler(deps.AccountService))),
+	)
+
+	mux.Handle(
+		"POST /api/user/keys",
+		authMW(activeMW(handlers.NewAPIKeyCreateHandler(deps.APIKeysService))),
+	)
+	mux.Handle(
+		"GET /api/user/keys",
+		authMW(handlers.NewAPIKeysGetHandler(deps.APIKeysService)),
+	)
+	mux.Handle(
+		"DELETE /api/user/keys/{id}",
+		authMW(handlers.NewAPIKeyDeleteHandler(deps.APIKeysService)),
+	)
 
 	mux.Handle(
 		"GET /api/user/orders",
-		authMW(
+		scopedMW(
+			model.ScopeOrdersRead,
 			middleware.Gzip(handlers.NewOrdersGetHandler(deps.OrdersService)),
 		),
 	)
 	mux.Handle(
 		"POST /api/user/orders",
-		authMW(handlers.NewOrdersPostHandler(deps.OrdersService)),
+		scopedMW(
+			model.ScopeOrdersWrite,
+			activeMW(handlers.NewOrdersPostHandler(deps.OrdersService)),
+		),
 	)
 
 	mux.Handle(
 		"GET /api/user/balance",
-		authMW(handlers.NewBalanceHandler(deps.BalanceService)),
+		scopedMW(
+			model.ScopeBalanceRead,
+			handlers.NewBalanceHandler(deps.BalanceService),
+		),
 	)
 	mux.Handle(
 		"POST /api/user/balance/withdraw",
-		authMW(handlers.NewBalanceWithdrawHandler(deps.BalanceService)),
+		scopedMW(
+			model.ScopeBalanceWithdraw,
+			activeMW(stepUpMW(
+				handlers.NewBalanceWithdrawHandler(deps.BalanceService),
+			)),
+		),
 	)
 
 	mux.Handle(
 		"GET /api/user/withdrawals",
-		authMW(
+		scopedMW(
+			model.ScopeWithdrawalsRead,
 			middleware.Gzip(handlers.NewWithdrawalsHandler(deps.WithdrawalsService)),
 		),
 	)
 
+	mux.Handle(
+		"GET /api/user/ledger",
+		scopedMW(
+			model.ScopeLedgerRead,
+			middleware.Gzip(handlers.NewLedgerHandler(deps.LedgerService)),
+		),
+	)
+
+	mux.Handle(
+		"GET /api/admin/users",
+		adminMW(
+			handlers.NewAdminUsersSearchHandler(deps.AdminService),
+			model.RoleAdmin,
+			model.RoleSupport,
+		),
+	)
+	mux.Handle(
+		"GET /api/admin/users/{id}/orders",
+		adminMW(
+			middleware.Gzip(
+				handlers.NewAdminUserOrdersHandler(deps.AdminService),
+			),
+			model.RoleAdmin,
+			model.RoleSupport,
+		),
+	)
+	mux.Handle(
+		"GET /api/admin/users/{id}/withdrawals",
+		adminMW(
+			middleware.Gzip(
+				handlers.NewAdminUserWithdrawalsHandler(deps.AdminService),
+			),
+			model.RoleAdmin,
+			model.RoleSupport,
+		),
+	)
+	mux.Handle(
+		"POST /api/admin/users/{id}/balance/adjustments",
+		adminMW(
+			handlers.NewAdminBalanceAdjustHandler(deps.AdminService),
+			model.RoleAdmin,
+		),
+	)
+	mux.Handle(
+		"PUT /api/admin/users/{id}/status",
+		adminMW(
+			handlers.NewAdminUserStatusHandler(deps.AdminService),
+			model.RoleAdmin,
+		),
+	)
+	mux.Handle(
+		"GET /api/admin/audit",
+		adminMW(
+			handlers.NewAdminAuditHandler(deps.AdminService),
+			model.RoleAdmin,
+		),
+	)
+
+	var handler http.Handler = mux
+	if deps.Metrics != nil {
+		mux.Handle(
+			"GET /metrics",
+			promhttp.HandlerFor(deps.Metrics, promhttp.HandlerOpts{}),
+		)
+		handler = middleware.Metrics(deps.Metrics)(mux)
+	}
+
 	return &Router{
-		router: logMW(mux),
+		router: logMW(
+			middleware.Trace()(middleware.RealIP(deps.TrustedProxies)(handler)),
+		),
 	}
 }
 
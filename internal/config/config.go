@@ -5,7 +5,10 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,14 +16,66 @@ var (
 	ErrParameterNotSet = errors.New("config parameter is not set")
 )
 
+const (
+	AccrualProviderHTTP   = "http"
+	AccrualProviderFile   = "file"
+	AccrualProviderMemory = "memory"
+)
+
+const (
+	TraceExporterNone   = "none"
+	TraceExporterStdout = "stdout"
+	TraceExporterOTLP   = "otlp"
+)
+
+// JWTKey ключ подписи в PEM, kid - идентификатор в заголовке токена.
+// RetireAt - момент, с которого токены прежнего ключа не принимаются,
+// нулевой - пока ключ в списке.
+type JWTKey struct {
+	ID       string
+	Path     string
+	RetireAt time.Time
+}
+
 type Config struct {
 	LogLevel             string
 	RunAddress           string
 	DatabaseURI          string
 	AccrualSystemAddress string
+	AccrualProvider      string
+	AccrualProviderFile  string
 	AccrualPollInterval  time.Duration
+	AccrualBackoffBase   time.Duration
+	AccrualBackoffMax    time.Duration
+	AccrualMaxAttempts   int
+	AccrualMaxOrderAge   time.Duration
+	AccrualRateLimit     int
+	BreakerFailureRatio  float64
+	BreakerMinRequests   int
+	BreakerCoolDown      time.Duration
 	JWTSecret            string
 	JWTTTL               time.Duration
+	JWTRefreshTTL        time.Duration
+	JWTKeys              []JWTKey
+	JWTSecretRetireAt    time.Time
+	JWTIssuer            string
+	JWTAudience          string
+	JWTLeeway            time.Duration
+	JWTLegacyUntil       time.Time
+	LoginMaxFailures     int
+	LoginIPMaxFailures   int
+	LoginFailureWindow   time.Duration
+	LoginLockout         time.Duration
+	LoginMaxLockout      time.Duration
+	TrustedProxies       []netip.Prefix
+	Argon2Memory         uint32
+	Argon2Iterations     uint32
+	Argon2Parallelism    uint8
+	PasswordHashLimit    int
+	PasswordMinClasses   int
+	PasswordDenyList     string
+	TraceExporter        string
+	OTLPEndpoint         string
 }
 
 func getenvOr(key, def string) string {
@@ -51,11 +106,61 @@ func NewConfig() (*Config, error) {
 		getenvOr("ACCRUAL_SYSTEM_ADDRESS", ""),
 		"accrual system address",
 	)
+	accrualProvider := flag.String(
+		"accrual-provider",
+		getenvOr("ACCRUAL_PROVIDER", AccrualProviderHTTP),
+		"accrual provider: http, file or memory (default: http)",
+	)
+	accrualProviderFile := flag.String(
+		"accrual-provider-file",
+		getenvOr("ACCRUAL_PROVIDER_FILE", ""),
+		"json script for file accrual provider",
+	)
 	pollInterval := flag.String(
 		"poll-interval",
 		getenvOr("ACCRUAL_POLL_INTERVAL", "5s"),
 		"accrual poll interval (default: 5s)",
 	)
+	backoffBase := flag.String(
+		"accrual-backoff-base",
+		getenvOr("ACCRUAL_BACKOFF_BASE", "1s"),
+		"initial per-order accrual poll delay (default: 1s)",
+	)
+	backoffMax := flag.String(
+		"accrual-backoff-max",
+		getenvOr("ACCRUAL_BACKOFF_MAX", "10m"),
+		"max per-order accrual poll delay (default: 10m)",
+	)
+	maxAttempts := flag.String(
+		"accrual-max-attempts",
+		getenvOr("ACCRUAL_MAX_ATTEMPTS", "100"),
+		"park order after N failed accrual requests, 0 - unlimited (default: 100)",
+	)
+	maxOrderAge := flag.String(
+		"accrual-max-order-age",
+		getenvOr("ACCRUAL_MAX_ORDER_AGE", "168h"),
+		"park order older than this, 0 - unlimited (default: 168h)",
+	)
+	rateLimit := flag.String(
+		"accrual-rate-limit",
+		getenvOr("ACCRUAL_RATE_LIMIT", "0"),
+		"accrual requests per minute, 0 - learn from 429 (default: 0)",
+	)
+	breakerRatio := flag.String(
+		"accrual-breaker-ratio",
+		getenvOr("ACCRUAL_BREAKER_FAILURE_RATIO", "0.5"),
+		"accrual failure ratio to open circuit breaker, 0 - disabled (default: 0.5)",
+	)
+	breakerMinRequests := flag.String(
+		"accrual-breaker-min-requests",
+		getenvOr("ACCRUAL_BREAKER_MIN_REQUESTS", "10"),
+		"min accrual requests per minute before breaker may open (default: 10)",
+	)
+	breakerCoolDown := flag.String(
+		"accrual-breaker-cooldown",
+		getenvOr("ACCRUAL_BREAKER_COOLDOWN", "30s"),
+		"accrual circuit breaker cool-down (default: 30s)",
+	)
 	JWTSecret := flag.String(
 		"jwt-secret",
 		getenvOr("JWT_SECRET", ""),
@@ -63,8 +168,114 @@ func NewConfig() (*Config, error) {
 	)
 	JWTTTL := flag.String(
 		"jwt-ttl",
-		getenvOr("JWT_TTL", "24h"),
-		"jwt token ttl (default: 24h)",
+		getenvOr("JWT_TTL", "15m"),
+		"jwt access token ttl (default: 15m)",
+	)
+	JWTRefreshTTL := flag.String(
+		"jwt-refresh-ttl",
+		getenvOr("JWT_REFRESH_TTL", "720h"),
+		"refresh token ttl (default: 720h)",
+	)
+
+	JWTKeys := flag.String(
+		"jwt-keys",
+		getenvOr("JWT_KEYS", ""),
+		"jwt signing keys kid=path.pem[@RFC3339 retire time],..., first one signs (default: HS256 with jwt-secret)",
+	)
+	JWTSecretRetireAt := flag.String(
+		"jwt-secret-retire-at",
+		getenvOr("JWT_SECRET_RETIRE_AT", ""),
+		"stop accepting HS256 tokens signed by jwt-secret at RFC3339 time when jwt-keys are set",
+	)
+	JWTIssuer := flag.String(
+		"jwt-issuer",
+		getenvOr("JWT_ISSUER", "gophermart"),
+		"jwt iss claim (default: gophermart)",
+	)
+	JWTAudience := flag.String(
+		"jwt-audience",
+		getenvOr("JWT_AUDIENCE", "gophermart"),
+		"jwt aud claim (default: gophermart)",
+	)
+	JWTLeeway := flag.String(
+		"jwt-leeway",
+		getenvOr("JWT_LEEWAY", "30s"),
+		"allowed clock skew for exp/nbf/iat (default: 30s)",
+	)
+	JWTLegacyUntil := flag.String(
+		"jwt-legacy-until",
+		getenvOr("JWT_LEGACY_UNTIL", ""),
+		"accept old format tokens without iss/aud/sub until RFC3339 time",
+	)
+	loginMaxFailures := flag.String(
+		"login-max-failures",
+		getenvOr("LOGIN_MAX_FAILURES", "5"),
+		"failed logins per account before lockout, 0 - disabled (default: 5)",
+	)
+	loginIPMaxFailures := flag.String(
+		"login-ip-max-failures",
+		getenvOr("LOGIN_IP_MAX_FAILURES", "50"),
+		"failed logins per client ip before lockout, 0 - disabled (default: 50)",
+	)
+	loginFailureWindow := flag.String(
+		"login-failure-window",
+		getenvOr("LOGIN_FAILURE_WINDOW", "15m"),
+		"window for counting failed logins (default: 15m)",
+	)
+	loginLockout := flag.String(
+		"login-lockout",
+		getenvOr("LOGIN_LOCKOUT", "1m"),
+		"first login lockout, doubles on each next one (default: 1m)",
+	)
+	loginMaxLockout := flag.String(
+		"login-max-lockout",
+		getenvOr("LOGIN_MAX_LOCKOUT", "1h"),
+		"max login lockout (default: 1h)",
+	)
+	trustedProxies := flag.String(
+		"trusted-proxies",
+		getenvOr("TRUSTED_PROXIES", ""),
+		"comma separated proxy CIDRs whose X-Forwarded-For is trusted for client ip",
+	)
+	argon2Memory := flag.String(
+		"argon2-memory",
+		getenvOr("PASSWORD_ARGON2_MEMORY", "65536"),
+		"argon2id memory for password hashing in KiB (default: 65536)",
+	)
+	argon2Iterations := flag.String(
+		"argon2-iterations",
+		getenvOr("PASSWORD_ARGON2_ITERATIONS", "3"),
+		"argon2id iterations for password hashing (default: 3)",
+	)
+	argon2Parallelism := flag.String(
+		"argon2-parallelism",
+		getenvOr("PASSWORD_ARGON2_PARALLELISM", "2"),
+		"argon2id parallelism for password hashing (default: 2)",
+	)
+	passwordHashLimit := flag.String(
+		"password-hash-limit",
+		getenvOr("PASSWORD_HASH_LIMIT", "8"),
+		"max concurrent password hash checks, each takes argon2 memory, 0 - no limit (default: 8)",
+	)
+	passwordMinClasses := flag.String(
+		"password-min-classes",
+		getenvOr("PASSWORD_MIN_CLASSES", "0"),
+		"min character classes (lower, upper, digits, symbols) in password, 0 - any (default: 0)",
+	)
+	passwordDenyList := flag.String(
+		"password-deny-list",
+		getenvOr("PASSWORD_DENY_LIST", ""),
+		"file with common or breached passwords, one per line",
+	)
+	traceExporter := flag.String(
+		"trace-exporter",
+		getenvOr("TRACE_EXPORTER", "none"),
+		"trace exporter: none, stdout, otlp (default: none)",
+	)
+	otlpEndpoint := flag.String(
+		"otlp-endpoint",
+		getenvOr("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+		"otlp http endpoint for traces (default: localhost:4318)",
 	)
 
 	flag.Parse()
@@ -73,10 +284,26 @@ func NewConfig() (*Config, error) {
 		return nil, fmt.Errorf("database URI error %w", ErrParameterNotSet)
 	}
 
-	if *accrualSysAddress == "" {
+	switch *accrualProvider {
+	case AccrualProviderHTTP:
+		if *accrualSysAddress == "" {
+			return nil, fmt.Errorf(
+				"accrual system address error %w",
+				ErrParameterNotSet,
+			)
+		}
+	case AccrualProviderFile:
+		if *accrualProviderFile == "" {
+			return nil, fmt.Errorf(
+				"accrual provider file error %w",
+				ErrParameterNotSet,
+			)
+		}
+	case AccrualProviderMemory:
+	default:
 		return nil, fmt.Errorf(
-			"accrual system address error %w",
-			ErrParameterNotSet,
+			"invalid accrual provider %q: must be one of http, file, memory",
+			*accrualProvider,
 		)
 	}
 
@@ -89,26 +316,352 @@ func NewConfig() (*Config, error) {
 		)
 	}
 
-	if *JWTSecret == "" {
+	backoffBaseDuration, err := time.ParseDuration(*backoffBase)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid accrual backoff base %q: %w",
+			*backoffBase,
+			err,
+		)
+	}
+
+	backoffMaxDuration, err := time.ParseDuration(*backoffMax)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid accrual backoff max %q: %w",
+			*backoffMax,
+			err,
+		)
+	}
+
+	maxAttemptsNum, err := strconv.Atoi(*maxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid accrual max attempts %q: %w",
+			*maxAttempts,
+			err,
+		)
+	}
+	if maxAttemptsNum < 0 {
+		return nil, fmt.Errorf(
+			"invalid accrual max attempts %q: must not be negative",
+			*maxAttempts,
+		)
+	}
+
+	maxOrderAgeDuration, err := time.ParseDuration(*maxOrderAge)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid accrual max order age %q: %w",
+			*maxOrderAge,
+			err,
+		)
+	}
+
+	rateLimitNum, err := strconv.Atoi(*rateLimit)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid accrual rate limit %q: %w",
+			*rateLimit,
+			err,
+		)
+	}
+	if rateLimitNum < 0 {
+		return nil, fmt.Errorf(
+			"invalid accrual rate limit %q: must not be negative",
+			*rateLimit,
+		)
+	}
+
+	breakerRatioNum, err := strconv.ParseFloat(*breakerRatio, 64)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid accrual breaker ratio %q: %w",
+			*breakerRatio,
+			err,
+		)
+	}
+	if breakerRatioNum < 0 || breakerRatioNum > 1 {
+		return nil, fmt.Errorf(
+			"invalid accrual breaker ratio %q: must be in [0, 1]",
+			*breakerRatio,
+		)
+	}
+
+	breakerMinRequestsNum, err := strconv.Atoi(*breakerMinRequests)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid accrual breaker min requests %q: %w",
+			*breakerMinRequests,
+			err,
+		)
+	}
+
+	breakerCoolDownDuration, err := time.ParseDuration(*breakerCoolDown)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid accrual breaker cool-down %q: %w",
+			*breakerCoolDown,
+			err,
+		)
+	}
+
+	jwtKeys, err := parseJWTKeys(*JWTKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if *JWTSecret == "" && len(jwtKeys) == 0 {
 		return nil, fmt.Errorf("no jwt token set %w", ErrParameterNotSet)
 	}
 
+	var jwtSecretRetireAtTime time.Time
+	if *JWTSecretRetireAt != "" {
+		jwtSecretRetireAtTime, err = time.Parse(time.RFC3339, *JWTSecretRetireAt)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid jwt secret retire time %q: %w",
+				*JWTSecretRetireAt,
+				err,
+			)
+		}
+	}
+
 	jwtTTLDuration, err := time.ParseDuration(*JWTTTL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid jwt ttl %q: %w", *JWTTTL, err)
 	}
 
+	jwtRefreshTTLDuration, err := time.ParseDuration(*JWTRefreshTTL)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid jwt refresh ttl %q: %w",
+			*JWTRefreshTTL,
+			err,
+		)
+	}
+
+	jwtLeewayDuration, err := time.ParseDuration(*JWTLeeway)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt leeway %q: %w", *JWTLeeway, err)
+	}
+
+	var jwtLegacyUntilTime time.Time
+	if *JWTLegacyUntil != "" {
+		jwtLegacyUntilTime, err = time.Parse(time.RFC3339, *JWTLegacyUntil)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid jwt legacy until %q: %w",
+				*JWTLegacyUntil,
+				err,
+			)
+		}
+	}
+
+	loginMaxFailuresNum, err := strconv.Atoi(*loginMaxFailures)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid login max failures %q: %w",
+			*loginMaxFailures,
+			err,
+		)
+	}
+
+	loginIPMaxFailuresNum, err := strconv.Atoi(*loginIPMaxFailures)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid login ip max failures %q: %w",
+			*loginIPMaxFailures,
+			err,
+		)
+	}
+
+	loginFailureWindowDuration, err := time.ParseDuration(*loginFailureWindow)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid login failure window %q: %w",
+			*loginFailureWindow,
+			err,
+		)
+	}
+
+	loginLockoutDuration, err := time.ParseDuration(*loginLockout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid login lockout %q: %w", *loginLockout, err)
+	}
+
+	loginMaxLockoutDuration, err := time.ParseDuration(*loginMaxLockout)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"invalid login max lockout %q: %w",
+			*loginMaxLockout,
+			err,
+		)
+	}
+	if loginMaxLockoutDuration < loginLockoutDuration {
+		return nil, fmt.Errorf(
+			"invalid login max lockout %q: less than login lockout %q",
+			*loginMaxLockout,
+			*loginLockout,
+		)
+	}
+
+	trustedProxiesList, err := parseTrustedProxies(*trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2MemoryNum, err := strconv.ParseUint(*argon2Memory, 10, 32)
+	if err != nil || argon2MemoryNum < 8*1024 {
+		return nil, fmt.Errorf(
+			"invalid argon2 memory %q: must be at least 8192 KiB",
+			*argon2Memory,
+		)
+	}
+
+	argon2IterationsNum, err := strconv.ParseUint(*argon2Iterations, 10, 32)
+	if err != nil || argon2IterationsNum < 1 {
+		return nil, fmt.Errorf(
+			"invalid argon2 iterations %q: must be positive",
+			*argon2Iterations,
+		)
+	}
+
+	argon2ParallelismNum, err := strconv.ParseUint(*argon2Parallelism, 10, 8)
+	if err != nil || argon2ParallelismNum < 1 {
+		return nil, fmt.Errorf(
+			"invalid argon2 parallelism %q: must be in [1, 255]",
+			*argon2Parallelism,
+		)
+	}
+
+	passwordHashLimitNum, err := strconv.Atoi(*passwordHashLimit)
+	if err != nil || passwordHashLimitNum < 0 {
+		return nil, fmt.Errorf(
+			"invalid password hash limit %q: must be non-negative",
+			*passwordHashLimit,
+		)
+	}
+
+	passwordMinClassesNum, err := strconv.Atoi(*passwordMinClasses)
+	if err != nil || passwordMinClassesNum < 0 || passwordMinClassesNum > 4 {
+		return nil, fmt.Errorf(
+			"invalid password min classes %q: must be in [0, 4]",
+			*passwordMinClasses,
+		)
+	}
+
+	switch *traceExporter {
+	case TraceExporterNone, TraceExporterStdout, TraceExporterOTLP:
+	default:
+		return nil, fmt.Errorf(
+			"invalid trace exporter %q: must be one of none, stdout, otlp",
+			*traceExporter,
+		)
+	}
+
 	return &Config{
 		LogLevel:             *logLevel,
 		RunAddress:           *runAddress,
 		DatabaseURI:          *databaseURI,
 		AccrualSystemAddress: *accrualSysAddress,
+		AccrualProvider:      *accrualProvider,
+		AccrualProviderFile:  *accrualProviderFile,
 		AccrualPollInterval:  pollIntervalDuration,
+		AccrualBackoffBase:   backoffBaseDuration,
+		AccrualBackoffMax:    backoffMaxDuration,
+		AccrualMaxAttempts:   maxAttemptsNum,
+		AccrualMaxOrderAge:   maxOrderAgeDuration,
+		AccrualRateLimit:     rateLimitNum,
+		BreakerFailureRatio:  breakerRatioNum,
+		BreakerMinRequests:   breakerMinRequestsNum,
+		BreakerCoolDown:      breakerCoolDownDuration,
 		JWTSecret:            *JWTSecret,
 		JWTTTL:               jwtTTLDuration,
+		JWTRefreshTTL:        jwtRefreshTTLDuration,
+		JWTKeys:              jwtKeys,
+		JWTSecretRetireAt:    jwtSecretRetireAtTime,
+		JWTIssuer:            *JWTIssuer,
+		JWTAudience:          *JWTAudience,
+		JWTLeeway:            jwtLeewayDuration,
+		JWTLegacyUntil:       jwtLegacyUntilTime,
+		LoginMaxFailures:     loginMaxFailuresNum,
+		LoginIPMaxFailures:   loginIPMaxFailuresNum,
+		LoginFailureWindow:   loginFailureWindowDuration,
+		LoginLockout:         loginLockoutDuration,
+		LoginMaxLockout:      loginMaxLockoutDuration,
+		TrustedProxies:       trustedProxiesList,
+		Argon2Memory:         uint32(argon2MemoryNum),
+		Argon2Iterations:     uint32(argon2IterationsNum),
+		Argon2Parallelism:    uint8(argon2ParallelismNum),
+		PasswordHashLimit:    passwordHashLimitNum,
+		PasswordMinClasses:   passwordMinClassesNum,
+		PasswordDenyList:     *passwordDenyList,
+		TraceExporter:        *traceExporter,
+		OTLPEndpoint:         *otlpEndpoint,
 	}, nil
 }
 
+// parseJWTKeys разбирает список "kid=path,kid=path@RFC3339". Время вывода
+// задаётся только прежним ключам: первый ключ подписывает токены.
+func parseJWTKeys(v string) ([]JWTKey, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+
+	var keys []JWTKey
+	seen := make(map[string]struct{})
+	for i, item := range strings.Split(v, ",") {
+		kid, path, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf(
+				"invalid jwt key %q: must be kid=path[@retire time]",
+				item,
+			)
+		}
+		if _, ok := seen[kid]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", kid)
+		}
+		seen[kid] = struct{}{}
+
+		key := JWTKey{ID: kid, Path: path}
+		if at := strings.LastIndex(path, "@"); at >= 0 {
+			if i == 0 {
+				return nil, fmt.Errorf(
+					"invalid jwt key %q: signing key can not be retired",
+					item,
+				)
+			}
+			retireAt, err := time.Parse(time.RFC3339, path[at+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid jwt key %q: %w", item, err)
+			}
+			key.Path, key.RetireAt = path[:at], retireAt
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// parseTrustedProxies разбирает список CIDR "10.0.0.0/8,::1/128".
+func parseTrustedProxies(v string) ([]netip.Prefix, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(v, ",") {
+		p, err := netip.ParsePrefix(strings.TrimSpace(item))
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", item, err)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+
+	return prefixes, nil
+}
+
 func (c *Config) String() string {
 	b, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrOrderNotParked = errors.New("order not found or not parked")
+)
+
+type ParkedOrdersRepository interface {
+	GetParkedOrders(ctx context.Context) ([]ParkedOrder, error)
+	RequeueOrder(ctx context.Context, number string) error
+	RequeueAll(ctx context.Context) (int64, error)
+}
+
+// AccrualFailure запись dead-letter таблицы: неудачное обращение к accrual
+// по конкретному заказу с сырым ответом.
+type AccrualFailure struct {
+	ID         int
+	OrderID    int
+	HTTPStatus int
+	Response   string
+	Error      string
+	CreatedAt  time.Time
+}
+
+// ParkedOrder заказ, снятый с опроса accrual до ручного разбора.
+type ParkedOrder struct {
+	ID         int
+	UserID     int
+	Number     string
+	Status     OrderStatus
+	Attempts   int
+	ParkedAt   time.Time
+	ParkReason string
+	Failures   int
+	LastError  string
+}
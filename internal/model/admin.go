@@ -0,0 +1,82 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidAdjustment нулевая сумма или пустая причина корректировки.
+	ErrInvalidAdjustment = errors.New("invalid balance adjustment")
+	// ErrInvalidStatusChange неизвестный статус, пустая причина или не
+	// указано, что делать с балансом при закрытии.
+	ErrInvalidStatusChange = errors.New("invalid account status change")
+)
+
+// Действия администраторов в журнале аудита.
+const (
+	AuditBalanceAdjustment = "balance_adjustment"
+	AuditUserSuspended     = "user_suspended"
+	AuditUserReactivated   = "user_reactivated"
+	AuditUserClosed        = "user_closed"
+	// AuditUserErased удаление аккаунта по запросу самого пользователя,
+	// actor - он же.
+	AuditUserErased = "user_erased"
+)
+
+// BalanceSettlement что сделать с остатком баланса при закрытии аккаунта.
+type BalanceSettlement string
+
+const (
+	// SettlementForfeit остаток сгорает.
+	SettlementForfeit BalanceSettlement = "forfeit"
+	// SettlementPayout остаток выплачен пользователю вне системы.
+	SettlementPayout BalanceSettlement = "settle"
+)
+
+// StatusChange смена статуса пользователя администратором. В Audit
+// заполняются ID, CreatedAt и Amount - списанный при закрытии остаток со
+// знаком минус.
+type StatusChange struct {
+	Audit      AuditRecord
+	Status     UserStatus
+	Settlement BalanceSettlement
+}
+
+//go:generate mockgen -destination ../service/admin/mocks/admin_repo.go . AdminRepository
+type AdminRepository interface {
+	// SearchUsers пользователи, логин которых содержит query, без учёта
+	// регистра. Пустой query - все пользователи.
+	SearchUsers(ctx context.Context, query string, limit int) ([]User, error)
+	// AdjustBalance в одной транзакции меняет баланс пользователя,
+	// записывает проводки и запись аудита. Заполняет ID и CreatedAt
+	// записи. ErrUserNotFound - пользователя нет, ErrAccountClosed -
+	// аккаунт закрыт, ErrInsufficientPoints - баланс стал бы отрицательным.
+	AdjustBalance(ctx context.Context, rec *AuditRecord) error
+	// ChangeUserStatus в одной транзакции меняет статус и пишет запись
+	// аудита. При закрытии остаток баланса списывается проводкой по
+	// Settlement, сессии отзываются, API ключи удаляются.
+	// ErrUserNotFound - пользователя нет, ErrInvalidStatusTransition -
+	// переход запрещён.
+	ChangeUserStatus(ctx context.Context, change *StatusChange) error
+	GetAuditRecords(
+		ctx context.Context,
+		targetUserID int,
+		limit int,
+	) ([]AuditRecord, error)
+}
+
+// AuditRecord действие администратора над пользователем (или удаление
+// аккаунта самим пользователем). Записи только добавляются.
+type AuditRecord struct {
+	ID           int
+	ActorID      int
+	Action       string
+	TargetUserID int
+	// Amount сумма корректировки баланса или списанный при закрытии
+	// остаток, для прочих действий 0.
+	Amount    Kopek
+	Reason    string
+	CreatedAt time.Time
+}
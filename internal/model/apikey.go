@@ -0,0 +1,72 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	// ErrInvalidAPIKeyParams неверное имя, права или срок действия ключа
+	// при создании.
+	ErrInvalidAPIKeyParams = errors.New("invalid api key parameters")
+)
+
+// APIKeyPrefix начало каждого API ключа: по нему ключ отличается от JWT
+// в заголовке Authorization и находится сканерами секретов.
+const APIKeyPrefix = "gm_"
+
+// Права API ключей.
+const (
+	ScopeOrdersRead      = "orders:read"
+	ScopeOrdersWrite     = "orders:write"
+	ScopeBalanceRead     = "balance:read"
+	ScopeBalanceWithdraw = "balance:withdraw"
+	ScopeWithdrawalsRead = "withdrawals:read"
+	ScopeLedgerRead      = "ledger:read"
+)
+
+// APIKeyScopes все права, которые можно выдать ключу.
+var APIKeyScopes = []string{
+	ScopeOrdersRead,
+	ScopeOrdersWrite,
+	ScopeBalanceRead,
+	ScopeBalanceWithdraw,
+	ScopeWithdrawalsRead,
+	ScopeLedgerRead,
+}
+
+func IsValidScope(scope string) bool {
+	return slices.Contains(APIKeyScopes, scope)
+}
+
+//go:generate mockgen -destination ../service/auth/mocks/api_keys_repo.go . APIKeysRepository
+type APIKeysRepository interface {
+	CreateAPIKey(ctx context.Context, k *APIKey) error
+	GetAPIKeysByUserID(ctx context.Context, userID int) ([]APIKey, error)
+	// GetAPIKeyByHash возвращает ErrAPIKeyNotFound, если ключа нет.
+	GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	// DeleteAPIKey удаляет ключ пользователя, ErrAPIKeyNotFound - ключа нет
+	// или он принадлежит другому пользователю.
+	DeleteAPIKey(ctx context.Context, userID, id int) error
+	TouchAPIKey(ctx context.Context, id int) error
+}
+
+// APIKey ключ для машинных клиентов (кассы партнёров). Сам ключ
+// хранится только в виде хеша, Prefix - его начало для опознания в списке.
+type APIKey struct {
+	ID         int
+	UserID     int
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	// UserStatus статус владельца, заполняется GetAPIKeyByHash.
+	UserStatus UserStatus
+}
@@ -0,0 +1,71 @@
+package model
+
+import "errors"
+
+type AuthErrorKind int
+
+const (
+	AuthMalformed AuthErrorKind = iota
+	AuthBadSignature
+	AuthExpired
+	AuthNotYetValid
+	AuthInvalidClaims
+	AuthRevoked
+)
+
+func (k AuthErrorKind) String() string {
+	switch k {
+	case AuthMalformed:
+		return "malformed"
+	case AuthBadSignature:
+		return "bad_signature"
+	case AuthExpired:
+		return "expired"
+	case AuthNotYetValid:
+		return "not_yet_valid"
+	case AuthInvalidClaims:
+		return "invalid_claims"
+	case AuthRevoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+// AuthError причина отказа в аутентификации по токену. Для errors.Is
+// отозванный токен - ErrTokenRevoked, остальные - ErrInvalidToken.
+type AuthError struct {
+	Kind AuthErrorKind
+	Err  error
+}
+
+func NewAuthError(kind AuthErrorKind, err error) *AuthError {
+	return &AuthError{Kind: kind, Err: err}
+}
+
+func (e *AuthError) Error() string {
+	if e.Err == nil {
+		return "token " + e.Kind.String()
+	}
+	return "token " + e.Kind.String() + ": " + e.Err.Error()
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AuthError) Is(target error) bool {
+	if e.Kind == AuthRevoked {
+		return target == ErrTokenRevoked
+	}
+	return target == ErrInvalidToken
+}
+
+// AuthErrorKindOf причина отказа, если err - *AuthError.
+func AuthErrorKindOf(err error) (AuthErrorKind, bool) {
+	var ae *AuthError
+	if !errors.As(err, &ae) {
+		return 0, false
+	}
+	return ae.Kind, true
+}
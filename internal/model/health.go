@@ -0,0 +1,23 @@
+package model
+
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthFail     = "fail"
+)
+
+// HealthReport отчёт /readyz. Status - fail, если не прошла хотя бы одна
+// критичная проверка, degraded - если не прошли только некритичные.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
+
+// HealthCheck результат одной именованной проверки.
+type HealthCheck struct {
+	Status   string         `json:"status"`
+	Critical bool           `json:"critical"`
+	Duration string         `json:"duration"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type LedgerRepository interface {
+	GetEntriesByUserID(ctx context.Context, userID int) ([]LedgerEntry, error)
+}
+
+// LedgerEntry одна проводка по счёту пользователя. Положительная сумма -
+// зачисление, отрицательная - списание.
+type LedgerEntry struct {
+	ID        int
+	UserID    int
+	Kind      LedgerEntryKind
+	Amount    Kopek
+	Reference string
+	CreatedAt time.Time
+}
+
+type LedgerEntryKind int
+
+const (
+	EntryAccrual LedgerEntryKind = iota
+	EntryWithdrawal
+	// EntryAdjustment ручная корректировка баланса администратором.
+	EntryAdjustment
+	// EntryForfeit остаток баланса закрытого аккаунта (или начисление
+	// после закрытия) списан в пользу программы.
+	EntryForfeit
+	// EntrySettlement остаток баланса закрытого аккаунта выплачен вне
+	// системы.
+	EntrySettlement
+)
+
+func (k LedgerEntryKind) String() string {
+	switch k {
+	case EntryAccrual:
+		return "ACCRUAL"
+	case EntryWithdrawal:
+		return "WITHDRAWAL"
+	case EntryAdjustment:
+		return "ADJUSTMENT"
+	case EntryForfeit:
+		return "FORFEIT"
+	case EntrySettlement:
+		return "SETTLEMENT"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (k LedgerEntryKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *LedgerEntryKind) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return k.fromString(v)
+	case []byte:
+		return k.fromString(string(v))
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+}
+
+func (k *LedgerEntryKind) fromString(v string) error {
+	switch v {
+	case "ACCRUAL":
+		*k = EntryAccrual
+	case "WITHDRAWAL":
+		*k = EntryWithdrawal
+	case "ADJUSTMENT":
+		*k = EntryAdjustment
+	case "FORFEIT":
+		*k = EntryForfeit
+	case "SETTLEMENT":
+		*k = EntrySettlement
+	default:
+		return fmt.Errorf("unknown ledger entry kind %q", v)
+	}
+	return nil
+}
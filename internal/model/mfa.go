@@ -0,0 +1,108 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrMFANotEnrolled    = errors.New("two-factor authentication not enabled")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrInvalidMFACode неверный, уже использованный или устаревший код.
+	ErrInvalidMFACode = errors.New("invalid two-factor code")
+	// ErrInvalidMFAChallenge токен второго шага входа неизвестен, истёк
+	// или исчерпал попытки.
+	ErrInvalidMFAChallenge = errors.New("invalid mfa challenge")
+	// ErrMFARequired вход или операция требуют код второго фактора.
+	ErrMFARequired = errors.New("two-factor code required")
+)
+
+//go:generate mockgen -destination ../service/auth/mocks/mfa_repo.go . MFARepository
+type MFARepository interface {
+	// GetMFA возвращает ErrMFANotEnrolled, если пользователь не начинал
+	// подключение.
+	GetMFA(ctx context.Context, userID int) (*MFA, error)
+	// SaveMFASecret начинает подключение (или начинает его заново) с новым
+	// секретом. ErrMFAAlreadyEnabled - второй фактор уже подключён.
+	SaveMFASecret(ctx context.Context, userID int, secret string) error
+	// EnableMFA в одной транзакции подтверждает подключение, запоминает
+	// использованный шаг кода и сохраняет хеши кодов восстановления.
+	// ErrMFANotEnrolled - нет неподтверждённого подключения.
+	EnableMFA(
+		ctx context.Context,
+		userID int,
+		step int64,
+		recoveryHashes []string,
+	) error
+	// DisableMFA удаляет секрет и коды восстановления.
+	DisableMFA(ctx context.Context, userID int) error
+	// UseMFAStep запоминает шаг принятого кода. ErrInvalidMFACode - код
+	// этого или более позднего шага уже принимался.
+	UseMFAStep(ctx context.Context, userID int, step int64) error
+	// UseRecoveryCode помечает код восстановления использованным.
+	// ErrInvalidMFACode - кода нет или он уже использован.
+	UseRecoveryCode(ctx context.Context, userID int, hash string) error
+	SetMFAWithdrawRequired(ctx context.Context, userID int, required bool) error
+
+	// CreateMFAChallenge сохраняет токен второго шага входа.
+	CreateMFAChallenge(ctx context.Context, c *MFAChallenge) error
+	// GetMFAChallenge возвращает ErrInvalidMFAChallenge, если токена нет
+	// или он истёк.
+	GetMFAChallenge(ctx context.Context, hash string) (*MFAChallenge, error)
+	// FailMFAChallenge учитывает неверный код, возвращает число попыток.
+	FailMFAChallenge(ctx context.Context, id int) (int, error)
+	// DeleteMFAChallenge ErrInvalidMFAChallenge - токен уже использован.
+	DeleteMFAChallenge(ctx context.Context, id int) error
+}
+
+// MFA второй фактор пользователя (TOTP). Пока EnabledAt nil, подключение
+// не подтверждено кодом и вход его не требует.
+type MFA struct {
+	UserID int
+	Secret string
+	// LastStep шаг последнего принятого кода: код нельзя предъявить
+	// повторно.
+	LastStep int64
+	// WithdrawRequired списание баллов требует свежий код.
+	WithdrawRequired bool
+	EnabledAt        *time.Time
+	CreatedAt        time.Time
+}
+
+func (m *MFA) Enabled() bool {
+	return m != nil && m.EnabledAt != nil
+}
+
+// MFAEnrollment секрет для приложения-аутентификатора, показывается один
+// раз при подключении.
+type MFAEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFAChallenge токен второго шага входа: пароль проверен, ожидается код.
+// Хранится только в виде хеша.
+type MFAChallenge struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	Attempts  int
+	ExpiresAt time.Time
+}
+
+// MFAChallengeError пароль верный, для входа нужен код второго фактора.
+// Token передаётся во втором шаге. Для errors.Is - ErrMFARequired.
+type MFAChallengeError struct {
+	Token     string
+	ExpiresIn time.Duration
+}
+
+func (e *MFAChallengeError) Error() string {
+	return fmt.Sprintf("%s, challenge expires in %s", ErrMFARequired, e.ExpiresIn)
+}
+
+func (e *MFAChallengeError) Is(target error) bool {
+	return target == ErrMFARequired
+}
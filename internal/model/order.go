@@ -25,22 +25,41 @@ type OrdersRepository interface {
 }
 
 type Order struct {
-	ID         int
-	UserID     int
-	Number     string
-	Status     OrderStatus
-	Accrual    Kopek
-	UploadedAt time.Time
+	ID           int
+	UserID       int
+	Number       string
+	Status       OrderStatus
+	Accrual      Kopek
+	Goods        []Good
+	Registration RegistrationState
+	Attempts     int
+	UploadedAt   time.Time
+}
+
+// Good позиция чека, передаётся в accrual при регистрации заказа.
+type Good struct {
+	Description string `json:"description"`
+	Price       Kopek  `json:"price"`
 }
 
 func NewOrder(userID int, num string) *Order {
 	return &Order{
-		UserID: userID,
-		Number: num,
-		Status: StatusNew,
+		UserID:       userID,
+		Number:       num,
+		Status:       StatusNew,
+		Registration: RegistrationPending,
 	}
 }
 
+func ValidateGoods(goods []Good) bool {
+	for _, g := range goods {
+		if strings.TrimSpace(g.Description) == "" || g.Price < 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func ValidateNumber(number string) bool {
 	return luhn.ValidateNumber(number)
 }
@@ -104,11 +123,65 @@ func (s *OrderStatus) fromString(v string) error {
 	return nil
 }
 
+// RegistrationState состояние регистрации заказа в accrual (POST /api/orders).
+type RegistrationState int
+
+const (
+	RegistrationPending RegistrationState = iota
+	RegistrationRegistered
+	RegistrationRejected
+)
+
+func (s RegistrationState) String() string {
+	switch s {
+	case RegistrationPending:
+		return "PENDING"
+	case RegistrationRegistered:
+		return "REGISTERED"
+	case RegistrationRejected:
+		return "REJECTED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (s *RegistrationState) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return s.fromString(v)
+	case []byte:
+		return s.fromString(string(v))
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+}
+
+func (s *RegistrationState) fromString(v string) error {
+	switch v {
+	case "PENDING":
+		*s = RegistrationPending
+	case "REGISTERED":
+		*s = RegistrationRegistered
+	case "REJECTED":
+		*s = RegistrationRejected
+	default:
+		return fmt.Errorf("unknown registration state %q", v)
+	}
+	return nil
+}
+
 type Kopek int
 
 func (k Kopek) MarshalJSON() ([]byte, error) {
 	v := int(k)
 
+	// проводки по счёту могут быть отрицательными
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
 	intPart := v / 100
 	frac := v % 100
 
@@ -116,11 +189,11 @@ func (k Kopek) MarshalJSON() ([]byte, error) {
 	// т.к. требований нет, просто визуальное соответствие.
 	switch {
 	case frac == 0:
-		return []byte(fmt.Sprintf("%d", intPart)), nil
+		return []byte(fmt.Sprintf("%s%d", sign, intPart)), nil
 	case frac%10 == 0:
-		return []byte(fmt.Sprintf("%d.%d", intPart, frac/10)), nil
+		return []byte(fmt.Sprintf("%s%d.%d", sign, intPart, frac/10)), nil
 	default:
-		return []byte(fmt.Sprintf("%d.%02d", intPart, frac)), nil
+		return []byte(fmt.Sprintf("%s%d.%02d", sign, intPart, frac)), nil
 	}
 }
 
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrTokenRevoked        = errors.New("token revoked")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused повторное использование уже обменянного
+	// refresh токена: вся цепочка (сессия) отзывается.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+//go:generate mockgen -destination ../service/auth/mocks/sessions_repo.go . SessionsRepository
+type SessionsRepository interface {
+	CreateRefreshToken(ctx context.Context, t *RefreshToken) error
+	// RotateRefreshToken в одной транзакции помечает токен с хешем oldHash
+	// использованным и сохраняет next в той же цепочке. Возвращает
+	// владельца токена с текущей версией токенов.
+	RotateRefreshToken(
+		ctx context.Context,
+		oldHash string,
+		next *RefreshToken,
+	) (*User, error)
+	// RevokeRefreshToken отзывает цепочку, к которой относится токен
+	// пользователя.
+	RevokeRefreshToken(ctx context.Context, userID int, hash string) error
+	// RevokeAccessToken вносит jti в список отозванных до истечения токена.
+	RevokeAccessToken(
+		ctx context.Context,
+		jti string,
+		expiresAt time.Time,
+	) error
+	// RevokeUserSessions увеличивает версию токенов пользователя, отзывает
+	// все его refresh токены и удаляет API ключи.
+	RevokeUserSessions(ctx context.Context, userID int) error
+	// IsAccessTokenValid проверяет, что jti не отозван и версия токенов
+	// пользователя не менялась. Возвращает и текущий статус пользователя.
+	IsAccessTokenValid(
+		ctx context.Context,
+		userID int,
+		jti string,
+		version int,
+	) (bool, UserStatus, error)
+}
+
+// RefreshToken хранится только в виде хеша. FamilyID объединяет токены,
+// полученные последовательной ротацией из одного входа.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// Session refresh токен без самого токена: для выгрузки данных
+// пользователя.
+type Session struct {
+	FamilyID  string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+// TokenPair выдаётся при входе и обмене refresh токена.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// Principal аутентифицированный субъект запроса.
+type Principal struct {
+	UserID    int
+	TokenID   string
+	ExpiresAt time.Time
+	// APIKeyID ключ, которым аутентифицирован запрос, 0 - access токен.
+	APIKeyID int
+	Scopes   []string
+	Roles    []string
+	Status   UserStatus
+}
+
+// HasRole есть хотя бы одна из ролей.
+func (p *Principal) HasRole(roles ...string) bool {
+	for _, r := range roles {
+		if slices.Contains(p.Roles, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope access токен пользователя даёт все права, API ключ - только
+// выданные ему.
+func (p *Principal) HasScope(scope string) bool {
+	if p.APIKeyID == 0 {
+		return true
+	}
+	return slices.Contains(p.Scopes, scope)
+}
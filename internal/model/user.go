@@ -3,7 +3,9 @@ package model
 import (
 	"context"
 	"errors"
-	"unicode/utf8"
+	"fmt"
+	"strings"
+	"time"
 )
 
 var (
@@ -11,34 +13,135 @@ var (
 	ErrUserNotFound           = errors.New("user not found")
 	ErrInvalidCredentials     = errors.New("invalid credentials")
 	ErrPasswordPolicyViolated = errors.New("password policy violated")
-)
-
-const (
-	minPasswordLength = 12
-	maxPasswordLength = 64
+	ErrAccountSuspended       = errors.New("account suspended")
+	ErrAccountClosed          = errors.New("account closed")
+	// ErrPasswordHasherBusy заняты все слоты хеширования паролей, запрос
+	// стоит повторить позже.
+	ErrPasswordHasherBusy = errors.New("password hasher busy")
+	// ErrInvalidStatusTransition переход между статусами запрещён: закрытый
+	// аккаунт не открывается, статус не меняется на тот же.
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
 )
 
 //go:generate mockgen -destination ../service/auth/mocks/users_repo.go . UsersRepository
 type UsersRepository interface {
 	Create(ctx context.Context, u *User) (*User, error)
 	GetByLogin(ctx context.Context, login string) (*User, error)
+	GetByID(ctx context.Context, id int) (*User, error)
+	// UpdatePasswordHash заменяет хеш пароля, только если он всё ещё равен
+	// oldHash: пароль, сменённый параллельно, не перезаписывается. Иначе
+	// ничего не делает.
+	UpdatePasswordHash(
+		ctx context.Context,
+		userID int,
+		oldHash, hash string,
+	) error
+	// ChangePassword в одной транзакции меняет хеш пароля, отзывает все
+	// сессии и удаляет API ключи пользователя. Возвращает пользователя с
+	// новой версией токенов.
+	ChangePassword(ctx context.Context, userID int, hash string) (*User, error)
+	// SetRoles заменяет роли пользователя и отзывает его сессии: роли
+	// записаны в выданных токенах.
+	SetRoles(ctx context.Context, login string, roles []string) error
+	// ExportData профиль, заказы, списания и сессии пользователя одним
+	// согласованным снимком.
+	ExportData(ctx context.Context, userID int) (*UserExport, error)
+	// Erase в одной транзакции обезличивает пользователя: логин заменяется
+	// на ErasedLogin, пароль, роли, сессии, API ключи, второй фактор и
+	// записи ограничения входа удаляются, остаток баланса сгорает, аккаунт
+	// закрывается. Пишется запись аудита AuditUserErased. Заказы, списания,
+	// проводки и аудит остаются за обезличенным id.
+	Erase(ctx context.Context, userID int) error
+}
+
+// Роли пользователей. Пользователь без ролей - обычный клиент.
+const (
+	// RoleAdmin полный доступ к /api/admin, включая корректировки баланса.
+	RoleAdmin = "admin"
+	// RoleSupport поиск пользователей и просмотр их заказов и списаний.
+	RoleSupport = "support"
+)
+
+func IsValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleSupport
 }
 
 type User struct {
 	ID           int
 	Login        string
 	PasswordHash string
+	// TokenVersion меняется при отзыве всех сессий пользователя.
+	TokenVersion int
+	Roles        []string
+	Status       UserStatus
+	CreatedAt    time.Time
+}
+
+// UserStatus состояние аккаунта. suspended - только чтение (на время
+// расследования), closed - окончательно, вход и токены не принимаются.
+type UserStatus string
+
+const (
+	UserStatusActive    UserStatus = "active"
+	UserStatusSuspended UserStatus = "suspended"
+	UserStatusClosed    UserStatus = "closed"
+)
+
+func (s UserStatus) IsValid() bool {
+	switch s {
+	case UserStatusActive, UserStatusSuspended, UserStatusClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanTransitionTo active и suspended переходят друг в друга и в closed,
+// из closed переходов нет.
+func (s UserStatus) CanTransitionTo(next UserStatus) bool {
+	if s == UserStatusClosed || s == next {
+		return false
+	}
+	return next.IsValid()
 }
 
 func NewUser(login string) *User {
 	return &User{Login: login}
 }
 
-func ValidatePassword(password string) error {
-	passLength := utf8.RuneCountInString(password)
-	if passLength < minPasswordLength || passLength > maxPasswordLength {
-		return ErrPasswordPolicyViolated
+// ErasedLogin логин, которым заменяется логин удалённого пользователя.
+func ErasedLogin(userID int) string {
+	return fmt.Sprintf("deleted-%d", userID)
+}
+
+// UserExport все данные пользователя для выгрузки по его запросу.
+type UserExport struct {
+	User        User
+	Orders      []Order
+	Withdrawals []Withdrawal
+	Sessions    []Session
+}
+
+// PasswordViolation нарушенное правило политики паролей.
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError все нарушения политики паролей. Для errors.Is -
+// ErrPasswordPolicyViolated.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Message)
 	}
+	return ErrPasswordPolicyViolated.Error() + ": " + strings.Join(msgs, "; ")
+}
 
-	return nil
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicyViolated
 }
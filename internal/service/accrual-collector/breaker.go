@@ -0,0 +1,193 @@
+package collector
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var ErrBreakerOpen = errors.New("accrual circuit breaker is open")
+
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerPolicy параметры автомата. Автомат размыкается, если за окно Window
+// было не меньше MinRequests запросов и доля ошибок достигла FailureRatio.
+// Через CoolDown пропускается HalfOpenProbes пробных запросов: успех
+// замыкает автомат, ошибка снова размыкает. Нулевой FailureRatio отключает
+// автомат.
+type BreakerPolicy struct {
+	FailureRatio   float64
+	MinRequests    int
+	Window         time.Duration
+	CoolDown       time.Duration
+	HalfOpenProbes int
+}
+
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{
+		FailureRatio:   0.5,
+		MinRequests:    10,
+		Window:         1 * time.Minute,
+		CoolDown:       30 * time.Second,
+		HalfOpenProbes: 1,
+	}
+}
+
+// Breaker circuit breaker для запросов в accrual, общий для всех воркеров
+// коллектора.
+type Breaker struct {
+	policy BreakerPolicy
+	now    func() time.Time
+	onOpen func(coolDown time.Duration)
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	probeAt     time.Time
+}
+
+func NewBreaker(policy BreakerPolicy) *Breaker {
+	return newBreaker(policy, time.Now)
+}
+
+func newBreaker(policy BreakerPolicy, now func() time.Time) *Breaker {
+	if policy.HalfOpenProbes <= 0 {
+		policy.HalfOpenProbes = 1
+	}
+	return &Breaker{
+		policy:      policy,
+		now:         now,
+		windowStart: now(),
+	}
+}
+
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.coolDownPassed() {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Allow сообщает, можно ли выполнить запрос. В полуоткрытом состоянии
+// одновременно пропускается не больше HalfOpenProbes запросов.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if !b.coolDownPassed() {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		fallthrough
+	case BreakerHalfOpen:
+		// пробный запрос мог не вернуть результат (например, отмена
+		// контекста), поэтому через CoolDown пропускаем новые пробы
+		if b.probes >= b.policy.HalfOpenProbes &&
+			b.now().Before(b.probeAt.Add(b.policy.CoolDown)) {
+			return false
+		}
+		if b.probes >= b.policy.HalfOpenProbes {
+			b.probes = 0
+		}
+		b.probes++
+		b.probeAt = b.now()
+		return true
+	default:
+		return true
+	}
+}
+
+// Record учитывает результат запроса, разрешённого Allow.
+func (b *Breaker) Record(success bool) {
+	if b.policy.FailureRatio <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerHalfOpen:
+		if success {
+			b.setState(BreakerClosed)
+			return
+		}
+		b.trip()
+	case BreakerClosed:
+		now := b.now()
+		if now.Sub(b.windowStart) >= b.policy.Window {
+			b.windowStart = now
+			b.requests, b.failures = 0, 0
+		}
+
+		b.requests++
+		if !success {
+			b.failures++
+		}
+
+		if b.requests >= b.policy.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.policy.FailureRatio {
+			b.trip()
+		}
+	}
+}
+
+func (b *Breaker) coolDownPassed() bool {
+	return !b.now().Before(b.openedAt.Add(b.policy.CoolDown))
+}
+
+func (b *Breaker) trip() {
+	b.openedAt = b.now()
+	b.setState(BreakerOpen)
+	if b.onOpen != nil {
+		b.onOpen(b.policy.CoolDown)
+	}
+}
+
+func (b *Breaker) setState(state BreakerState) {
+	if b.state == state {
+		return
+	}
+
+	slog.Warn(
+		"accrual circuit breaker state changed",
+		slog.String("from", b.state.String()),
+		slog.String("to", state.String()),
+		slog.Int("requests", b.requests),
+		slog.Int("failures", b.failures),
+	)
+
+	b.state = state
+	b.probes = 0
+	b.windowStart = b.now()
+	b.requests, b.failures = 0, 0
+}
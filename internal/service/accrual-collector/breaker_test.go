@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newBreaker(BreakerPolicy{
+		FailureRatio:   0.5,
+		MinRequests:    4,
+		Window:         time.Minute,
+		CoolDown:       30 * time.Second,
+		HalfOpenProbes: 1,
+	}, func() time.Time { return now })
+
+	var pausedFor time.Duration
+	b.onOpen = func(d time.Duration) { pausedFor = d }
+
+	// ниже MinRequests автомат не размыкается
+	for range 3 {
+		assert.True(t, b.Allow())
+		b.Record(false)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+
+	assert.True(t, b.Allow())
+	b.Record(true)
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.Equal(t, 30*time.Second, pausedFor)
+	assert.False(t, b.Allow())
+
+	// после cool-down пропускается одна проба
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+
+	b.Record(false)
+	assert.Equal(t, BreakerOpen, b.State())
+
+	now = now.Add(30 * time.Second)
+	assert.True(t, b.Allow())
+	b.Record(true)
+	assert.Equal(t, BreakerClosed, b.State())
+	assert.True(t, b.Allow())
+}
+
+func TestBreakerWindowReset(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newBreaker(BreakerPolicy{
+		FailureRatio: 0.5,
+		MinRequests:  2,
+		Window:       time.Minute,
+		CoolDown:     time.Second,
+	}, func() time.Time { return now })
+
+	b.Record(false)
+	now = now.Add(time.Minute)
+	b.Record(true)
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := NewBreaker(BreakerPolicy{MinRequests: 1})
+
+	for range 10 {
+		assert.True(t, b.Allow())
+		b.Record(false)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+}
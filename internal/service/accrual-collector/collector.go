@@ -2,106 +2,279 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
-	"strconv"
-	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/fragpit/gophermart/internal/model"
-	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
-const (
-	clientTimeout           = 5 * time.Second
-	getOrdersURL            = "/api/orders/"
-	defaultRetryAfterPeriod = "60"
+var tracer = otel.Tracer(
+	"github.com/fragpit/gophermart/internal/service/accrual-collector",
 )
 
+const defaultRetryAfterPeriod = 60 * time.Second
+
+//go:generate mockgen -destination ./mocks/collector_repo.go . CollectorRepository
 type CollectorRepository interface {
 	SetAccrual(ctx context.Context, id int, sum model.Kopek) error
 	SetStatus(ctx context.Context, id int, status string) error
-	GetOrdersBatch(ctx context.Context, batchSize int) ([]model.Order, error)
+	SetRegistration(
+		ctx context.Context,
+		id int,
+		state model.RegistrationState,
+	) error
+	GetOrdersBatch(
+		ctx context.Context,
+		batchSize int,
+		baseDelay, maxDelay time.Duration,
+	) ([]model.Order, error)
+	ParkStaleOrders(
+		ctx context.Context,
+		maxAttempts int,
+		maxAge time.Duration,
+	) (int64, error)
+	RecordFailure(
+		ctx context.Context,
+		failure *model.AccrualFailure,
+		park bool,
+	) error
+	GetQueueDepth(ctx context.Context) (map[model.OrderStatus]int64, error)
+}
+
+// PollPolicy расписание опроса accrual по каждому заказу. После выборки
+// заказ не запрашивается ещё BaseDelay, каждая следующая задержка вдвое
+// длиннее (не более MaxDelay). Заказы, превысившие MaxAttempts неудачных
+// запросов или MaxAge, откладываются (parked) для ручного разбора.
+// Нулевые MaxAttempts/MaxAge отключают соответствующее ограничение.
+// Возврат в очередь сбрасывает счётчик попыток и задержку до BaseDelay.
+type PollPolicy struct {
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	MaxAttempts int
+	MaxAge      time.Duration
+}
+
+func DefaultPollPolicy() PollPolicy {
+	return PollPolicy{
+		BaseDelay:   1 * time.Second,
+		MaxDelay:    10 * time.Minute,
+		MaxAttempts: 100,
+		MaxAge:      7 * 24 * time.Hour,
+	}
 }
 
-type AccrualResponse struct {
-	Number  string      `json:"order"`
-	Status  string      `json:"status"`
-	Accrual model.Kopek `json:"accrual,omitempty"`
+type Option func(*Collector)
+
+func WithPollPolicy(p PollPolicy) Option {
+	return func(c *Collector) {
+		c.policy = p
+	}
+}
+
+// WithRateLimit начальный лимит запросов в accrual в минуту. 0 - лимит
+// неизвестен и будет получен из первого ответа 429.
+func WithRateLimit(perMinute int) Option {
+	return func(c *Collector) {
+		c.limiter.SetLimit(perMinute)
+	}
+}
+
+func WithBreakerPolicy(p BreakerPolicy) Option {
+	return func(c *Collector) {
+		c.breaker = NewBreaker(p)
+	}
 }
 
 type Collector struct {
 	PollInterval time.Duration
-	Client       *resty.Client
+	Provider     AccrualProvider
 
 	repo        CollectorRepository
+	policy      PollPolicy
+	breaker     *Breaker
+	limiter     *Limiter
+	leader      LeaderLock
+	isLeader    atomic.Bool
+	listener    OrdersListener
+	wake        chan struct{}
+	metrics     *collectorMetrics
 	nextAllowed atomic.Int64
+	startedAt   time.Time
+	lastCycle   atomic.Int64
+	leaderSince atomic.Int64
+	maxLag      time.Duration
 
 	WorkersNum int
 	BatchSize  int
 }
 
 func NewCollector(
-	accrualAddress string,
+	provider AccrualProvider,
 	interval time.Duration,
 
 	repo CollectorRepository,
+	opts ...Option,
 ) *Collector {
-	client := resty.New()
-
-	client.
-		SetTimeout(clientTimeout).
-		SetRetryCount(3).
-		SetRetryWaitTime(1 * time.Second).
-		SetRetryMaxWaitTime(5 * time.Second).
-		SetBaseURL(accrualAddress)
-
 	c := &Collector{
 		PollInterval: interval,
-		Client:       client,
+		Provider:     provider,
 		repo:         repo,
+		policy:       DefaultPollPolicy(),
+		breaker:      NewBreaker(DefaultBreakerPolicy()),
+		limiter:      NewLimiter(0),
+		wake:         make(chan struct{}, 1),
+		metrics:      newCollectorMetrics(),
+		maxLag:       3*interval + time.Minute,
 		BatchSize:    10,
 		WorkersNum:   3,
 	}
-	c.nextAllowed.Store(time.Now().UnixNano())
+	c.startedAt = time.Now()
+	c.nextAllowed.Store(c.startedAt.UnixNano())
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// разомкнутый автомат приостанавливает опрос так же, как 429
+	c.breaker.onOpen = func(d time.Duration) {
+		c.metrics.observePause(pauseReasonBreaker, time.Now())
+		c.setRetryAfter(d)
+	}
+	if g, ok := provider.(retryGate); ok {
+		g.SetRetryGate(func() bool {
+			return c.breaker.State() == BreakerClosed
+		})
+	}
 
 	return c
 }
 
+// BreakerState текущее состояние circuit breaker для health.
+func (c *Collector) BreakerState() BreakerState {
+	return c.breaker.State()
+}
+
+// call выполняет запрос к провайдеру с учётом лимитера и circuit breaker.
+// Ошибкой для автомата считаются только сбои, которые может исправить
+// повтор: ответ accrual, в том числе 429, говорит о его доступности.
+func (c *Collector) call(
+	ctx context.Context,
+	operation string,
+	fn func(ctx context.Context) (*AccrualResult, error),
+) (*AccrualResult, error) {
+	if err := c.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if !c.breaker.Allow() {
+		c.metrics.observeResult(operation, nil, ErrBreakerOpen)
+		return nil, ErrBreakerOpen
+	}
+
+	res, err := fn(ctx)
+	c.metrics.observeResult(operation, res, err)
+	if ctx.Err() == nil {
+		var ae *AccrualError
+		failed := err != nil && !(errors.As(err, &ae) && ae.Permanent)
+		c.breaker.Record(!failed)
+	}
+
+	return res, err
+}
+
 func (c *Collector) Run(ctx context.Context) error {
 	tick := time.NewTicker(c.PollInterval)
 	defer tick.Stop()
+	defer c.resign()
+
+	if c.listener != nil {
+		done := make(chan struct{})
+		defer func() { <-done }()
+		go func() {
+			defer close(done)
+			c.listenOrders(ctx)
+		}()
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case <-tick.C:
-			if ctx.Err() != nil {
-				continue
-			}
+			c.cycle(ctx)
+		case <-c.wake:
+			slog.Debug("collector woken up by new order notification")
+			c.cycle(ctx)
+		}
+	}
+}
 
-			if time.Now().UnixNano() < c.nextAllowed.Load() {
-				continue
-			}
+func (c *Collector) cycle(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
 
-			slog.Info("fetching accrual data")
-			if err := c.processOrders(ctx); err != nil {
-				return fmt.Errorf("collector error: %w", err)
-			}
-		}
+	if !c.leading(ctx) {
+		return
 	}
+
+	now := time.Now()
+	if now.UnixNano() < c.nextAllowed.Load() {
+		return
+	}
+	c.metrics.observeResume(now)
+
+	ctx, span := tracer.Start(ctx, "Collector.cycle")
+	defer span.End()
+
+	c.observeQueue(ctx)
+
+	slog.Info("fetching accrual data")
+	if err := c.processOrders(ctx); err != nil {
+		// ошибка цикла (например, недоступна БД) не должна
+		// останавливать сервис, пробуем на следующей итерации
+		slog.Error("collector cycle failed", slog.Any("error", err))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	c.lastCycle.Store(time.Now().UnixNano())
 }
 
 func (c *Collector) processOrders(ctx context.Context) error {
-	orders, err := c.repo.GetOrdersBatch(ctx, c.BatchSize)
+	parked, err := c.repo.ParkStaleOrders(
+		ctx,
+		c.policy.MaxAttempts,
+		c.policy.MaxAge,
+	)
+	if err != nil {
+		return err
+	}
+	if parked > 0 {
+		slog.Warn(
+			"orders parked for manual review",
+			slog.Int64("count", parked),
+		)
+	}
+
+	orders, err := c.repo.GetOrdersBatch(
+		ctx,
+		c.BatchSize,
+		c.policy.BaseDelay,
+		c.policy.MaxDelay,
+	)
 	if err != nil {
 		return err
 	}
 	slog.Debug("fetched orders", slog.Int("count", len(orders)))
+	c.metrics.batchSize.Observe(float64(len(orders)))
 	if len(orders) == 0 {
 		return nil
 	}
@@ -118,7 +291,7 @@ func (c *Collector) processOrders(ctx context.Context) error {
 			}
 
 			if err := c.handleOrder(ctx, &j); err != nil {
-				return err
+				c.recordFailure(ctx, &j, err)
 			}
 		}
 
@@ -161,82 +334,242 @@ func (c *Collector) handleOrder(ctx context.Context, order *model.Order) error {
 		return nil
 	}
 
+	ctx, span := tracer.Start(
+		ctx,
+		"Collector.handleOrder",
+		trace.WithAttributes(attribute.String("order.number", order.Number)),
+	)
+	defer span.End()
+
 	slog.Info("processing order", slog.String("number", order.Number))
 
-	var respBody AccrualResponse
-	resp, err := c.Client.R().
-		SetContext(ctx).
-		SetResult(&respBody).
-		Get(getOrdersURL + order.Number)
+	// заказы с чеком сразу регистрируем в accrual, остальные сначала
+	// проверяем: их мог зарегистрировать сам магазин.
+	if order.Registration == model.RegistrationPending && len(order.Goods) > 0 {
+		return c.registerOrder(ctx, order)
+	}
+
+	res, err := c.call(
+		ctx,
+		"check",
+		func(ctx context.Context) (*AccrualResult, error) {
+			return c.Provider.Check(ctx, order.Number)
+		},
+	)
+	if errors.Is(err, ErrBreakerOpen) {
+		slog.Debug(
+			"accrual circuit breaker is open, skipping order",
+			slog.String("number", order.Number),
+		)
+		return nil
+	}
 	if err != nil {
 		slog.Error("failed to request accrual", slog.Any("error", err))
-		return fmt.Errorf("failed to request accrual: %w", err)
+		return err
 	}
 
-	sc := resp.StatusCode()
-	if sc != http.StatusOK {
-		switch sc {
-		case http.StatusNoContent:
-			slog.Info(
-				"order is not registered in accrual",
-				slog.String("number", order.Number),
-			)
-			return nil
-		case http.StatusTooManyRequests:
-			periodRaw := strings.TrimSpace(resp.Header().Get("Retry-After"))
-			if periodRaw == "" {
-				periodRaw = defaultRetryAfterPeriod
-			}
-			period, err := strconv.Atoi(periodRaw)
-			if err != nil {
-				slog.Error(
-					"failed to get retry period from header, setting default",
-					slog.String("header_value", periodRaw),
-					slog.String("default_value", defaultRetryAfterPeriod),
-				)
-				period, _ = strconv.Atoi(defaultRetryAfterPeriod)
-			}
-
-			d := time.Duration(period) * time.Second
-			c.setRetryAfter(d)
-			slog.Info(
-				"too many requests to accrual, setting retry-after",
-				slog.Duration("period", d),
-			)
+	switch res.Status {
+	case ResultUnknown:
+		slog.Info(
+			"order is not registered in accrual",
+			slog.String("number", order.Number),
+		)
+		if order.Registration == model.RegistrationPending {
+			return c.registerOrder(ctx, order)
+		}
+		return nil
+	case ResultRateLimited:
+		c.handleRateLimited(res)
+		return nil
+	}
 
-			return nil
-		default:
-			slog.Error("failed to request accrual", slog.Int("http_code", sc))
-			return fmt.Errorf("failed to request accrual, http_code=%d", sc)
+	if order.Registration == model.RegistrationPending {
+		if err := c.repo.SetRegistration(
+			ctx,
+			order.ID,
+			model.RegistrationRegistered,
+		); err != nil {
+			slog.Error("failed to set order registration", slog.Any("error", err))
+			return fmt.Errorf("failed to set order registration: %w", err)
 		}
 	}
 
-	switch respBody.Status {
-	case model.StatusProcessed.String():
-		if err := c.repo.SetAccrual(ctx, order.ID, respBody.Accrual); err != nil {
+	switch res.Status {
+	case ResultProcessed:
+		if err := c.repo.SetAccrual(ctx, order.ID, res.Accrual); err != nil {
 			slog.Error("failed to set accrual", slog.Any("error", err))
 			return fmt.Errorf("failed to set accrual: %w", err)
 		}
-	case
-		model.StatusInvalid.String(),
-		model.StatusProcessing.String(),
-		"REGISTERED":
+	case ResultInvalid:
+		if err := c.repo.SetStatus(ctx, order.ID, model.StatusInvalid.String()); err != nil {
+			slog.Error(
+				"failed to set order status",
+				slog.Any("error", err),
+				slog.String("status", res.Status.String()),
+			)
+			return fmt.Errorf("failed to set order status: %w", err)
+		}
+	case ResultRegistered, ResultProcessing:
 		if err := c.repo.SetStatus(ctx, order.ID, model.StatusProcessing.String()); err != nil {
 			slog.Error(
 				"failed to set order status",
 				slog.Any("error", err),
-				slog.String("status", respBody.Status),
+				slog.String("status", res.Status.String()),
 			)
 			return fmt.Errorf("failed to set order status: %w", err)
 		}
 	default:
-		slog.Error("unknown order status", slog.String("status", respBody.Status))
-		return fmt.Errorf("unknown order status")
+		slog.Error("unknown order status", slog.String("status", res.Status.String()))
+		return &AccrualError{
+			Permanent: true,
+			Err:       fmt.Errorf("unexpected accrual result %s", res.Status),
+		}
 	}
 
 	return nil
 }
 
+func (c *Collector) registerOrder(
+	ctx context.Context,
+	order *model.Order,
+) error {
+	res, err := c.call(
+		ctx,
+		"register",
+		func(ctx context.Context) (*AccrualResult, error) {
+			return c.Provider.Register(ctx, order.Number, order.Goods)
+		},
+	)
+	if errors.Is(err, ErrBreakerOpen) {
+		slog.Debug(
+			"accrual circuit breaker is open, skipping order",
+			slog.String("number", order.Number),
+		)
+		return nil
+	}
+	if err != nil {
+		slog.Error("failed to register order in accrual", slog.Any("error", err))
+		return err
+	}
+
+	var (
+		state  model.RegistrationState
+		status model.OrderStatus
+	)
+	switch res.Status {
+	case ResultProcessing, ResultProcessed:
+		state, status = model.RegistrationRegistered, model.StatusProcessing
+	case ResultRegistered:
+		slog.Info(
+			"order is already registered in accrual",
+			slog.String("number", order.Number),
+		)
+		state, status = model.RegistrationRegistered, order.Status
+	case ResultInvalid:
+		state, status = model.RegistrationRejected, model.StatusInvalid
+	case ResultRateLimited:
+		c.handleRateLimited(res)
+		return nil
+	default:
+		slog.Error(
+			"unexpected accrual registration result",
+			slog.String("status", res.Status.String()),
+		)
+		return &AccrualError{
+			Err: fmt.Errorf(
+				"unexpected accrual registration result %s",
+				res.Status,
+			),
+		}
+	}
+
+	if err := c.repo.SetRegistration(ctx, order.ID, state); err != nil {
+		slog.Error("failed to set order registration", slog.Any("error", err))
+		return fmt.Errorf("failed to set order registration: %w", err)
+	}
+
+	if status != order.Status {
+		if err := c.repo.SetStatus(ctx, order.ID, status.String()); err != nil {
+			slog.Error("failed to set order status", slog.Any("error", err))
+			return fmt.Errorf("failed to set order status: %w", err)
+		}
+	}
+
+	slog.Info(
+		"order registered in accrual",
+		slog.String("number", order.Number),
+		slog.String("registration", state.String()),
+	)
+
+	return nil
+}
+
+// recordFailure сохраняет ошибку по заказу в dead-letter таблицу. Ошибка
+// одного заказа не прерывает обработку остальных.
+func (c *Collector) recordFailure(
+	ctx context.Context,
+	order *model.Order,
+	err error,
+) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	failure := &model.AccrualFailure{
+		OrderID: order.ID,
+		Error:   err.Error(),
+	}
+
+	var park bool
+	var ae *AccrualError
+	if errors.As(err, &ae) {
+		failure.HTTPStatus = ae.StatusCode
+		failure.Response = ae.Body
+		park = ae.Permanent
+	}
+
+	slog.Warn(
+		"failed to process order",
+		slog.String("number", order.Number),
+		slog.Int("attempts", order.Attempts),
+		slog.Bool("parked", park),
+		slog.Any("error", err),
+	)
+
+	if err := c.repo.RecordFailure(ctx, failure, park); err != nil {
+		slog.Error(
+			"failed to record accrual failure",
+			slog.String("number", order.Number),
+			slog.Any("error", err),
+		)
+	}
+}
+
+func (c *Collector) handleRateLimited(res *AccrualResult) {
+	if res.RateLimit > 0 {
+		if prev := c.limiter.Limit(); prev != res.RateLimit {
+			c.limiter.SetLimit(res.RateLimit)
+			slog.Info(
+				"learned accrual rate limit",
+				slog.Int("requests_per_minute", res.RateLimit),
+				slog.Int("previous", prev),
+			)
+		}
+	}
+
+	d := res.RetryAfter
+	if d <= 0 {
+		d = defaultRetryAfterPeriod
+	}
+
+	c.metrics.observePause(pauseReasonRateLimit, time.Now())
+	c.setRetryAfter(d)
+	slog.Info(
+		"too many requests to accrual, setting retry-after",
+		slog.Duration("period", d),
+	)
+}
+
 func (c *Collector) setRetryAfter(d time.Duration) {
 	c.nextAllowed.Store(time.Now().Add(d).UnixNano())
 }
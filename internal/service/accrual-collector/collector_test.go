@@ -0,0 +1,382 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/accrual-collector/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCollector_handleOrder(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	const orderNum = "79927398713"
+
+	tests := []struct {
+		name     string
+		order    model.Order
+		getCode  int
+		getBody  string
+		postCode int
+		prepare  func(*mocks.MockCollectorRepository)
+		wantPost bool
+		wantErr  bool
+	}{
+		{
+			name: "register order with goods",
+			order: model.Order{
+				ID:           1,
+				Number:       orderNum,
+				Status:       model.StatusNew,
+				Registration: model.RegistrationPending,
+				Goods:        []model.Good{{Description: "Bork", Price: 700000}},
+			},
+			postCode: http.StatusAccepted,
+			prepare: func(r *mocks.MockCollectorRepository) {
+				r.EXPECT().
+					SetRegistration(gomock.Any(), 1, model.RegistrationRegistered).
+					Return(nil)
+				r.EXPECT().
+					SetStatus(gomock.Any(), 1, model.StatusProcessing.String()).
+					Return(nil)
+			},
+			wantPost: true,
+		},
+		{
+			name: "order already registered by shop",
+			order: model.Order{
+				ID:           2,
+				Number:       orderNum,
+				Status:       model.StatusNew,
+				Registration: model.RegistrationPending,
+				Goods:        []model.Good{{Description: "Bork", Price: 700000}},
+			},
+			postCode: http.StatusConflict,
+			prepare: func(r *mocks.MockCollectorRepository) {
+				r.EXPECT().
+					SetRegistration(gomock.Any(), 2, model.RegistrationRegistered).
+					Return(nil)
+			},
+			wantPost: true,
+		},
+		{
+			name: "rejected registration invalidates order",
+			order: model.Order{
+				ID:           3,
+				Number:       orderNum,
+				Status:       model.StatusNew,
+				Registration: model.RegistrationPending,
+				Goods:        []model.Good{{Description: "Bork", Price: 700000}},
+			},
+			postCode: http.StatusBadRequest,
+			prepare: func(r *mocks.MockCollectorRepository) {
+				r.EXPECT().
+					SetRegistration(gomock.Any(), 3, model.RegistrationRejected).
+					Return(nil)
+				r.EXPECT().
+					SetStatus(gomock.Any(), 3, model.StatusInvalid.String()).
+					Return(nil)
+			},
+			wantPost: true,
+		},
+		{
+			name: "unknown order without goods is registered",
+			order: model.Order{
+				ID:           4,
+				Number:       orderNum,
+				Status:       model.StatusNew,
+				Registration: model.RegistrationPending,
+			},
+			getCode:  http.StatusNoContent,
+			postCode: http.StatusAccepted,
+			prepare: func(r *mocks.MockCollectorRepository) {
+				r.EXPECT().
+					SetRegistration(gomock.Any(), 4, model.RegistrationRegistered).
+					Return(nil)
+				r.EXPECT().
+					SetStatus(gomock.Any(), 4, model.StatusProcessing.String()).
+					Return(nil)
+			},
+			wantPost: true,
+		},
+		{
+			name: "processed order",
+			order: model.Order{
+				ID:           5,
+				Number:       orderNum,
+				Status:       model.StatusProcessing,
+				Registration: model.RegistrationRegistered,
+			},
+			getCode: http.StatusOK,
+			getBody: `{"order":"` + orderNum + `","status":"PROCESSED","accrual":500}`,
+			prepare: func(r *mocks.MockCollectorRepository) {
+				r.EXPECT().SetAccrual(gomock.Any(), 5, model.Kopek(50000)).Return(nil)
+			},
+		},
+		{
+			name: "invalid order is terminal",
+			order: model.Order{
+				ID:           7,
+				Number:       orderNum,
+				Status:       model.StatusProcessing,
+				Registration: model.RegistrationRegistered,
+			},
+			getCode: http.StatusOK,
+			getBody: `{"order":"` + orderNum + `","status":"INVALID"}`,
+			prepare: func(r *mocks.MockCollectorRepository) {
+				r.EXPECT().
+					SetStatus(gomock.Any(), 7, model.StatusInvalid.String()).
+					Return(nil)
+			},
+		},
+		{
+			name: "accrual internal error",
+			order: model.Order{
+				ID:           6,
+				Number:       orderNum,
+				Status:       model.StatusProcessing,
+				Registration: model.RegistrationRegistered,
+			},
+			getCode: http.StatusInternalServerError,
+			prepare: func(r *mocks.MockCollectorRepository) {},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var posted bool
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.Method == http.MethodPost {
+						posted = true
+						var req AccrualRegisterRequest
+						assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+						assert.Equal(t, tt.order.Number, req.Number)
+						w.WriteHeader(tt.postCode)
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(tt.getCode)
+					_, _ = w.Write([]byte(tt.getBody))
+				},
+			))
+			defer srv.Close()
+
+			repo := mocks.NewMockCollectorRepository(ctrl)
+			tt.prepare(repo)
+
+			c := NewCollector(newTestProvider(srv.URL), time.Second, repo)
+
+			err := c.handleOrder(context.Background(), &tt.order)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantPost, posted)
+		})
+	}
+}
+
+func TestCollector_processOrders(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	policy := PollPolicy{
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		MaxAttempts: 5,
+		MaxAge:      time.Hour,
+	}
+
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	gomock.InOrder(
+		repo.EXPECT().
+			ParkStaleOrders(gomock.Any(), 5, time.Hour).
+			Return(int64(2), nil),
+		repo.EXPECT().
+			GetOrdersBatch(gomock.Any(), 10, time.Second, time.Minute).
+			Return(nil, nil),
+	)
+
+	c := NewCollector(NewHTTPProvider("http://localhost"), time.Second, repo, WithPollPolicy(policy))
+
+	assert.NoError(t, c.processOrders(context.Background()))
+}
+
+func TestCollector_processOrdersRecordsFailures(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name       string
+		code       int
+		body       string
+		wantStatus int
+		wantPark   bool
+	}{
+		{
+			name:       "internal error is recorded",
+			code:       http.StatusInternalServerError,
+			body:       "boom",
+			wantStatus: http.StatusInternalServerError,
+			wantPark:   false,
+		},
+		{
+			name:       "unknown status parks order",
+			code:       http.StatusOK,
+			body:       `{"order":"79927398713","status":"WHATEVER"}`,
+			wantStatus: http.StatusOK,
+			wantPark:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(tt.code)
+					_, _ = w.Write([]byte(tt.body))
+				},
+			))
+			defer srv.Close()
+
+			orders := []model.Order{
+				{
+					ID:           1,
+					Number:       "79927398713",
+					Status:       model.StatusProcessing,
+					Registration: model.RegistrationRegistered,
+				},
+			}
+
+			repo := mocks.NewMockCollectorRepository(ctrl)
+			repo.EXPECT().
+				ParkStaleOrders(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(int64(0), nil)
+			repo.EXPECT().
+				GetOrdersBatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(orders, nil)
+			repo.EXPECT().
+				RecordFailure(gomock.Any(), gomock.Any(), tt.wantPark).
+				DoAndReturn(func(
+					_ context.Context,
+					f *model.AccrualFailure,
+					_ bool,
+				) error {
+					assert.Equal(t, 1, f.OrderID)
+					assert.Equal(t, tt.wantStatus, f.HTTPStatus)
+					assert.Equal(t, tt.body, f.Response)
+					return nil
+				})
+
+			c := NewCollector(newTestProvider(srv.URL), time.Second, repo)
+
+			assert.NoError(t, c.processOrders(context.Background()))
+		})
+	}
+}
+
+func TestCollector_breakerPausesPolling(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	))
+	defer srv.Close()
+
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	c := NewCollector(newTestProvider(srv.URL), time.Second, repo, WithBreakerPolicy(
+		BreakerPolicy{
+			FailureRatio: 0.5,
+			MinRequests:  2,
+			Window:       time.Minute,
+			CoolDown:     time.Minute,
+		},
+	))
+
+	order := model.Order{
+		ID:           1,
+		Number:       "79927398713",
+		Status:       model.StatusProcessing,
+		Registration: model.RegistrationRegistered,
+	}
+
+	for range 2 {
+		assert.Error(t, c.handleOrder(context.Background(), &order))
+	}
+	assert.Equal(t, BreakerOpen, c.BreakerState())
+	assert.Greater(t, c.nextAllowed.Load(), time.Now().UnixNano())
+
+	// пауза снята, но автомат ещё разомкнут: запрос не уходит в accrual
+	c.nextAllowed.Store(time.Now().UnixNano())
+	assert.NoError(t, c.handleOrder(context.Background(), &order))
+	assert.Equal(t, 2, requests)
+}
+
+func TestCollector_handleTooManyRequestsLearnsLimit(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	retryAt := time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Retry-After", retryAt)
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write(
+				[]byte("No more than 30 requests per minute allowed"),
+			)
+		},
+	))
+	defer srv.Close()
+
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	c := NewCollector(newTestProvider(srv.URL), time.Second, repo)
+
+	order := model.Order{
+		ID:           1,
+		Number:       "79927398713",
+		Status:       model.StatusProcessing,
+		Registration: model.RegistrationRegistered,
+	}
+
+	assert.NoError(t, c.handleOrder(context.Background(), &order))
+	assert.Equal(t, 30, c.limiter.Limit())
+
+	paused := time.Until(time.Unix(0, c.nextAllowed.Load()))
+	assert.Greater(t, paused, time.Minute)
+	assert.LessOrEqual(t, paused, 2*time.Minute)
+}
+
+func newTestProvider(addr string) *HTTPProvider {
+	p := NewHTTPProvider(addr)
+	p.Client.SetRetryCount(0)
+	return p
+}
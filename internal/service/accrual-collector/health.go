@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// accrualPinger реализуют провайдеры с сетевым источником данных.
+type accrualPinger interface {
+	Ping(ctx context.Context) error
+}
+
+// WithMaxCycleLag допустимое время с последнего успешного цикла опроса,
+// после которого ведущая реплика считается отставшей.
+func WithMaxCycleLag(d time.Duration) Option {
+	return func(c *Collector) {
+		c.maxLag = d
+	}
+}
+
+// LastCycle время последнего успешного цикла опроса, нулевое - циклов
+// ещё не было.
+func (c *Collector) LastCycle() time.Time {
+	ns := c.lastCycle.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// PausedUntil окончание паузы опроса после 429 или размыкания circuit
+// breaker, нулевое - опрос не приостановлен.
+func (c *Collector) PausedUntil() time.Time {
+	until := time.Unix(0, c.nextAllowed.Load())
+	if !until.After(time.Now()) {
+		return time.Time{}
+	}
+	return until
+}
+
+// CheckCycle проверка готовности: ведущая реплика успешно завершала цикл
+// не позже maxLag назад. Резервная реплика и пауза опроса отставанием не
+// считаются, пауза отдаётся отдельно в CheckPause.
+func (c *Collector) CheckCycle(_ context.Context) (map[string]any, error) {
+	details := map[string]any{"role": c.Role()}
+
+	// отставание считается с запуска или захвата лидерства
+	since := c.startedAt
+	if ns := c.leaderSince.Load(); ns > since.UnixNano() {
+		since = time.Unix(0, ns)
+	}
+	if last := c.LastCycle(); !last.IsZero() {
+		details["last_cycle"] = last.UTC().Format(time.RFC3339)
+		if last.After(since) {
+			since = last
+		}
+	}
+	lag := time.Since(since)
+	details["lag"] = lag.Round(time.Second).String()
+
+	if c.isLeader.Load() && c.PausedUntil().IsZero() && lag > c.maxLag {
+		return details, fmt.Errorf(
+			"no successful collector cycle for %s",
+			lag.Round(time.Second),
+		)
+	}
+	return details, nil
+}
+
+// CheckPause отдаёт состояние паузы опроса и circuit breaker. Пауза -
+// штатная реакция на 429 и сбои accrual, поэтому проверка не падает.
+func (c *Collector) CheckPause(_ context.Context) (map[string]any, error) {
+	details := map[string]any{
+		"paused":     false,
+		"breaker":    c.BreakerState().String(),
+		"rate_limit": c.limiter.Limit(),
+	}
+	if until := c.PausedUntil(); !until.IsZero() {
+		details["paused"] = true
+		details["paused_until"] = until.UTC().Format(time.RFC3339)
+	}
+	return details, nil
+}
+
+// CheckAccrual проверяет доступность accrual, если провайдер сетевой.
+func (c *Collector) CheckAccrual(ctx context.Context) (map[string]any, error) {
+	p, ok := c.Provider.(accrualPinger)
+	if !ok {
+		return map[string]any{"provider": "local"}, nil
+	}
+	return nil, p.Ping(ctx)
+}
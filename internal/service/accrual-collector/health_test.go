@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector_CheckCycle(t *testing.T) {
+	tests := []struct {
+		name      string
+		leader    bool
+		startedAt time.Duration
+		lastCycle time.Duration
+		paused    bool
+		wantErr   bool
+	}{
+		{
+			name:      "recent cycle",
+			leader:    true,
+			startedAt: -time.Hour,
+			lastCycle: -10 * time.Second,
+		},
+		{
+			name:      "just started",
+			leader:    true,
+			startedAt: -10 * time.Second,
+		},
+		{
+			name:      "stale leader",
+			leader:    true,
+			startedAt: -time.Hour,
+			lastCycle: -10 * time.Minute,
+			wantErr:   true,
+		},
+		{
+			name:      "stale standby",
+			startedAt: -time.Hour,
+		},
+		{
+			name:      "stale but paused",
+			leader:    true,
+			startedAt: -time.Hour,
+			lastCycle: -10 * time.Minute,
+			paused:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollector(
+				NewMemoryProvider(),
+				time.Second,
+				nil,
+				WithMaxCycleLag(time.Minute),
+			)
+			c.isLeader.Store(tt.leader)
+			c.startedAt = time.Now().Add(tt.startedAt)
+			if tt.lastCycle != 0 {
+				c.lastCycle.Store(time.Now().Add(tt.lastCycle).UnixNano())
+			}
+			if tt.paused {
+				c.setRetryAfter(time.Minute)
+			}
+
+			details, err := c.CheckCycle(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Contains(t, details, "lag")
+		})
+	}
+}
+
+func TestCollector_CheckPause(t *testing.T) {
+	c := NewCollector(NewMemoryProvider(), time.Second, nil, WithRateLimit(5))
+
+	details, err := c.CheckPause(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, false, details["paused"])
+	assert.Equal(t, "closed", details["breaker"])
+	assert.Equal(t, 5, details["rate_limit"])
+
+	c.setRetryAfter(time.Minute)
+
+	details, err = c.CheckPause(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, true, details["paused"])
+	assert.Contains(t, details, "paused_until")
+}
+
+func TestCollector_CheckAccrual(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.WriteHeader(http.StatusNotFound)
+		},
+	))
+
+	c := NewCollector(NewHTTPProvider(srv.URL), time.Second, nil)
+	_, err := c.CheckAccrual(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, hits)
+
+	srv.Close()
+	_, err = c.CheckAccrual(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 1, hits)
+
+	local := NewCollector(NewMemoryProvider(), time.Second, nil)
+	_, err = local.CheckAccrual(context.Background())
+	assert.NoError(t, err)
+}
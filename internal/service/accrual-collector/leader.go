@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const leaderReleaseTimeout = 5 * time.Second
+
+// LeaderLock межпроцессная блокировка для выбора одной активной реплики
+// коллектора. TryAcquire не блокируется и для текущего лидера проверяет, что
+// блокировка всё ещё удерживается.
+//
+//go:generate mockgen -destination ./mocks/leader_lock.go . LeaderLock
+type LeaderLock interface {
+	TryAcquire(ctx context.Context) (bool, error)
+	Release(ctx context.Context) error
+}
+
+// WithLeaderLock включает выбор лидера: опрашивает accrual только реплика,
+// удерживающая блокировку, поэтому пауза после 429 действует на всех.
+func WithLeaderLock(l LeaderLock) Option {
+	return func(c *Collector) {
+		c.leader = l
+	}
+}
+
+// Role роль реплики для health: leader или standby.
+func (c *Collector) Role() string {
+	if c.isLeader.Load() {
+		return "leader"
+	}
+	return "standby"
+}
+
+// leading пытается захватить или подтвердить лидерство.
+func (c *Collector) leading(ctx context.Context) bool {
+	if c.leader == nil {
+		c.isLeader.Store(true)
+		return true
+	}
+
+	ok, err := c.leader.TryAcquire(ctx)
+	if err != nil {
+		slog.Error("failed to acquire collector leadership", slog.Any("error", err))
+		ok = false
+	}
+
+	if was := c.isLeader.Swap(ok); was != ok {
+		if ok {
+			c.leaderSince.Store(time.Now().UnixNano())
+			slog.Info("collector leadership acquired")
+		} else {
+			c.metrics.observeStandby(time.Now())
+			slog.Warn("collector leadership lost, switching to standby")
+		}
+	}
+
+	return ok
+}
+
+func (c *Collector) resign() {
+	if c.leader == nil || !c.isLeader.Swap(false) {
+		return
+	}
+	c.metrics.observeStandby(time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaderReleaseTimeout)
+	defer cancel()
+
+	if err := c.leader.Release(ctx); err != nil {
+		slog.Error("failed to release collector leadership", slog.Any("error", err))
+		return
+	}
+	slog.Info("collector leadership released")
+}
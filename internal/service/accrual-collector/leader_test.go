@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/accrual-collector/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCollector_leading(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lock := mocks.NewMockLeaderLock(ctrl)
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	c := NewCollector(NewHTTPProvider("http://localhost"), time.Second, repo, WithLeaderLock(lock))
+
+	gomock.InOrder(
+		lock.EXPECT().TryAcquire(gomock.Any()).Return(false, nil),
+		lock.EXPECT().TryAcquire(gomock.Any()).Return(true, nil),
+		lock.EXPECT().TryAcquire(gomock.Any()).Return(false, errors.New("conn lost")),
+		lock.EXPECT().TryAcquire(gomock.Any()).Return(true, nil),
+		lock.EXPECT().Release(gomock.Any()).Return(nil),
+	)
+
+	ctx := context.Background()
+
+	assert.False(t, c.leading(ctx))
+	assert.Equal(t, "standby", c.Role())
+
+	assert.True(t, c.leading(ctx))
+	assert.Equal(t, "leader", c.Role())
+
+	assert.False(t, c.leading(ctx))
+	assert.Equal(t, "standby", c.Role())
+
+	assert.True(t, c.leading(ctx))
+	c.resign()
+	assert.Equal(t, "standby", c.Role())
+
+	// повторный resign без лидерства не освобождает блокировку
+	c.resign()
+}
+
+func TestCollector_leadershipLostResetsMetrics(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lock := mocks.NewMockLeaderLock(ctrl)
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	reg := prometheus.NewRegistry()
+	c := NewCollector(
+		NewMemoryProvider(),
+		time.Second,
+		repo,
+		WithLeaderLock(lock),
+		WithMetrics(reg),
+	)
+
+	gomock.InOrder(
+		lock.EXPECT().TryAcquire(gomock.Any()).Return(true, nil),
+		lock.EXPECT().TryAcquire(gomock.Any()).Return(false, nil),
+	)
+	repo.EXPECT().
+		GetQueueDepth(gomock.Any()).
+		Return(map[model.OrderStatus]int64{model.StatusNew: 5}, nil)
+
+	ctx := context.Background()
+
+	assert.True(t, c.leading(ctx))
+	c.observeQueue(ctx)
+	c.metrics.observePause(pauseReasonRateLimit, time.Now().Add(-time.Minute))
+	assert.Equal(t, 2, testutil.CollectAndCount(reg, "gophermart_collector_queue_depth"))
+
+	// резервная реплика не отдаёт устаревшую глубину очереди, пауза
+	// закрыта в момент потери лидерства
+	assert.False(t, c.leading(ctx))
+	assert.Equal(t, 0, testutil.CollectAndCount(reg, "gophermart_collector_queue_depth"))
+	assert.InDelta(t, 60, testutil.ToFloat64(
+		c.metrics.pausedTime.WithLabelValues(pauseReasonRateLimit),
+	), 5)
+}
+
+func TestCollector_leadingWithoutLock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	c := NewCollector(
+		NewHTTPProvider("http://localhost"),
+		time.Second,
+		mocks.NewMockCollectorRepository(ctrl),
+	)
+
+	assert.True(t, c.leading(context.Background()))
+	assert.Equal(t, "leader", c.Role())
+}
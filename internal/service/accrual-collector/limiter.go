@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var rateLimitBodyRe = regexp.MustCompile(
+	`(?i)no more than (\d+) requests per minute`,
+)
+
+// Limiter token bucket для запросов в accrual, общий для всех воркеров.
+// Ёмкость корзины 1: запросы идут не чаще одного в 1m/N, поэтому в любую
+// минуту попадает не больше N запросов. Нулевой лимит - без ограничений,
+// пока лимит не станет известен из ответа 429.
+type Limiter struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	perMin   int
+	interval time.Duration
+	next     time.Time
+}
+
+func NewLimiter(perMinute int) *Limiter {
+	return newLimiter(perMinute, time.Now)
+}
+
+func newLimiter(perMinute int, now func() time.Time) *Limiter {
+	l := &Limiter{now: now}
+	l.SetLimit(perMinute)
+	return l
+}
+
+// Limit текущий лимит запросов в минуту, 0 - без ограничений.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.perMin
+}
+
+func (l *Limiter) SetLimit(perMinute int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if perMinute < 0 {
+		perMinute = 0
+	}
+	l.perMin = perMinute
+	l.interval = 0
+	if perMinute > 0 {
+		l.interval = time.Minute / time.Duration(perMinute)
+	}
+}
+
+// reserve занимает ближайший свободный слот и возвращает время ожидания
+// до него.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.interval == 0 {
+		return 0
+	}
+
+	now := l.now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+
+	return wait
+}
+
+// Wait блокирует до появления токена или отмены контекста.
+func (l *Limiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// parseRateLimit достаёт N из тела 429 "No more than N requests per minute
+// allowed".
+func parseRateLimit(body string) (int, bool) {
+	m := rateLimitBodyRe.FindStringSubmatch(body)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в формате HTTP-date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+
+	if sec, err := strconv.Atoi(v); err == nil {
+		if sec < 0 {
+			return 0, false
+		}
+		return time.Duration(sec) * time.Second, true
+	}
+
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	d := t.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
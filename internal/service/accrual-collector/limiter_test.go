@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_reserve(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newLimiter(0, func() time.Time { return now })
+
+	// без лимита запросы не ждут
+	for range 5 {
+		assert.Zero(t, l.reserve())
+	}
+
+	l.SetLimit(60)
+	assert.Equal(t, 60, l.Limit())
+	assert.Zero(t, l.reserve())
+	assert.Equal(t, time.Second, l.reserve())
+	assert.Equal(t, 2*time.Second, l.reserve())
+
+	// за время простоя токены не копятся сверх одного
+	now = now.Add(time.Minute)
+	assert.Zero(t, l.reserve())
+	assert.Equal(t, time.Second, l.reserve())
+}
+
+func TestLimiter_WaitCanceled(t *testing.T) {
+	l := NewLimiter(1)
+	assert.NoError(t, l.Wait(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+}
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		body   string
+		want   int
+		wantOK bool
+	}{
+		{body: "No more than 10 requests per minute allowed", want: 10, wantOK: true},
+		{body: "no more than 3 requests per minute allowed\n", want: 3, wantOK: true},
+		{body: "No more than 0 requests per minute allowed", wantOK: false},
+		{body: "Too Many Requests", wantOK: false},
+		{body: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			got, ok := parseRateLimit(tt.body)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "seconds", value: "60", want: time.Minute, wantOK: true},
+		{name: "seconds with spaces", value: " 5 ", want: 5 * time.Second, wantOK: true},
+		{
+			name:   "http date",
+			value:  "Wed, 01 Jan 2025 12:00:30 GMT",
+			want:   30 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "http date in the past",
+			value:  "Wed, 01 Jan 2025 11:00:00 GMT",
+			want:   0,
+			wantOK: true,
+		},
+		{name: "negative", value: "-1", wantOK: false},
+		{name: "garbage", value: "soon", wantOK: false},
+		{name: "empty", value: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	pauseReasonRateLimit = "rate_limit"
+	pauseReasonBreaker   = "breaker"
+)
+
+type collectorMetrics struct {
+	batchSize  prometheus.Histogram
+	responses  *prometheus.CounterVec
+	pauses     *prometheus.CounterVec
+	pausedTime *prometheus.CounterVec
+	queueDepth *prometheus.GaugeVec
+
+	// текущая пауза: время учитывается, когда опрос возобновился
+	pauseMu     sync.Mutex
+	pauseReason string
+	pausedAt    time.Time
+}
+
+func newCollectorMetrics() *collectorMetrics {
+	return &collectorMetrics{
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "gophermart",
+			Subsystem: "collector",
+			Name:      "batch_orders",
+			Help:      "Number of orders fetched per collector batch.",
+			Buckets:   []float64{0, 1, 2, 5, 10, 20, 50, 100},
+		}),
+		responses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "gophermart",
+				Subsystem: "collector",
+				Name:      "accrual_responses_total",
+				Help:      "Accrual responses by operation and result.",
+			},
+			[]string{"operation", "result"},
+		),
+		pauses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "gophermart",
+				Subsystem: "collector",
+				Name:      "pauses_total",
+				Help:      "Number of accrual polling pauses by reason.",
+			},
+			[]string{"reason"},
+		),
+		pausedTime: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: "gophermart",
+				Subsystem: "collector",
+				Name:      "paused_seconds_total",
+				Help:      "Time accrual polling was paused by reason.",
+			},
+			[]string{"reason"},
+		),
+		queueDepth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: "gophermart",
+				Subsystem: "collector",
+				Name:      "queue_depth",
+				Help:      "Number of orders waiting for accrual by status.",
+			},
+			[]string{"status"},
+		),
+	}
+}
+
+func (m *collectorMetrics) register(reg prometheus.Registerer) {
+	reg.MustRegister(
+		m.batchSize,
+		m.responses,
+		m.pauses,
+		m.pausedTime,
+		m.queueDepth,
+	)
+}
+
+// WithMetrics регистрирует метрики коллектора.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(c *Collector) {
+		c.metrics.register(reg)
+	}
+}
+
+func (m *collectorMetrics) observeResult(
+	operation string,
+	res *AccrualResult,
+	err error,
+) {
+	result := "ERROR"
+	switch {
+	case errors.Is(err, ErrBreakerOpen):
+		result = "BREAKER_OPEN"
+	case err == nil && res != nil:
+		result = res.Status.String()
+	}
+	m.responses.WithLabelValues(operation, result).Inc()
+}
+
+// observePause отмечает начало паузы. Новая пауза во время текущей
+// продлевает её; при другой причине прошедшее время записывается на
+// прежнюю.
+func (m *collectorMetrics) observePause(reason string, now time.Time) {
+	m.pauses.WithLabelValues(reason).Inc()
+
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+
+	if m.pauseReason == reason {
+		return
+	}
+	m.endPauseLocked(now)
+	m.pauseReason = reason
+	m.pausedAt = now
+}
+
+// observeResume записывает фактическую длительность паузы: опрос
+// возобновился или реплика перестала быть лидером.
+func (m *collectorMetrics) observeResume(now time.Time) {
+	m.pauseMu.Lock()
+	defer m.pauseMu.Unlock()
+
+	m.endPauseLocked(now)
+}
+
+func (m *collectorMetrics) endPauseLocked(now time.Time) {
+	if m.pauseReason == "" {
+		return
+	}
+	if d := now.Sub(m.pausedAt); d > 0 {
+		m.pausedTime.WithLabelValues(m.pauseReason).Add(d.Seconds())
+	}
+	m.pauseReason = ""
+}
+
+// observeStandby сбрасывает метрики лидера: глубину очереди реплика в
+// резерве не обновляет, и старые значения суммировались бы с лидерскими.
+func (m *collectorMetrics) observeStandby(now time.Time) {
+	m.observeResume(now)
+	m.queueDepth.Reset()
+}
+
+// observeQueue обновляет глубину очереди. Ошибка не прерывает цикл.
+func (c *Collector) observeQueue(ctx context.Context) {
+	depth, err := c.repo.GetQueueDepth(ctx)
+	if err != nil {
+		slog.Warn("failed to get accrual queue depth", slog.Any("error", err))
+		return
+	}
+
+	for _, status := range []model.OrderStatus{
+		model.StatusNew,
+		model.StatusProcessing,
+	} {
+		c.metrics.queueDepth.
+			WithLabelValues(status.String()).
+			Set(float64(depth[status]))
+	}
+}
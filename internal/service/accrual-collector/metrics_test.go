@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/accrual-collector/mocks"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCollector_metrics(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p := NewMemoryProvider()
+	p.Script("1", AccrualResult{Status: ResultProcessing})
+	p.Script(
+		"2",
+		AccrualResult{Status: ResultRateLimited, RetryAfter: 30 * time.Second},
+	)
+
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	repo.EXPECT().
+		GetQueueDepth(gomock.Any()).
+		Return(map[model.OrderStatus]int64{model.StatusProcessing: 3}, nil)
+	repo.EXPECT().
+		SetStatus(gomock.Any(), 1, model.StatusProcessing.String()).
+		Return(nil)
+
+	reg := prometheus.NewRegistry()
+	c := NewCollector(p, time.Second, repo, WithMetrics(reg))
+
+	ctx := context.Background()
+	c.observeQueue(ctx)
+	for i, number := range []string{"1", "2"} {
+		order := model.Order{
+			ID:           i + 1,
+			Number:       number,
+			Status:       model.StatusProcessing,
+			Registration: model.RegistrationRegistered,
+		}
+		assert.NoError(t, c.handleOrder(ctx, &order))
+	}
+
+	expected := `
+# HELP gophermart_collector_accrual_responses_total Accrual responses by operation and result.
+# TYPE gophermart_collector_accrual_responses_total counter
+gophermart_collector_accrual_responses_total{operation="check",result="PROCESSING"} 1
+gophermart_collector_accrual_responses_total{operation="check",result="RATE_LIMITED"} 1
+# HELP gophermart_collector_pauses_total Number of accrual polling pauses by reason.
+# TYPE gophermart_collector_pauses_total counter
+gophermart_collector_pauses_total{reason="rate_limit"} 1
+# HELP gophermart_collector_queue_depth Number of orders waiting for accrual by status.
+# TYPE gophermart_collector_queue_depth gauge
+gophermart_collector_queue_depth{status="NEW"} 0
+gophermart_collector_queue_depth{status="PROCESSING"} 3
+`
+	assert.NoError(t, testutil.GatherAndCompare(
+		reg,
+		strings.NewReader(expected),
+		"gophermart_collector_accrual_responses_total",
+		"gophermart_collector_pauses_total",
+		"gophermart_collector_queue_depth",
+	))
+
+	// длительность паузы ещё неизвестна: опрос не возобновился
+	assert.Equal(t, 0, testutil.CollectAndCount(
+		reg,
+		"gophermart_collector_paused_seconds_total",
+	))
+}
+
+func TestCollectorMetrics_pausedTime(t *testing.T) {
+	m := newCollectorMetrics()
+	start := time.Now()
+
+	// повторный 429 во время паузы не начинает новую, автомат меняет
+	// причину, возобновление закрывает паузу
+	m.observePause(pauseReasonRateLimit, start)
+	m.observePause(pauseReasonRateLimit, start.Add(10*time.Second))
+	m.observePause(pauseReasonBreaker, start.Add(20*time.Second))
+	m.observeResume(start.Add(45 * time.Second))
+	m.observeResume(start.Add(time.Hour))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(
+		m.pauses.WithLabelValues(pauseReasonRateLimit),
+	))
+	assert.Equal(t, 20.0, testutil.ToFloat64(
+		m.pausedTime.WithLabelValues(pauseReasonRateLimit),
+	))
+	assert.Equal(t, 25.0, testutil.ToFloat64(
+		m.pausedTime.WithLabelValues(pauseReasonBreaker),
+	))
+}
+
+func TestCollector_observeQueueError(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	repo.EXPECT().
+		GetQueueDepth(gomock.Any()).
+		Return(nil, errors.New("db down"))
+
+	reg := prometheus.NewRegistry()
+	c := NewCollector(NewMemoryProvider(), time.Second, repo, WithMetrics(reg))
+	c.observeQueue(context.Background())
+
+	assert.Equal(t, 0, testutil.CollectAndCount(reg, "gophermart_collector_queue_depth"))
+}
@@ -0,0 +1,144 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/service/accrual-collector (interfaces: CollectorRepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/collector_repo.go . CollectorRepository
+//
+
+// Package mock_collector is a generated GoMock package.
+package mock_collector
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCollectorRepository is a mock of CollectorRepository interface.
+type MockCollectorRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCollectorRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCollectorRepositoryMockRecorder is the mock recorder for MockCollectorRepository.
+type MockCollectorRepositoryMockRecorder struct {
+	mock *MockCollectorRepository
+}
+
+// NewMockCollectorRepository creates a new mock instance.
+func NewMockCollectorRepository(ctrl *gomock.Controller) *MockCollectorRepository {
+	mock := &MockCollectorRepository{ctrl: ctrl}
+	mock.recorder = &MockCollectorRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCollectorRepository) EXPECT() *MockCollectorRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetOrdersBatch mocks base method.
+func (m *MockCollectorRepository) GetOrdersBatch(ctx context.Context, batchSize int, baseDelay, maxDelay time.Duration) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrdersBatch", ctx, batchSize, baseDelay, maxDelay)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrdersBatch indicates an expected call of GetOrdersBatch.
+func (mr *MockCollectorRepositoryMockRecorder) GetOrdersBatch(ctx, batchSize, baseDelay, maxDelay any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrdersBatch", reflect.TypeOf((*MockCollectorRepository)(nil).GetOrdersBatch), ctx, batchSize, baseDelay, maxDelay)
+}
+
+// GetQueueDepth mocks base method.
+func (m *MockCollectorRepository) GetQueueDepth(ctx context.Context) (map[model.OrderStatus]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQueueDepth", ctx)
+	ret0, _ := ret[0].(map[model.OrderStatus]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQueueDepth indicates an expected call of GetQueueDepth.
+func (mr *MockCollectorRepositoryMockRecorder) GetQueueDepth(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQueueDepth", reflect.TypeOf((*MockCollectorRepository)(nil).GetQueueDepth), ctx)
+}
+
+// ParkStaleOrders mocks base method.
+func (m *MockCollectorRepository) ParkStaleOrders(ctx context.Context, maxAttempts int, maxAge time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParkStaleOrders", ctx, maxAttempts, maxAge)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParkStaleOrders indicates an expected call of ParkStaleOrders.
+func (mr *MockCollectorRepositoryMockRecorder) ParkStaleOrders(ctx, maxAttempts, maxAge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParkStaleOrders", reflect.TypeOf((*MockCollectorRepository)(nil).ParkStaleOrders), ctx, maxAttempts, maxAge)
+}
+
+// RecordFailure mocks base method.
+func (m *MockCollectorRepository) RecordFailure(ctx context.Context, failure *model.AccrualFailure, park bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFailure", ctx, failure, park)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockCollectorRepositoryMockRecorder) RecordFailure(ctx, failure, park any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockCollectorRepository)(nil).RecordFailure), ctx, failure, park)
+}
+
+// SetAccrual mocks base method.
+func (m *MockCollectorRepository) SetAccrual(ctx context.Context, id int, sum model.Kopek) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAccrual", ctx, id, sum)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAccrual indicates an expected call of SetAccrual.
+func (mr *MockCollectorRepositoryMockRecorder) SetAccrual(ctx, id, sum any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccrual", reflect.TypeOf((*MockCollectorRepository)(nil).SetAccrual), ctx, id, sum)
+}
+
+// SetRegistration mocks base method.
+func (m *MockCollectorRepository) SetRegistration(ctx context.Context, id int, state model.RegistrationState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRegistration", ctx, id, state)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRegistration indicates an expected call of SetRegistration.
+func (mr *MockCollectorRepositoryMockRecorder) SetRegistration(ctx, id, state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRegistration", reflect.TypeOf((*MockCollectorRepository)(nil).SetRegistration), ctx, id, state)
+}
+
+// SetStatus mocks base method.
+func (m *MockCollectorRepository) SetStatus(ctx context.Context, id int, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatus indicates an expected call of SetStatus.
+func (mr *MockCollectorRepositoryMockRecorder) SetStatus(ctx, id, status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatus", reflect.TypeOf((*MockCollectorRepository)(nil).SetStatus), ctx, id, status)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/service/accrual-collector (interfaces: LeaderLock)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/leader_lock.go . LeaderLock
+//
+
+// Package mock_collector is a generated GoMock package.
+package mock_collector
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLeaderLock is a mock of LeaderLock interface.
+type MockLeaderLock struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeaderLockMockRecorder
+	isgomock struct{}
+}
+
+// MockLeaderLockMockRecorder is the mock recorder for MockLeaderLock.
+type MockLeaderLockMockRecorder struct {
+	mock *MockLeaderLock
+}
+
+// NewMockLeaderLock creates a new mock instance.
+func NewMockLeaderLock(ctrl *gomock.Controller) *MockLeaderLock {
+	mock := &MockLeaderLock{ctrl: ctrl}
+	mock.recorder = &MockLeaderLockMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLeaderLock) EXPECT() *MockLeaderLockMockRecorder {
+	return m.recorder
+}
+
+// Release mocks base method.
+func (m *MockLeaderLock) Release(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockLeaderLockMockRecorder) Release(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockLeaderLock)(nil).Release), ctx)
+}
+
+// TryAcquire mocks base method.
+func (m *MockLeaderLock) TryAcquire(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryAcquire", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TryAcquire indicates an expected call of TryAcquire.
+func (mr *MockLeaderLockMockRecorder) TryAcquire(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryAcquire", reflect.TypeOf((*MockLeaderLock)(nil).TryAcquire), ctx)
+}
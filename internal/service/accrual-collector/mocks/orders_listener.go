@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/service/accrual-collector (interfaces: OrdersListener)
+//
+// Generated by this command:
+//
+//	mockgen -destination ./mocks/orders_listener.go . OrdersListener
+//
+
+// Package mock_collector is a generated GoMock package.
+package mock_collector
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOrdersListener is a mock of OrdersListener interface.
+type MockOrdersListener struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrdersListenerMockRecorder
+	isgomock struct{}
+}
+
+// MockOrdersListenerMockRecorder is the mock recorder for MockOrdersListener.
+type MockOrdersListenerMockRecorder struct {
+	mock *MockOrdersListener
+}
+
+// NewMockOrdersListener creates a new mock instance.
+func NewMockOrdersListener(ctrl *gomock.Controller) *MockOrdersListener {
+	mock := &MockOrdersListener{ctrl: ctrl}
+	mock.recorder = &MockOrdersListenerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrdersListener) EXPECT() *MockOrdersListenerMockRecorder {
+	return m.recorder
+}
+
+// Listen mocks base method.
+func (m *MockOrdersListener) Listen(ctx context.Context, notify func()) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Listen", ctx, notify)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Listen indicates an expected call of Listen.
+func (mr *MockOrdersListenerMockRecorder) Listen(ctx, notify any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Listen", reflect.TypeOf((*MockOrdersListener)(nil).Listen), ctx, notify)
+}
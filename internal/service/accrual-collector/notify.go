@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	listenRetryMin = 1 * time.Second
+	listenRetryMax = 1 * time.Minute
+	// listenStableAfter подписка, продержавшаяся столько, считается
+	// восстановленной: задержка переподключения сбрасывается.
+	listenStableAfter = 30 * time.Second
+)
+
+// OrdersListener подписка на уведомления о новых заказах. Listen вызывает
+// notify сразу после подписки и на каждое уведомление, блокируется до
+// отмены контекста или потери соединения.
+//
+//go:generate mockgen -destination ./mocks/orders_listener.go . OrdersListener
+type OrdersListener interface {
+	Listen(ctx context.Context, notify func()) error
+}
+
+// WithOrdersListener включает немедленную обработку новых заказов по
+// уведомлению. Тикер остаётся страховочным проходом.
+func WithOrdersListener(l OrdersListener) Option {
+	return func(c *Collector) {
+		c.listener = l
+	}
+}
+
+// wakeup запускает внеочередной цикл. Уведомления, пришедшие во время цикла,
+// схлопываются в один.
+func (c *Collector) wakeup() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// listenOrders держит подписку и переподключается с экспоненциальной
+// задержкой. После переподключения выполняется внеочередной цикл:
+// уведомления за время простоя потеряны. Задержка сбрасывается, только если
+// подписка продержалась listenStableAfter или по ней пришло уведомление:
+// соединение, рвущееся сразу после LISTEN, не переподключается каждую
+// секунду.
+func (c *Collector) listenOrders(ctx context.Context) {
+	delay := listenRetryMin
+	for {
+		start := time.Now()
+		// первый вызов notify - сразу после подписки, не уведомление
+		calls := 0
+		err := c.listener.Listen(ctx, func() {
+			calls++
+			c.wakeup()
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		delay = listenRetryDelay(delay, time.Since(start), calls > 1)
+
+		slog.Warn(
+			"orders listener disconnected, reconnecting",
+			slog.Any("error", err),
+			slog.Duration("delay", delay),
+		)
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		delay = min(delay*2, listenRetryMax)
+	}
+}
+
+// listenRetryDelay задержка перед переподключением после подписки,
+// продержавшейся uptime.
+func listenRetryDelay(
+	delay, uptime time.Duration,
+	notified bool,
+) time.Duration {
+	if notified || uptime >= listenStableAfter {
+		return listenRetryMin
+	}
+	return delay
+}
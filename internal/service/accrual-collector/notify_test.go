@@ -0,0 +1,152 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/accrual-collector/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCollector_RunWakesUpOnNotification(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	listener := mocks.NewMockOrdersListener(ctrl)
+
+	listener.EXPECT().
+		Listen(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, notify func()) error {
+			notify()
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	processed := make(chan struct{})
+	repo.EXPECT().
+		GetQueueDepth(gomock.Any()).
+		Return(map[model.OrderStatus]int64{model.StatusNew: 1}, nil)
+	repo.EXPECT().
+		ParkStaleOrders(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(int64(0), nil)
+	repo.EXPECT().
+		GetOrdersBatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			context.Context,
+			int,
+			time.Duration,
+			time.Duration,
+		) ([]model.Order, error) {
+			close(processed)
+			return nil, nil
+		})
+
+	// тикер не успеет сработать, цикл запускает только уведомление
+	c := NewCollector(
+		NewHTTPProvider("http://localhost"),
+		time.Hour,
+		repo,
+		WithOrdersListener(listener),
+	)
+
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	select {
+	case <-processed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("collector was not woken up")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestCollector_listenOrdersReconnects(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener := mocks.NewMockOrdersListener(ctrl)
+	gomock.InOrder(
+		listener.EXPECT().
+			Listen(gomock.Any(), gomock.Any()).
+			Return(errors.New("connection refused")),
+		listener.EXPECT().
+			Listen(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, notify func()) error {
+				notify()
+				cancel()
+				return errors.New("conn closed")
+			}),
+	)
+
+	c := NewCollector(
+		NewHTTPProvider("http://localhost"),
+		time.Hour,
+		mocks.NewMockCollectorRepository(ctrl),
+		WithOrdersListener(listener),
+	)
+	c.listenOrders(ctx)
+
+	select {
+	case <-c.wake:
+	default:
+		t.Fatal("expected wakeup after reconnect")
+	}
+}
+
+func TestListenRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		delay    time.Duration
+		uptime   time.Duration
+		notified bool
+		want     time.Duration
+	}{
+		{
+			name:   "dropped right after subscribe",
+			delay:  8 * time.Second,
+			uptime: 10 * time.Millisecond,
+			want:   8 * time.Second,
+		},
+		{
+			name:     "notification received",
+			delay:    8 * time.Second,
+			uptime:   10 * time.Millisecond,
+			notified: true,
+			want:     listenRetryMin,
+		},
+		{
+			name:   "stayed up",
+			delay:  listenRetryMax,
+			uptime: listenStableAfter,
+			want:   listenRetryMin,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(
+				t,
+				tc.want,
+				listenRetryDelay(tc.delay, tc.uptime, tc.notified),
+			)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+// AccrualProvider источник данных о расчёте начислений. Ответы accrual,
+// предусмотренные протоколом, возвращаются результатом; ошибкой - сбои
+// (сеть, 5xx, непонятный ответ), в том числе *AccrualError.
+type AccrualProvider interface {
+	// Register регистрирует заказ с составом чека.
+	Register(
+		ctx context.Context,
+		number string,
+		goods []model.Good,
+	) (*AccrualResult, error)
+	// Check запрашивает статус расчёта по заказу.
+	Check(ctx context.Context, number string) (*AccrualResult, error)
+}
+
+// retryGate реализуют провайдеры с собственными повторами запросов:
+// коллектор разрешает повторы, только пока circuit breaker замкнут.
+type retryGate interface {
+	SetRetryGate(allow func() bool)
+}
+
+type ResultStatus int
+
+const (
+	// ResultUnknown заказ не зарегистрирован в accrual.
+	ResultUnknown ResultStatus = iota
+	// ResultRegistered заказ зарегистрирован, расчёт не начат (или, для
+	// Register, заказ уже был зарегистрирован ранее).
+	ResultRegistered
+	ResultProcessing
+	ResultProcessed
+	ResultInvalid
+	// ResultRateLimited превышен лимит запросов, см. RetryAfter и RateLimit.
+	ResultRateLimited
+)
+
+func (s ResultStatus) String() string {
+	switch s {
+	case ResultUnknown:
+		return "UNKNOWN"
+	case ResultRegistered:
+		return "REGISTERED"
+	case ResultProcessing:
+		return "PROCESSING"
+	case ResultProcessed:
+		return "PROCESSED"
+	case ResultInvalid:
+		return "INVALID"
+	case ResultRateLimited:
+		return "RATE_LIMITED"
+	default:
+		return "UNDEFINED"
+	}
+}
+
+func (s ResultStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *ResultStatus) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "UNKNOWN":
+		*s = ResultUnknown
+	case "REGISTERED":
+		*s = ResultRegistered
+	case "PROCESSING":
+		*s = ResultProcessing
+	case "PROCESSED":
+		*s = ResultProcessed
+	case "INVALID":
+		*s = ResultInvalid
+	case "RATE_LIMITED":
+		*s = ResultRateLimited
+	default:
+		return fmt.Errorf("unknown accrual result status %q", string(b))
+	}
+	return nil
+}
+
+// AccrualResult типизированный ответ провайдера. Accrual заполняется для
+// ResultProcessed, RetryAfter и RateLimit (запросов в минуту) - для
+// ResultRateLimited, нулевые значения означают "не сообщается".
+type AccrualResult struct {
+	Status     ResultStatus
+	Accrual    model.Kopek
+	RetryAfter time.Duration
+	RateLimit  int
+}
+
+// AccrualError ошибка обработки конкретного заказа на стороне accrual.
+// Permanent - повтор не поможет, заказ откладывается для ручного разбора.
+type AccrualError struct {
+	StatusCode int
+	Body       string
+	Permanent  bool
+	Err        error
+}
+
+func (e *AccrualError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AccrualError) Unwrap() error {
+	return e.Err
+}
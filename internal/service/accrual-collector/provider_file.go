@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+// providerScript формат файла для NewFileProvider:
+//
+//	{
+//	  "orders": {
+//	    "79927398713": [
+//	      {"status": "PROCESSING"},
+//	      {"status": "PROCESSED", "accrual": 500}
+//	    ]
+//	  },
+//	  "default": [{"status": "INVALID"}]
+//	}
+type providerScript struct {
+	Orders  map[string][]scriptStep `json:"orders"`
+	Default []scriptStep            `json:"default"`
+}
+
+type scriptStep struct {
+	Status     ResultStatus `json:"status"`
+	Accrual    model.Kopek  `json:"accrual"`
+	RetryAfter string       `json:"retry_after"`
+	RateLimit  int          `json:"rate_limit"`
+}
+
+// NewFileProvider загружает сценарий MemoryProvider из JSON файла, для
+// демонстраций без accrual.
+func NewFileProvider(path string) (*MemoryProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accrual script: %w", err)
+	}
+
+	var script providerScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse accrual script: %w", err)
+	}
+
+	p := NewMemoryProvider()
+	for number, steps := range script.Orders {
+		results, err := scriptResults(steps)
+		if err != nil {
+			return nil, fmt.Errorf("order %s: %w", number, err)
+		}
+		p.Script(number, results...)
+	}
+
+	results, err := scriptResults(script.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default: %w", err)
+	}
+	p.SetDefault(results...)
+
+	return p, nil
+}
+
+func scriptResults(steps []scriptStep) ([]AccrualResult, error) {
+	results := make([]AccrualResult, 0, len(steps))
+	for _, s := range steps {
+		res := AccrualResult{
+			Status:    s.Status,
+			Accrual:   s.Accrual,
+			RateLimit: s.RateLimit,
+		}
+		if s.RetryAfter != "" {
+			d, err := time.ParseDuration(s.RetryAfter)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"invalid retry_after %q: %w",
+					s.RetryAfter,
+					err,
+				)
+			}
+			res.RetryAfter = d
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
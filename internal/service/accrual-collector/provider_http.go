@@ -0,0 +1,259 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	clientTimeout    = 5 * time.Second
+	getOrdersURL     = "/api/orders/"
+	registerOrderURL = "/api/orders"
+)
+
+var _ AccrualProvider = (*HTTPProvider)(nil)
+
+type AccrualResponse struct {
+	Number  string      `json:"order"`
+	Status  string      `json:"status"`
+	Accrual model.Kopek `json:"accrual,omitempty"`
+}
+
+type AccrualRegisterRequest struct {
+	Number string       `json:"order"`
+	Goods  []model.Good `json:"goods"`
+}
+
+// HTTPProvider клиент API системы расчёта баллов accrual.
+type HTTPProvider struct {
+	Client *resty.Client
+
+	allowRetry func() bool
+}
+
+func NewHTTPProvider(accrualAddress string) *HTTPProvider {
+	p := &HTTPProvider{}
+
+	client := resty.New()
+	client.
+		SetTimeout(clientTimeout).
+		SetRetryCount(3).
+		SetRetryWaitTime(1 * time.Second).
+		SetRetryMaxWaitTime(5 * time.Second).
+		SetBaseURL(accrualAddress).
+		AddRetryCondition(func(_ *resty.Response, err error) bool {
+			// повторяем только сетевые ошибки
+			return err != nil &&
+				(p.allowRetry == nil || p.allowRetry())
+		}).
+		OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+			// передаём контекст трассировки в accrual
+			otel.GetTextMapPropagator().Inject(
+				r.Context(),
+				propagation.HeaderCarrier(r.Header),
+			)
+			return nil
+		})
+	p.Client = client
+
+	return p
+}
+
+func (p *HTTPProvider) SetRetryGate(allow func() bool) {
+	p.allowRetry = allow
+}
+
+func (p *HTTPProvider) Check(
+	ctx context.Context,
+	number string,
+) (res *AccrualResult, err error) {
+	ctx, span := startProviderSpan(ctx, "HTTPProvider.Check", number)
+	defer func() { endProviderSpan(span, res, err) }()
+
+	var respBody AccrualResponse
+	resp, err := p.Client.R().
+		SetContext(ctx).
+		SetResult(&respBody).
+		Get(getOrdersURL + number)
+	if err != nil {
+		return nil, newAccrualError(
+			resp,
+			fmt.Errorf("failed to request accrual: %w", err),
+		)
+	}
+
+	switch sc := resp.StatusCode(); sc {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return &AccrualResult{Status: ResultUnknown}, nil
+	case http.StatusTooManyRequests:
+		return rateLimitedResult(resp), nil
+	default:
+		return nil, newAccrualError(
+			resp,
+			fmt.Errorf("failed to request accrual, http_code=%d", sc),
+		)
+	}
+
+	switch respBody.Status {
+	case "REGISTERED":
+		return &AccrualResult{Status: ResultRegistered}, nil
+	case model.StatusProcessing.String():
+		return &AccrualResult{Status: ResultProcessing}, nil
+	case model.StatusProcessed.String():
+		return &AccrualResult{
+			Status:  ResultProcessed,
+			Accrual: respBody.Accrual,
+		}, nil
+	case model.StatusInvalid.String():
+		return &AccrualResult{Status: ResultInvalid}, nil
+	default:
+		ae := newAccrualError(
+			resp,
+			fmt.Errorf("unknown order status %q", respBody.Status),
+		)
+		ae.Permanent = true
+		return nil, ae
+	}
+}
+
+func (p *HTTPProvider) Register(
+	ctx context.Context,
+	number string,
+	goods []model.Good,
+) (res *AccrualResult, err error) {
+	ctx, span := startProviderSpan(ctx, "HTTPProvider.Register", number)
+	defer func() { endProviderSpan(span, res, err) }()
+
+	if goods == nil {
+		goods = []model.Good{}
+	}
+
+	resp, err := p.Client.R().
+		SetContext(ctx).
+		SetBody(AccrualRegisterRequest{
+			Number: number,
+			Goods:  goods,
+		}).
+		Post(registerOrderURL)
+	if err != nil {
+		return nil, newAccrualError(
+			resp,
+			fmt.Errorf("failed to register order in accrual: %w", err),
+		)
+	}
+
+	switch sc := resp.StatusCode(); sc {
+	case http.StatusAccepted:
+		return &AccrualResult{Status: ResultProcessing}, nil
+	case http.StatusConflict:
+		return &AccrualResult{Status: ResultRegistered}, nil
+	case http.StatusBadRequest:
+		slog.Warn(
+			"accrual rejected order registration",
+			slog.String("number", number),
+			slog.String("response", resp.String()),
+		)
+		return &AccrualResult{Status: ResultInvalid}, nil
+	case http.StatusTooManyRequests:
+		return rateLimitedResult(resp), nil
+	default:
+		return nil, newAccrualError(
+			resp,
+			fmt.Errorf("failed to register order in accrual, http_code=%d", sc),
+		)
+	}
+}
+
+// Ping проверяет, что accrual отвечает по HTTP. Запрос идёт мимо resty,
+// чтобы не было повторов, и не в /api/orders, чтобы не тратить лимит
+// запросов; любой HTTP-ответ означает доступность.
+func (p *HTTPProvider) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		p.Client.BaseURL,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to build accrual ping request: %w", err)
+	}
+
+	resp, err := p.Client.GetClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("accrual is unreachable: %w", err)
+	}
+	_ = resp.Body.Close()
+
+	return nil
+}
+
+func startProviderSpan(
+	ctx context.Context,
+	name string,
+	number string,
+) (context.Context, trace.Span) {
+	return tracer.Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("order.number", number)),
+	)
+}
+
+func endProviderSpan(span trace.Span, res *AccrualResult, err error) {
+	defer span.End()
+
+	if err != nil {
+		var ae *AccrualError
+		if errors.As(err, &ae) && ae.StatusCode != 0 {
+			span.SetAttributes(
+				attribute.Int("http.response.status_code", ae.StatusCode),
+			)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.SetAttributes(attribute.String("accrual.result", res.Status.String()))
+}
+
+func rateLimitedResult(resp *resty.Response) *AccrualResult {
+	res := &AccrualResult{Status: ResultRateLimited}
+	if limit, ok := parseRateLimit(resp.String()); ok {
+		res.RateLimit = limit
+	}
+
+	header := resp.Header().Get("Retry-After")
+	if d, ok := parseRetryAfter(header, time.Now()); ok {
+		res.RetryAfter = d
+	} else if header != "" {
+		slog.Error(
+			"failed to get retry period from header",
+			slog.String("header_value", header),
+		)
+	}
+
+	return res
+}
+
+func newAccrualError(resp *resty.Response, err error) *AccrualError {
+	ae := &AccrualError{Err: err}
+	if resp != nil && resp.RawResponse != nil {
+		ae.StatusCode = resp.StatusCode()
+		ae.Body = resp.String()
+	}
+	return ae
+}
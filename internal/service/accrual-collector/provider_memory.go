@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+var _ AccrualProvider = (*MemoryProvider)(nil)
+
+// MemoryProvider сценарный провайдер в памяти. Для заказа задаётся
+// последовательность результатов Check, последний результат повторяется.
+// Заказы без сценария получают сценарий по умолчанию, а если его нет -
+// ведут себя как в accrual: UNKNOWN до регистрации, затем PROCESSING.
+type MemoryProvider struct {
+	mu         sync.Mutex
+	scripts    map[string][]AccrualResult
+	fallback   []AccrualResult
+	steps      map[string]int
+	registered map[string]bool
+}
+
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		scripts:    make(map[string][]AccrualResult),
+		steps:      make(map[string]int),
+		registered: make(map[string]bool),
+	}
+}
+
+// Script задаёт сценарий ответов Check для заказа. Заказ со сценарием
+// считается уже зарегистрированным в accrual.
+func (p *MemoryProvider) Script(number string, results ...AccrualResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.scripts[number] = results
+	p.steps[number] = 0
+}
+
+// SetDefault задаёт сценарий для заказов без собственного сценария.
+func (p *MemoryProvider) SetDefault(results ...AccrualResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.fallback = results
+}
+
+func (p *MemoryProvider) Register(
+	_ context.Context,
+	number string,
+	_ []model.Good,
+) (*AccrualResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.scripts[number]; ok || p.registered[number] {
+		return &AccrualResult{Status: ResultRegistered}, nil
+	}
+	p.registered[number] = true
+
+	return &AccrualResult{Status: ResultProcessing}, nil
+}
+
+func (p *MemoryProvider) Check(
+	_ context.Context,
+	number string,
+) (*AccrualResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	script, ok := p.scripts[number]
+	if !ok {
+		script = p.fallback
+	}
+	if len(script) == 0 {
+		if p.registered[number] {
+			return &AccrualResult{Status: ResultProcessing}, nil
+		}
+		return &AccrualResult{Status: ResultUnknown}, nil
+	}
+
+	step := p.steps[number]
+	if step < len(script)-1 {
+		p.steps[number] = step + 1
+	}
+	res := script[step]
+
+	return &res, nil
+}
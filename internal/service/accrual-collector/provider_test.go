@@ -0,0 +1,235 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/accrual-collector/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHTTPProvider_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		body    string
+		header  map[string]string
+		want    *AccrualResult
+		wantErr bool
+	}{
+		{
+			name: "processed",
+			code: http.StatusOK,
+			body: `{"order":"1","status":"PROCESSED","accrual":500}`,
+			want: &AccrualResult{Status: ResultProcessed, Accrual: 50000},
+		},
+		{
+			name: "registered",
+			code: http.StatusOK,
+			body: `{"order":"1","status":"REGISTERED"}`,
+			want: &AccrualResult{Status: ResultRegistered},
+		},
+		{
+			name: "not registered",
+			code: http.StatusNoContent,
+			want: &AccrualResult{Status: ResultUnknown},
+		},
+		{
+			name:   "rate limited",
+			code:   http.StatusTooManyRequests,
+			body:   "No more than 5 requests per minute allowed",
+			header: map[string]string{"Retry-After": "30"},
+			want: &AccrualResult{
+				Status:     ResultRateLimited,
+				RetryAfter: 30 * time.Second,
+				RateLimit:  5,
+			},
+		},
+		{
+			name:    "unknown status",
+			code:    http.StatusOK,
+			body:    `{"order":"1","status":"WHATEVER"}`,
+			wantErr: true,
+		},
+		{
+			name:    "internal error",
+			code:    http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					for k, v := range tt.header {
+						w.Header().Set(k, v)
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(tt.code)
+					_, _ = w.Write([]byte(tt.body))
+				},
+			))
+			defer srv.Close()
+
+			got, err := newTestProvider(srv.URL).Check(context.Background(), "1")
+			if tt.wantErr {
+				var ae *AccrualError
+				assert.ErrorAs(t, err, &ae)
+				assert.Equal(t, tt.code, ae.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHTTPProvider_PropagatesTraceContext(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			traceparent = r.Header.Get("traceparent")
+			w.WriteHeader(http.StatusNoContent)
+		},
+	))
+	defer srv.Close()
+
+	_, err := newTestProvider(srv.URL).Check(context.Background(), "1")
+	require.NoError(t, err)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "HTTPProvider.Check", spans[0].Name)
+	assert.Contains(t, traceparent, spans[0].SpanContext.TraceID().String())
+	assert.Contains(t, traceparent, spans[0].SpanContext.SpanID().String())
+}
+
+func TestMemoryProvider(t *testing.T) {
+	ctx := context.Background()
+	p := NewMemoryProvider()
+	p.Script(
+		"1",
+		AccrualResult{Status: ResultProcessing},
+		AccrualResult{Status: ResultProcessed, Accrual: 100},
+	)
+
+	res, err := p.Check(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, ResultProcessing, res.Status)
+
+	// последний шаг сценария повторяется
+	for range 2 {
+		res, err = p.Check(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, ResultProcessed, res.Status)
+		assert.Equal(t, model.Kopek(100), res.Accrual)
+	}
+
+	res, err = p.Register(ctx, "1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, ResultRegistered, res.Status)
+
+	// заказ без сценария
+	res, err = p.Check(ctx, "2")
+	require.NoError(t, err)
+	assert.Equal(t, ResultUnknown, res.Status)
+
+	res, err = p.Register(ctx, "2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, ResultProcessing, res.Status)
+
+	res, err = p.Check(ctx, "2")
+	require.NoError(t, err)
+	assert.Equal(t, ResultProcessing, res.Status)
+}
+
+func TestNewFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accrual.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"orders": {
+			"1": [
+				{"status": "RATE_LIMITED", "retry_after": "5s", "rate_limit": 10},
+				{"status": "PROCESSED", "accrual": 729.98}
+			]
+		},
+		"default": [{"status": "INVALID"}]
+	}`), 0o600))
+
+	p, err := NewFileProvider(path)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	res, err := p.Check(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, &AccrualResult{
+		Status:     ResultRateLimited,
+		RetryAfter: 5 * time.Second,
+		RateLimit:  10,
+	}, res)
+
+	res, err = p.Check(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, &AccrualResult{Status: ResultProcessed, Accrual: 72998}, res)
+
+	res, err = p.Check(ctx, "2")
+	require.NoError(t, err)
+	assert.Equal(t, ResultInvalid, res.Status)
+}
+
+func TestNewFileProviderInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accrual.json")
+	require.NoError(t, os.WriteFile(
+		path,
+		[]byte(`{"default": [{"status": "DONE"}]}`),
+		0o600,
+	))
+
+	_, err := NewFileProvider(path)
+	assert.Error(t, err)
+
+	_, err = NewFileProvider(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestCollector_handleOrderMemoryProvider(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p := NewMemoryProvider()
+	p.Script("1", AccrualResult{Status: ResultProcessed, Accrual: 500})
+
+	repo := mocks.NewMockCollectorRepository(ctrl)
+	repo.EXPECT().SetAccrual(gomock.Any(), 7, model.Kopek(500)).Return(nil)
+
+	c := NewCollector(p, time.Second, repo)
+	order := model.Order{
+		ID:           7,
+		Number:       "1",
+		Status:       model.StatusProcessing,
+		Registration: model.RegistrationRegistered,
+	}
+	assert.NoError(t, c.handleOrder(context.Background(), &order))
+}
@@ -0,0 +1,224 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 500
+	MaxReasonLength    = 1000
+)
+
+var tracer = otel.Tracer("github.com/fragpit/gophermart/internal/service/admin")
+
+// AdminService операции /api/admin: поиск пользователей, просмотр их
+// заказов и списаний, ручные корректировки баланса и смена статуса.
+type AdminService struct {
+	repo        model.AdminRepository
+	orders      model.OrdersRepository
+	withdrawals model.WithdrawalsRepository
+}
+
+func NewAdminService(
+	repo model.AdminRepository,
+	orders model.OrdersRepository,
+	withdrawals model.WithdrawalsRepository,
+) *AdminService {
+	return &AdminService{
+		repo:        repo,
+		orders:      orders,
+		withdrawals: withdrawals,
+	}
+}
+
+func (s *AdminService) SearchUsers(
+	ctx context.Context,
+	query string,
+	limit int,
+) ([]model.User, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.SearchUsers")
+	defer span.End()
+
+	return s.repo.SearchUsers(
+		ctx,
+		strings.TrimSpace(query),
+		clampLimit(limit),
+	)
+}
+
+func (s *AdminService) GetUserOrders(
+	ctx context.Context,
+	userID int,
+) ([]model.Order, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.GetUserOrders")
+	defer span.End()
+
+	return s.orders.GetOrdersByUserID(ctx, userID)
+}
+
+func (s *AdminService) GetUserWithdrawals(
+	ctx context.Context,
+	userID int,
+) ([]model.Withdrawal, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.GetUserWithdrawals")
+	defer span.End()
+
+	return s.withdrawals.GetWithdrawalsByUserID(ctx, userID)
+}
+
+// AdjustBalance зачисляет (amount > 0) или списывает (amount < 0) баллы
+// пользователя. Причина обязательна и сохраняется в журнале аудита вместе
+// с администратором.
+func (s *AdminService) AdjustBalance(
+	ctx context.Context,
+	actor *model.Principal,
+	userID int,
+	amount model.Kopek,
+	reason string,
+) (*model.AuditRecord, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.AdjustBalance")
+	defer span.End()
+
+	reason = strings.TrimSpace(reason)
+	if amount == 0 {
+		return nil, fmt.Errorf(
+			"%w: amount must not be zero",
+			model.ErrInvalidAdjustment,
+		)
+	}
+	if reason == "" || utf8.RuneCountInString(reason) > MaxReasonLength {
+		return nil, fmt.Errorf(
+			"%w: reason must be 1 to %d characters long",
+			model.ErrInvalidAdjustment,
+			MaxReasonLength,
+		)
+	}
+
+	rec := &model.AuditRecord{
+		ActorID:      actor.UserID,
+		Action:       model.AuditBalanceAdjustment,
+		TargetUserID: userID,
+		Amount:       amount,
+		Reason:       reason,
+	}
+	if err := s.repo.AdjustBalance(ctx, rec); err != nil {
+		return nil, err
+	}
+
+	slog.Info(
+		"balance adjusted",
+		slog.Int("admin_id", actor.UserID),
+		slog.Int("user_id", userID),
+		slog.Int("amount", int(amount)),
+		slog.Int("audit_id", rec.ID),
+	)
+
+	return rec, nil
+}
+
+// ChangeUserStatus приостанавливает, возобновляет или закрывает аккаунт.
+// При закрытии settlement определяет судьбу остатка баланса: сгорает или
+// считается выплаченным. Свой статус администратор менять не может.
+func (s *AdminService) ChangeUserStatus(
+	ctx context.Context,
+	actor *model.Principal,
+	userID int,
+	status model.UserStatus,
+	settlement model.BalanceSettlement,
+	reason string,
+) (*model.AuditRecord, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.ChangeUserStatus")
+	defer span.End()
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > MaxReasonLength {
+		return nil, fmt.Errorf(
+			"%w: reason must be 1 to %d characters long",
+			model.ErrInvalidStatusChange,
+			MaxReasonLength,
+		)
+	}
+	if actor.UserID == userID {
+		return nil, fmt.Errorf(
+			"%w: cannot change own status",
+			model.ErrInvalidStatusChange,
+		)
+	}
+
+	var action string
+	switch status {
+	case model.UserStatusActive:
+		action = model.AuditUserReactivated
+	case model.UserStatusSuspended:
+		action = model.AuditUserSuspended
+	case model.UserStatusClosed:
+		action = model.AuditUserClosed
+		if settlement != model.SettlementForfeit &&
+			settlement != model.SettlementPayout {
+			return nil, fmt.Errorf(
+				"%w: balance must be %q or %q",
+				model.ErrInvalidStatusChange,
+				model.SettlementForfeit,
+				model.SettlementPayout,
+			)
+		}
+	default:
+		return nil, fmt.Errorf(
+			"%w: unknown status %q",
+			model.ErrInvalidStatusChange,
+			status,
+		)
+	}
+
+	change := &model.StatusChange{
+		Audit: model.AuditRecord{
+			ActorID:      actor.UserID,
+			Action:       action,
+			TargetUserID: userID,
+			Reason:       reason,
+		},
+		Status:     status,
+		Settlement: settlement,
+	}
+	if err := s.repo.ChangeUserStatus(ctx, change); err != nil {
+		return nil, err
+	}
+
+	slog.Info(
+		"user status changed",
+		slog.Int("admin_id", actor.UserID),
+		slog.Int("user_id", userID),
+		slog.String("status", string(status)),
+		slog.Int("settled", int(-change.Audit.Amount)),
+		slog.Int("audit_id", change.Audit.ID),
+	)
+
+	return &change.Audit, nil
+}
+
+// GetAuditRecords журнал аудита, userID 0 - по всем пользователям.
+func (s *AdminService) GetAuditRecords(
+	ctx context.Context,
+	userID int,
+	limit int,
+) ([]model.AuditRecord, error) {
+	ctx, span := tracer.Start(ctx, "AdminService.GetAuditRecords")
+	defer span.End()
+
+	return s.repo.GetAuditRecords(ctx, userID, clampLimit(limit))
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultSearchLimit
+	}
+	return min(limit, MaxSearchLimit)
+}
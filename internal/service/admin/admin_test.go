@@ -0,0 +1,245 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/admin/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminService_AdjustBalance(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	actor := &model.Principal{UserID: 1, Roles: []string{model.RoleAdmin}}
+
+	tests := []struct {
+		name    string
+		amount  model.Kopek
+		reason  string
+		prepare func(*mocks.MockAdminRepository)
+		wantErr error
+	}{
+		{
+			name:   "credit",
+			amount: 1050,
+			reason: "  goodwill for order 79927398713 ",
+			prepare: func(r *mocks.MockAdminRepository) {
+				r.EXPECT().AdjustBalance(gomock.Any(), &model.AuditRecord{
+					ActorID:      1,
+					Action:       model.AuditBalanceAdjustment,
+					TargetUserID: 7,
+					Amount:       1050,
+					Reason:       "goodwill for order 79927398713",
+				}).DoAndReturn(func(_ context.Context, rec *model.AuditRecord) error {
+					rec.ID = 11
+					return nil
+				})
+			},
+		},
+		{
+			name:   "debit below zero",
+			amount: -500,
+			reason: "duplicate accrual",
+			prepare: func(r *mocks.MockAdminRepository) {
+				r.EXPECT().AdjustBalance(gomock.Any(), gomock.Any()).
+					Return(model.ErrInsufficientPoints)
+			},
+			wantErr: model.ErrInsufficientPoints,
+		},
+		{
+			name:    "zero amount",
+			reason:  "nothing",
+			wantErr: model.ErrInvalidAdjustment,
+		},
+		{
+			name:    "no reason",
+			amount:  100,
+			reason:  "   ",
+			wantErr: model.ErrInvalidAdjustment,
+		},
+		{
+			name:    "reason too long",
+			amount:  100,
+			reason:  strings.Repeat("x", MaxReasonLength+1),
+			wantErr: model.ErrInvalidAdjustment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockAdminRepository(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(repo)
+			}
+
+			svc := NewAdminService(repo, nil, nil)
+			rec, err := svc.AdjustBalance(
+				context.Background(),
+				actor,
+				7,
+				tt.amount,
+				tt.reason,
+			)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 11, rec.ID)
+		})
+	}
+}
+
+func TestAdminService_ChangeUserStatus(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	actor := &model.Principal{UserID: 1, Roles: []string{model.RoleAdmin}}
+
+	tests := []struct {
+		name       string
+		userID     int
+		status     model.UserStatus
+		settlement model.BalanceSettlement
+		reason     string
+		prepare    func(*mocks.MockAdminRepository)
+		wantErr    error
+	}{
+		{
+			name:   "suspend",
+			userID: 7,
+			status: model.UserStatusSuspended,
+			reason: " fraud investigation ",
+			prepare: func(r *mocks.MockAdminRepository) {
+				r.EXPECT().ChangeUserStatus(gomock.Any(), &model.StatusChange{
+					Audit: model.AuditRecord{
+						ActorID:      1,
+						Action:       model.AuditUserSuspended,
+						TargetUserID: 7,
+						Reason:       "fraud investigation",
+					},
+					Status: model.UserStatusSuspended,
+				}).DoAndReturn(func(_ context.Context, c *model.StatusChange) error {
+					c.Audit.ID = 11
+					return nil
+				})
+			},
+		},
+		{
+			name:       "close with forfeit",
+			userID:     7,
+			status:     model.UserStatusClosed,
+			settlement: model.SettlementForfeit,
+			reason:     "confirmed fraud",
+			prepare: func(r *mocks.MockAdminRepository) {
+				r.EXPECT().ChangeUserStatus(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, c *model.StatusChange) error {
+						assert.Equal(t, model.AuditUserClosed, c.Audit.Action)
+						assert.Equal(t, model.SettlementForfeit, c.Settlement)
+						c.Audit.ID = 11
+						c.Audit.Amount = -1500
+						return nil
+					})
+			},
+		},
+		{
+			name:    "close without settlement",
+			userID:  7,
+			status:  model.UserStatusClosed,
+			reason:  "confirmed fraud",
+			wantErr: model.ErrInvalidStatusChange,
+		},
+		{
+			name:    "unknown status",
+			userID:  7,
+			status:  "deleted",
+			reason:  "cleanup",
+			wantErr: model.ErrInvalidStatusChange,
+		},
+		{
+			name:    "no reason",
+			userID:  7,
+			status:  model.UserStatusSuspended,
+			reason:  "  ",
+			wantErr: model.ErrInvalidStatusChange,
+		},
+		{
+			name:    "own account",
+			userID:  1,
+			status:  model.UserStatusSuspended,
+			reason:  "test",
+			wantErr: model.ErrInvalidStatusChange,
+		},
+		{
+			name:   "transition not allowed",
+			userID: 7,
+			status: model.UserStatusActive,
+			reason: "reopen",
+			prepare: func(r *mocks.MockAdminRepository) {
+				r.EXPECT().ChangeUserStatus(gomock.Any(), gomock.Any()).
+					Return(model.ErrInvalidStatusTransition)
+			},
+			wantErr: model.ErrInvalidStatusTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockAdminRepository(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(repo)
+			}
+
+			rec, err := NewAdminService(repo, nil, nil).ChangeUserStatus(
+				context.Background(),
+				actor,
+				tt.userID,
+				tt.status,
+				tt.settlement,
+				tt.reason,
+			)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 11, rec.ID)
+		})
+	}
+}
+
+func TestAdminService_SearchUsers(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		limit     int
+		wantQuery string
+		wantLimit int
+	}{
+		{"default limit", " user ", 0, "user", DefaultSearchLimit},
+		{"explicit limit", "user", 10, "user", 10},
+		{"limit capped", "", 10000, "", MaxSearchLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockAdminRepository(ctrl)
+			repo.EXPECT().
+				SearchUsers(gomock.Any(), tt.wantQuery, tt.wantLimit).
+				Return([]model.User{{ID: 7, Login: "user"}}, nil)
+
+			users, err := NewAdminService(repo, nil, nil).
+				SearchUsers(context.Background(), tt.query, tt.limit)
+			require.NoError(t, err)
+			assert.Len(t, users, 1)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+// ExportData все данные пользователя по его запросу: профиль, заказы,
+// списания и сессии.
+func (a *AuthService) ExportData(
+	ctx context.Context,
+	p *model.Principal,
+) (*model.UserExport, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ExportData")
+	defer span.End()
+
+	export, err := a.repo.ExportData(ctx, p.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export user data: %w", err)
+	}
+
+	slog.Info("user data exported", slog.Int("user_id", p.UserID))
+
+	return export, nil
+}
+
+// DeleteAccount удаляет аккаунт по запросу пользователя после проверки
+// пароля: персональные данные стираются, финансовые записи остаются за
+// обезличенным id, остаток баланса сгорает. Неверный пароль учитывается в
+// ограничении перебора паролей.
+func (a *AuthService) DeleteAccount(
+	ctx context.Context,
+	p *model.Principal,
+	password string,
+) error {
+	ctx, span := tracer.Start(ctx, "AuthService.DeleteAccount")
+	defer span.End()
+
+	u, err := a.verifyPassword(ctx, p.UserID, password)
+	if err != nil {
+		return err
+	}
+
+	if err := a.repo.Erase(ctx, u.ID); err != nil {
+		return fmt.Errorf("failed to erase user: %w", err)
+	}
+
+	slog.Info("user account erased", slog.Int("user_id", u.ID))
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/auth/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthService_ExportData(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockUsersRepository(ctrl)
+	repo.EXPECT().ExportData(gomock.Any(), 7).Return(&model.UserExport{
+		User:   model.User{ID: 7, Login: "alice"},
+		Orders: []model.Order{{ID: 1, UserID: 7, Number: "79927398713"}},
+	}, nil)
+
+	svc := NewAuthService(repo, nil, testJWT(t), time.Hour)
+	export, err := svc.ExportData(
+		context.Background(),
+		&model.Principal{UserID: 7},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", export.User.Login)
+	assert.Len(t, export.Orders, 1)
+}
+
+func TestAuthService_DeleteAccount(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	hasher := NewPasswordHasher(testArgon2Params(), 0)
+	hashed, _ := hasher.Hash("current_password")
+	user := &model.User{ID: 7, Login: "alice", PasswordHash: hashed}
+
+	tests := []struct {
+		name     string
+		password string
+		prepare  func(*mocks.MockUsersRepository)
+		wantErr  error
+	}{
+		{
+			name:     "wrong password",
+			password: "wrong_password",
+			prepare: func(r *mocks.MockUsersRepository) {
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+			},
+			wantErr: model.ErrInvalidCredentials,
+		},
+		{
+			name:     "storage error",
+			password: "current_password",
+			prepare: func(r *mocks.MockUsersRepository) {
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				r.EXPECT().Erase(gomock.Any(), 7).Return(errDBDown)
+			},
+			wantErr: errDBDown,
+		},
+		{
+			name:     "erased",
+			password: "current_password",
+			prepare: func(r *mocks.MockUsersRepository) {
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				r.EXPECT().Erase(gomock.Any(), 7).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			repo := mocks.NewMockUsersRepository(ctrl)
+			tt.prepare(repo)
+			svc := NewAuthService(
+				repo,
+				nil,
+				testJWT(t),
+				time.Hour,
+				WithPasswordHasher(hasher),
+			)
+
+			err := svc.DeleteAccount(
+				context.Background(),
+				&model.Principal{UserID: 7},
+				tt.password,
+			)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
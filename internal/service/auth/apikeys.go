@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+const (
+	DefaultAPIKeyTTL = 90 * 24 * time.Hour
+	MaxAPIKeyTTL     = 365 * 24 * time.Hour
+	MaxAPIKeyName    = 64
+
+	apiKeyBytes = 32
+	// apiKeyPrefixLen длина видимой части ключа, которая хранится открыто.
+	apiKeyPrefixLen = len(model.APIKeyPrefix) + 8
+	// apiKeyTouchInterval last_used_at обновляется не чаще, чтобы частые
+	// запросы кассы не писали в БД на каждый вызов.
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyService выпуск и проверка API ключей. Ключ случайный и длинный,
+// поэтому, как и refresh токен, хранится в виде sha256.
+type APIKeyService struct {
+	repo model.APIKeysRepository
+}
+
+func NewAPIKeyService(repo model.APIKeysRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+// CreateAPIKey выпускает ключ. Возвращает запись и сам ключ, который
+// показывается пользователю один раз. Нулевой expiresAt - DefaultAPIKeyTTL.
+func (s *APIKeyService) CreateAPIKey(
+	ctx context.Context,
+	userID int,
+	name string,
+	scopes []string,
+	expiresAt time.Time,
+) (*model.APIKey, string, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.CreateAPIKey")
+	defer span.End()
+
+	now := time.Now()
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(DefaultAPIKeyTTL)
+	}
+	if err := validateAPIKey(name, scopes, expiresAt, now); err != nil {
+		return nil, "", err
+	}
+
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	raw := model.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	k := &model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:apiKeyPrefixLen],
+		KeyHash:   hashToken(raw),
+		Scopes:    dedupScopes(scopes),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.CreateAPIKey(ctx, k); err != nil {
+		return nil, "", err
+	}
+
+	slog.Info(
+		"api key created",
+		slog.Int("user_id", userID),
+		slog.Int("key_id", k.ID),
+		slog.Any("scopes", k.Scopes),
+	)
+
+	return k, raw, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(
+	ctx context.Context,
+	userID int,
+) ([]model.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.GetAPIKeys")
+	defer span.End()
+
+	return s.repo.GetAPIKeysByUserID(ctx, userID)
+}
+
+func (s *APIKeyService) DeleteAPIKey(
+	ctx context.Context,
+	userID, id int,
+) error {
+	ctx, span := tracer.Start(ctx, "APIKeyService.DeleteAPIKey")
+	defer span.End()
+
+	return s.repo.DeleteAPIKey(ctx, userID, id)
+}
+
+// VerifyAPIKey проверяет ключ и срок его действия. Principal получает
+// только права ключа.
+func (s *APIKeyService) VerifyAPIKey(
+	ctx context.Context,
+	key string,
+) (*model.Principal, error) {
+	ctx, span := tracer.Start(ctx, "APIKeyService.VerifyAPIKey")
+	defer span.End()
+
+	if !strings.HasPrefix(key, model.APIKeyPrefix) {
+		return nil, model.ErrInvalidAPIKey
+	}
+
+	k, err := s.repo.GetAPIKeyByHash(ctx, hashToken(key))
+	if errors.Is(err, model.ErrAPIKeyNotFound) {
+		return nil, model.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	now := time.Now()
+	if !k.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expired", model.ErrInvalidAPIKey)
+	}
+	if k.UserStatus == model.UserStatusClosed {
+		return nil, fmt.Errorf("%w: %w", model.ErrInvalidAPIKey, model.ErrAccountClosed)
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval {
+		// время последнего использования справочное, запрос из-за него
+		// не отклоняется
+		if err := s.repo.TouchAPIKey(ctx, k.ID); err != nil {
+			slog.Error(
+				"failed to update api key last use",
+				slog.Int("key_id", k.ID),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	return &model.Principal{
+		UserID:    k.UserID,
+		ExpiresAt: k.ExpiresAt,
+		APIKeyID:  k.ID,
+		Scopes:    k.Scopes,
+		Status:    k.UserStatus,
+	}, nil
+}
+
+func validateAPIKey(
+	name string,
+	scopes []string,
+	expiresAt, now time.Time,
+) error {
+	if name == "" || utf8.RuneCountInString(name) > MaxAPIKeyName {
+		return fmt.Errorf(
+			"%w: name must be 1 to %d characters long",
+			model.ErrInvalidAPIKeyParams,
+			MaxAPIKeyName,
+		)
+	}
+
+	if len(scopes) == 0 {
+		return fmt.Errorf(
+			"%w: at least one scope required",
+			model.ErrInvalidAPIKeyParams,
+		)
+	}
+	for _, sc := range scopes {
+		if !model.IsValidScope(sc) {
+			return fmt.Errorf(
+				"%w: unknown scope %q",
+				model.ErrInvalidAPIKeyParams,
+				sc,
+			)
+		}
+	}
+
+	if !expiresAt.After(now) || expiresAt.After(now.Add(MaxAPIKeyTTL)) {
+		return fmt.Errorf(
+			"%w: expires_at must be in the future and within %d days",
+			model.ErrInvalidAPIKeyParams,
+			int(MaxAPIKeyTTL.Hours()/24),
+		)
+	}
+
+	return nil
+}
+
+func dedupScopes(scopes []string) []string {
+	out := make([]string, 0, len(scopes))
+	for _, sc := range scopes {
+		if !slices.Contains(out, sc) {
+			out = append(out, sc)
+		}
+	}
+	return out
+}
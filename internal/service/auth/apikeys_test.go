@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/auth/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name      string
+		keyName   string
+		scopes    []string
+		expiresAt time.Time
+		want      []string
+		wantErr   error
+	}{
+		{
+			name:    "default expiry",
+			keyName: "pos",
+			scopes: []string{
+				model.ScopeOrdersWrite,
+				model.ScopeBalanceRead,
+				model.ScopeOrdersWrite,
+			},
+			want: []string{model.ScopeOrdersWrite, model.ScopeBalanceRead},
+		},
+		{
+			name:      "explicit expiry",
+			keyName:   "pos",
+			scopes:    []string{model.ScopeOrdersWrite},
+			expiresAt: time.Now().Add(24 * time.Hour),
+			want:      []string{model.ScopeOrdersWrite},
+		},
+		{
+			name:    "empty name",
+			scopes:  []string{model.ScopeOrdersWrite},
+			wantErr: model.ErrInvalidAPIKeyParams,
+		},
+		{
+			name:    "no scopes",
+			keyName: "pos",
+			wantErr: model.ErrInvalidAPIKeyParams,
+		},
+		{
+			name:    "unknown scope",
+			keyName: "pos",
+			scopes:  []string{"admin"},
+			wantErr: model.ErrInvalidAPIKeyParams,
+		},
+		{
+			name:      "expiry in the past",
+			keyName:   "pos",
+			scopes:    []string{model.ScopeOrdersWrite},
+			expiresAt: time.Now().Add(-time.Minute),
+			wantErr:   model.ErrInvalidAPIKeyParams,
+		},
+		{
+			name:      "expiry too far",
+			keyName:   "pos",
+			scopes:    []string{model.ScopeOrdersWrite},
+			expiresAt: time.Now().Add(MaxAPIKeyTTL + time.Hour),
+			wantErr:   model.ErrInvalidAPIKeyParams,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockAPIKeysRepository(ctrl)
+			svc := NewAPIKeyService(repo)
+
+			var stored *model.APIKey
+			if tt.wantErr == nil {
+				repo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, k *model.APIKey) error {
+						stored = k
+						k.ID = 3
+						return nil
+					})
+			}
+
+			k, raw, err := svc.CreateAPIKey(
+				context.Background(),
+				7,
+				tt.keyName,
+				tt.scopes,
+				tt.expiresAt,
+			)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.True(t, strings.HasPrefix(raw, model.APIKeyPrefix))
+			assert.True(t, strings.HasPrefix(raw, k.Prefix))
+			assert.Equal(t, hashToken(raw), stored.KeyHash)
+			assert.NotContains(t, stored.KeyHash, raw)
+			assert.Equal(t, 7, k.UserID)
+			assert.Equal(t, tt.want, k.Scopes)
+			if tt.expiresAt.IsZero() {
+				assert.WithinDuration(
+					t,
+					time.Now().Add(DefaultAPIKeyTTL),
+					k.ExpiresAt,
+					time.Second,
+				)
+			} else {
+				assert.Equal(t, tt.expiresAt, k.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_VerifyAPIKey(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	const raw = "gm_secret"
+	recent := time.Now().Add(-10 * time.Second)
+	stale := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		key       string
+		prepare   func(*mocks.MockAPIKeysRepository)
+		wantErr   error
+		wantScope []string
+	}{
+		{
+			name:    "not an api key",
+			key:     "eyJhbGciOi",
+			wantErr: model.ErrInvalidAPIKey,
+		},
+		{
+			name: "unknown key",
+			key:  raw,
+			prepare: func(r *mocks.MockAPIKeysRepository) {
+				r.EXPECT().GetAPIKeyByHash(gomock.Any(), hashToken(raw)).
+					Return(nil, model.ErrAPIKeyNotFound)
+			},
+			wantErr: model.ErrInvalidAPIKey,
+		},
+		{
+			name: "storage error",
+			key:  raw,
+			prepare: func(r *mocks.MockAPIKeysRepository) {
+				r.EXPECT().GetAPIKeyByHash(gomock.Any(), hashToken(raw)).
+					Return(nil, errDBDown)
+			},
+			wantErr: errDBDown,
+		},
+		{
+			name: "expired",
+			key:  raw,
+			prepare: func(r *mocks.MockAPIKeysRepository) {
+				r.EXPECT().GetAPIKeyByHash(gomock.Any(), hashToken(raw)).
+					Return(&model.APIKey{
+						ID:        3,
+						UserID:    7,
+						ExpiresAt: time.Now().Add(-time.Second),
+					}, nil)
+			},
+			wantErr: model.ErrInvalidAPIKey,
+		},
+		{
+			name: "account closed",
+			key:  raw,
+			prepare: func(r *mocks.MockAPIKeysRepository) {
+				r.EXPECT().GetAPIKeyByHash(gomock.Any(), hashToken(raw)).
+					Return(&model.APIKey{
+						ID:         3,
+						UserID:     7,
+						ExpiresAt:  time.Now().Add(time.Hour),
+						UserStatus: model.UserStatusClosed,
+					}, nil)
+			},
+			wantErr: model.ErrAccountClosed,
+		},
+		{
+			name: "valid, last use updated",
+			key:  raw,
+			prepare: func(r *mocks.MockAPIKeysRepository) {
+				r.EXPECT().GetAPIKeyByHash(gomock.Any(), hashToken(raw)).
+					Return(&model.APIKey{
+						ID:         3,
+						UserID:     7,
+						Scopes:     []string{model.ScopeOrdersWrite},
+						ExpiresAt:  time.Now().Add(time.Hour),
+						LastUsedAt: &stale,
+					}, nil)
+				r.EXPECT().TouchAPIKey(gomock.Any(), 3).
+					Return(errors.New("db down"))
+			},
+			wantScope: []string{model.ScopeOrdersWrite},
+		},
+		{
+			name: "valid, recently used",
+			key:  raw,
+			prepare: func(r *mocks.MockAPIKeysRepository) {
+				r.EXPECT().GetAPIKeyByHash(gomock.Any(), hashToken(raw)).
+					Return(&model.APIKey{
+						ID:         3,
+						UserID:     7,
+						Scopes:     []string{model.ScopeBalanceRead},
+						ExpiresAt:  time.Now().Add(time.Hour),
+						LastUsedAt: &recent,
+					}, nil)
+			},
+			wantScope: []string{model.ScopeBalanceRead},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockAPIKeysRepository(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(repo)
+			}
+
+			p, err := NewAPIKeyService(repo).VerifyAPIKey(
+				context.Background(),
+				tt.key,
+			)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, 7, p.UserID)
+			assert.Equal(t, 3, p.APIKeyID)
+			assert.Equal(t, tt.wantScope, p.Scopes)
+			assert.True(t, p.HasScope(tt.wantScope[0]))
+			assert.False(t, p.HasScope(model.ScopeBalanceWithdraw))
+		})
+	}
+}
@@ -2,84 +2,441 @@ package auth
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"github.com/fragpit/gophermart/internal/model"
+	"go.opentelemetry.io/otel"
 )
 
+const refreshTokenBytes = 32
+
+var tracer = otel.Tracer("github.com/fragpit/gophermart/internal/service/auth")
+
 // var _ handlers.AuthService = (*AuthService)(nil)
 
 type AuthService struct {
-	repo model.UsersRepository
+	repo     model.UsersRepository
+	sessions model.SessionsRepository
+
+	jwt        JWTConfig
+	refreshTTL time.Duration
+	hasher     *PasswordHasher
+	policy     *PasswordPolicy
+
+	throttle       model.LoginThrottleRepository
+	throttlePolicy LoginThrottle
 
-	jwtSecret string
-	jwtTTL    time.Duration
+	mfa model.MFARepository
 }
 
+type Option func(*AuthService)
+
 func NewAuthService(
 	repo model.UsersRepository,
-	jwtSecret string,
-	jwtTTL time.Duration,
+	sessions model.SessionsRepository,
+	jwt JWTConfig,
+	refreshTTL time.Duration,
+	opts ...Option,
 ) *AuthService {
-	return &AuthService{
-		repo:      repo,
-		jwtSecret: jwtSecret,
-		jwtTTL:    jwtTTL,
+	a := &AuthService{
+		repo:       repo,
+		sessions:   sessions,
+		jwt:        jwt,
+		refreshTTL: refreshTTL,
+		hasher:     NewPasswordHasher(DefaultArgon2Params(), 0),
+		policy:     DefaultPasswordPolicy(),
+	}
+	for _, opt := range opts {
+		opt(a)
 	}
+	return a
 }
 
 func (a *AuthService) Register(
 	ctx context.Context,
 	login, password string,
-) (string, error) {
-	if _, err := a.repo.GetByLogin(ctx, login); err == nil {
-		return "", model.ErrUserExists
-	}
+) (*model.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Register")
+	defer span.End()
 
-	if err := model.ValidatePassword(password); err != nil {
-		return "", model.ErrPasswordPolicyViolated
+	if err := a.policy.Validate(login, password); err != nil {
+		return nil, err
 	}
 
 	u := model.NewUser(login)
-	passwordHash, err := HashPassword(password)
+	passwordHash, err := a.hasher.Hash(password)
 	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 	u.PasswordHash = passwordHash
 
 	u, err = a.repo.Create(ctx, u)
+	if errors.Is(err, model.ErrUserExists) {
+		return nil, err
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to create user: %w", err)
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
 	slog.Info("user created", slog.Int("user_id", u.ID))
 
-	token, err := CreateJWTToken(a.jwtSecret, a.jwtTTL, u.ID)
+	return a.issueTokens(ctx, u)
+}
+
+// Login проверяет пароль. clientIP учитывается в ограничении перебора
+// паролей, пустой - ограничение только по логину. При подключённом втором
+// факторе токены не выдаются: MFAChallengeError с токеном для LoginMFA.
+func (a *AuthService) Login(
+	ctx context.Context,
+	login, password, clientIP string,
+) (*model.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	keys := a.throttleKeys(login, clientIP)
+	if err := a.checkThrottle(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	u, err := a.repo.GetByLogin(ctx, login)
+	if err != nil && !errors.Is(err, model.ErrUserNotFound) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// для неизвестного логина пароль сравнивается с фиктивным хешем: ответ
+	// и время ответа те же, что при неверном пароле
+	hash := a.hasher.Dummy()
+	if u != nil {
+		hash = u.PasswordHash
+	}
+	ok, needsRehash, err := a.hasher.Verify(password, hash)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
+	}
+	if !ok || u == nil {
+		a.loginFailed(ctx, keys)
+		return nil, model.ErrInvalidCredentials
+	}
+
+	// статус проверяется после пароля: без него закрытость аккаунта не
+	// раскрывается
+	if u.Status == model.UserStatusClosed {
+		return nil, model.ErrAccountClosed
 	}
 
-	return token, nil
+	if needsRehash {
+		a.rehashPassword(ctx, u, password)
+	}
+
+	if a.mfa != nil {
+		m, err := a.mfa.GetMFA(ctx, u.ID)
+		if err != nil && !errors.Is(err, model.ErrMFANotEnrolled) {
+			return nil, fmt.Errorf("failed to get mfa: %w", err)
+		}
+		if m.Enabled() {
+			// счётчик неудач не сбрасывается до кода второго фактора: иначе
+			// повторный Login снимал бы ограничение перебора кодов
+			return nil, a.newMFAChallenge(ctx, u.ID)
+		}
+	}
+
+	a.loginSucceeded(ctx, keys)
+
+	return a.issueTokens(ctx, u)
 }
 
-func (a *AuthService) Login(
+// ChangePassword меняет пароль после проверки текущего и отзывает все
+// сессии пользователя; вызывающий клиент получает новую пару токенов.
+// Неверный текущий пароль учитывается в ограничении перебора паролей.
+func (a *AuthService) ChangePassword(
 	ctx context.Context,
-	login, password string,
-) (string, error) {
-	u, err := a.repo.GetByLogin(ctx, login)
+	p *model.Principal,
+	current, next string,
+) (*model.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ChangePassword")
+	defer span.End()
+
+	u, err := a.verifyPassword(ctx, p.UserID, current)
 	if err != nil {
-		return "", model.ErrUserNotFound
+		return nil, err
+	}
+
+	if err := a.policy.Validate(u.Login, next); err != nil {
+		return nil, err
+	}
+	if next == current {
+		return nil, &model.PasswordPolicyError{
+			Violations: []model.PasswordViolation{{
+				Rule:    "unchanged",
+				Message: "new password must differ from the current one",
+			}},
+		}
 	}
 
-	if ok := ComparePasswordHash(password, u.PasswordHash); !ok {
-		return "", model.ErrInvalidCredentials
+	hash, err := a.hasher.Hash(next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	token, err := CreateJWTToken(a.jwtSecret, a.jwtTTL, u.ID)
+	u, err = a.repo.ChangePassword(ctx, u.ID, hash)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return nil, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	slog.Info(
+		"password changed, user sessions revoked",
+		slog.Int("user_id", u.ID),
+	)
+
+	return a.issueTokens(ctx, u)
+}
+
+// verifyPassword проверяет пароль аутентифицированного пользователя перед
+// чувствительной операцией. Неверный пароль учитывается в ограничении
+// перебора паролей по логину.
+func (a *AuthService) verifyPassword(
+	ctx context.Context,
+	userID int,
+	password string,
+) (*model.User, error) {
+	u, err := a.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	keys := a.throttleKeys(u.Login, "")
+	if err := a.checkThrottle(ctx, keys); err != nil {
+		return nil, err
 	}
-	return token, nil
+
+	ok, _, err := a.hasher.Verify(password, u.PasswordHash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		a.loginFailed(ctx, keys)
+		return nil, model.ErrInvalidCredentials
+	}
+
+	return u, nil
+}
+
+// rehashPassword пересчитывает устаревший хеш (bcrypt или argon2id с
+// прежними параметрами) при входе, пока пароль известен. Ошибка не мешает
+// входу: хеш обновится при следующем. Пароль, сменённый за время входа,
+// не перезаписывается старым.
+func (a *AuthService) rehashPassword(
+	ctx context.Context,
+	u *model.User,
+	password string,
+) {
+	hash, err := a.hasher.Hash(password)
+	if err != nil {
+		slog.Error("failed to rehash password", slog.Any("error", err))
+		return
+	}
+
+	if err := a.repo.UpdatePasswordHash(
+		ctx,
+		u.ID,
+		u.PasswordHash,
+		hash,
+	); err != nil {
+		slog.Error(
+			"failed to update password hash",
+			slog.Int("user_id", u.ID),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	slog.Info("password hash upgraded", slog.Int("user_id", u.ID))
+}
+
+// Refresh обменивает refresh токен на новую пару. Старый токен
+// становится недействительным; его повторное предъявление отзывает всю
+// цепочку.
+func (a *AuthService) Refresh(
+	ctx context.Context,
+	refreshToken string,
+) (*model.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Refresh")
+	defer span.End()
+
+	if refreshToken == "" {
+		return nil, model.ErrInvalidRefreshToken
+	}
+
+	next, raw, err := a.newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := a.sessions.RotateRefreshToken(
+		ctx,
+		hashToken(refreshToken),
+		next,
+	)
+	if err != nil {
+		if errors.Is(err, model.ErrRefreshTokenReused) {
+			slog.Warn("refresh token reuse detected, session revoked")
+		}
+		return nil, err
+	}
+
+	access, err := CreateJWTToken(a.jwt, u.ID, u.TokenVersion, u.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &model.TokenPair{
+		AccessToken:  access,
+		RefreshToken: raw,
+		ExpiresIn:    a.jwt.TTL,
+	}, nil
+}
+
+// Logout отзывает текущий access токен и цепочку переданного refresh
+// токена, all - все сессии пользователя.
+func (a *AuthService) Logout(
+	ctx context.Context,
+	p *model.Principal,
+	refreshToken string,
+	all bool,
+) error {
+	ctx, span := tracer.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
+	if all {
+		if err := a.sessions.RevokeUserSessions(ctx, p.UserID); err != nil {
+			return fmt.Errorf("failed to revoke user sessions: %w", err)
+		}
+		slog.Info("all user sessions revoked", slog.Int("user_id", p.UserID))
+		return nil
+	}
+
+	if p.TokenID != "" {
+		if err := a.sessions.RevokeAccessToken(
+			ctx,
+			p.TokenID,
+			p.ExpiresAt,
+		); err != nil {
+			return fmt.Errorf("failed to revoke access token: %w", err)
+		}
+	}
+
+	if refreshToken != "" {
+		if err := a.sessions.RevokeRefreshToken(
+			ctx,
+			p.UserID,
+			hashToken(refreshToken),
+		); err != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// VerifyAccessToken проверяет подпись, срок действия и отзыв access
+// токена.
+func (a *AuthService) VerifyAccessToken(
+	ctx context.Context,
+	token string,
+) (*model.Principal, error) {
+	claims, err := ParseJWTToken(a.jwt, token)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, status, err := a.sessions.IsAccessTokenValid(
+		ctx,
+		claims.UserID(),
+		claims.ID,
+		claims.TokenVersion,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if !ok {
+		return nil, model.NewAuthError(model.AuthRevoked, model.ErrTokenRevoked)
+	}
+	// закрытие отзывает сессии, проверка статуса - на случай токена,
+	// выданного до закрытия в той же версии
+	if status == model.UserStatusClosed {
+		return nil, model.NewAuthError(model.AuthRevoked, model.ErrAccountClosed)
+	}
+
+	p := &model.Principal{
+		UserID:  claims.UserID(),
+		TokenID: claims.ID,
+		Roles:   claims.Roles,
+		Status:  status,
+	}
+	if claims.ExpiresAt != nil {
+		p.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return p, nil
+}
+
+// issueTokens выдаёт пару токенов при входе, refresh токен начинает новую
+// цепочку.
+func (a *AuthService) issueTokens(
+	ctx context.Context,
+	u *model.User,
+) (*model.TokenPair, error) {
+	access, err := CreateJWTToken(a.jwt, u.ID, u.TokenVersion, u.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	rt, raw, err := a.newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	rt.UserID = u.ID
+
+	if err := a.sessions.CreateRefreshToken(ctx, rt); err != nil {
+		return nil, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return &model.TokenPair{
+		AccessToken:  access,
+		RefreshToken: raw,
+		ExpiresIn:    a.jwt.TTL,
+	}, nil
+}
+
+// newRefreshToken генерирует refresh токен в новой цепочке. Возвращает
+// запись для хранения и сам токен, который отдаётся клиенту.
+func (a *AuthService) newRefreshToken() (*model.RefreshToken, string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate token family: %w", err)
+	}
+
+	return &model.RefreshToken{
+		FamilyID:  familyID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(a.refreshTTL),
+	}, raw, nil
+}
+
+// hashToken refresh токен случайный и длинный, поэтому достаточно sha256.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
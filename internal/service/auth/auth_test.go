@@ -4,15 +4,20 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fragpit/gophermart/internal/model"
 	mocks "github.com/fragpit/gophermart/internal/service/auth/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
 )
 
+var errDBDown = errors.New("db down")
+
 func TestAuthService_Register(t *testing.T) {
 	slog.SetDefault(slog.New(slog.DiscardHandler))
 
@@ -24,25 +29,24 @@ func TestAuthService_Register(t *testing.T) {
 		name      string
 		args      args
 		prepare   func(*mocks.MockUsersRepository, context.Context, args)
+		sessions  func(*mocks.MockSessionsRepository)
 		wantErr   error
 		wantToken bool
 	}{
 		{
 			name: "user already exists",
-			args: args{"user", "pass"},
+			args: args{"user", "valid_password"},
 			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
-				r.EXPECT().GetByLogin(ctx, a.login).Return(&model.User{ID: 1}, nil)
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).
+					Return(nil, model.ErrUserExists)
 			},
 			wantErr:   model.ErrUserExists,
 			wantToken: false,
 		},
 		{
-			name: "invalid password policy",
-			args: args{"user", "1"},
-			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
-				r.EXPECT().GetByLogin(ctx, a.login).
-					Return(nil, errors.New("not found"))
-			},
+			name:      "invalid password policy",
+			args:      args{"user", "1"},
+			prepare:   func(r *mocks.MockUsersRepository, ctx context.Context, a args) {},
 			wantErr:   model.ErrPasswordPolicyViolated,
 			wantToken: false,
 		},
@@ -50,11 +54,18 @@ func TestAuthService_Register(t *testing.T) {
 			name: "create user and token",
 			args: args{"user", "valid_password"},
 			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
-				r.EXPECT().GetByLogin(ctx, a.login).
-					Return(nil, errors.New("not found"))
-				r.EXPECT().Create(ctx, gomock.Any()).
+				r.EXPECT().Create(gomock.Any(), gomock.Any()).
 					Return(&model.User{ID: 42, Login: a.login}, nil)
 			},
+			sessions: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, rt *model.RefreshToken) error {
+						assert.Equal(t, 42, rt.UserID)
+						assert.NotEmpty(t, rt.FamilyID)
+						assert.Len(t, rt.TokenHash, 64)
+						return nil
+					})
+			},
 			wantErr:   nil,
 			wantToken: true,
 		},
@@ -66,20 +77,25 @@ func TestAuthService_Register(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockUsersRepository(ctrl)
+			sessions := mocks.NewMockSessionsRepository(ctrl)
 			ctx := context.Background()
-			svc := NewAuthService(repo, "secret", time.Minute)
+			svc := NewAuthService(repo, sessions, testJWT(t), time.Hour)
 
 			tt.prepare(repo, ctx, tt.args)
+			if tt.sessions != nil {
+				tt.sessions(sessions)
+			}
 
-			token, err := svc.Register(ctx, tt.args.login, tt.args.password)
+			tokens, err := svc.Register(ctx, tt.args.login, tt.args.password)
 
 			assert.ErrorIs(t, err, tt.wantErr)
 
 			if tt.wantToken {
-				assert.NotEmpty(t, token)
+				assert.NotEmpty(t, tokens.AccessToken)
+				assert.NotEmpty(t, tokens.RefreshToken)
 			}
 			if !tt.wantToken {
-				assert.Empty(t, token)
+				assert.Nil(t, tokens)
 			}
 		})
 	}
@@ -90,12 +106,14 @@ func TestAuthService_Login(t *testing.T) {
 		login    string
 		password string
 	}
-	hashed, _ := HashPassword("pass")
+	hashed, _ := NewPasswordHasher(DefaultArgon2Params(), 0).Hash("pass")
+	bcrypted, _ := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
 
 	tests := []struct {
 		name      string
 		args      args
 		prepare   func(*mocks.MockUsersRepository, context.Context, args)
+		sessions  func(*mocks.MockSessionsRepository)
 		wantErr   error
 		wantToken bool
 	}{
@@ -103,29 +121,78 @@ func TestAuthService_Login(t *testing.T) {
 			name: "user not found",
 			args: args{"user", "pass"},
 			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
-				r.EXPECT().GetByLogin(ctx, a.login).
-					Return(nil, errors.New("not found"))
+				r.EXPECT().GetByLogin(gomock.Any(), a.login).
+					Return(nil, model.ErrUserNotFound)
+			},
+			wantErr:   model.ErrInvalidCredentials,
+			wantToken: false,
+		},
+		{
+			name: "storage error",
+			args: args{"user", "pass"},
+			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
+				r.EXPECT().GetByLogin(gomock.Any(), a.login).
+					Return(nil, errDBDown)
 			},
-			wantErr:   model.ErrUserNotFound,
+			wantErr:   errDBDown,
 			wantToken: false,
 		},
 		{
 			name: "invalid password",
 			args: args{"user", "invalid_pass"},
 			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
-				r.EXPECT().GetByLogin(ctx, a.login).
+				r.EXPECT().GetByLogin(gomock.Any(), a.login).
 					Return(&model.User{ID: 10, Login: a.login, PasswordHash: hashed}, nil)
 			},
 			wantErr:   model.ErrInvalidCredentials,
 			wantToken: false,
 		},
+		{
+			name: "account closed",
+			args: args{"user", "pass"},
+			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
+				r.EXPECT().GetByLogin(gomock.Any(), a.login).
+					Return(&model.User{
+						ID:           7,
+						Login:        a.login,
+						PasswordHash: hashed,
+						Status:       model.UserStatusClosed,
+					}, nil)
+			},
+			wantErr:   model.ErrAccountClosed,
+			wantToken: false,
+		},
 		{
 			name: "success",
 			args: args{"user", "pass"},
 			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
-				r.EXPECT().GetByLogin(ctx, a.login).
+				r.EXPECT().GetByLogin(gomock.Any(), a.login).
 					Return(&model.User{ID: 7, Login: a.login, PasswordHash: hashed}, nil)
 			},
+			sessions: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+			},
+			wantErr:   nil,
+			wantToken: true,
+		},
+		{
+			name: "bcrypt hash upgraded",
+			args: args{"user", "pass"},
+			prepare: func(r *mocks.MockUsersRepository, ctx context.Context, a args) {
+				r.EXPECT().GetByLogin(gomock.Any(), a.login).
+					Return(&model.User{ID: 7, Login: a.login, PasswordHash: string(bcrypted)}, nil)
+				r.EXPECT().
+					UpdatePasswordHash(gomock.Any(), 7, string(bcrypted), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int, _, hash string) error {
+						assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$"))
+						return nil
+					})
+			},
+			sessions: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+			},
 			wantErr:   nil,
 			wantToken: true,
 		},
@@ -137,20 +204,383 @@ func TestAuthService_Login(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockUsersRepository(ctrl)
+			sessions := mocks.NewMockSessionsRepository(ctrl)
 			ctx := context.Background()
-			svc := NewAuthService(repo, "secret", time.Minute)
+			svc := NewAuthService(repo, sessions, testJWT(t), time.Hour)
 
 			tt.prepare(repo, ctx, tt.args)
+			if tt.sessions != nil {
+				tt.sessions(sessions)
+			}
 
-			token, err := svc.Login(ctx, tt.args.login, tt.args.password)
+			tokens, err := svc.Login(ctx, tt.args.login, tt.args.password, "")
 
 			assert.ErrorIs(t, err, tt.wantErr)
 
 			if tt.wantToken {
-				assert.NotEmpty(t, token)
+				assert.NotEmpty(t, tokens.AccessToken)
+				assert.NotEmpty(t, tokens.RefreshToken)
+			}
+			if !tt.wantToken {
+				assert.Nil(t, tokens)
+			}
+		})
+	}
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name      string
+		token     string
+		prepare   func(*mocks.MockSessionsRepository)
+		wantErr   error
+		wantToken bool
+	}{
+		{
+			name:    "empty token",
+			token:   "",
+			wantErr: model.ErrInvalidRefreshToken,
+		},
+		{
+			name:  "unknown token",
+			token: "unknown",
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().
+					RotateRefreshToken(gomock.Any(), hashToken("unknown"), gomock.Any()).
+					Return(nil, model.ErrInvalidRefreshToken)
+			},
+			wantErr: model.ErrInvalidRefreshToken,
+		},
+		{
+			name:  "reused token",
+			token: "used",
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().
+					RotateRefreshToken(gomock.Any(), hashToken("used"), gomock.Any()).
+					Return(nil, model.ErrRefreshTokenReused)
+			},
+			wantErr: model.ErrRefreshTokenReused,
+		},
+		{
+			name:  "rotated",
+			token: "valid",
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().
+					RotateRefreshToken(gomock.Any(), hashToken("valid"), gomock.Any()).
+					Return(&model.User{ID: 7, TokenVersion: 3}, nil)
+			},
+			wantToken: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessions := mocks.NewMockSessionsRepository(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(sessions)
 			}
+			svc := NewAuthService(
+				mocks.NewMockUsersRepository(ctrl),
+				sessions,
+				testJWT(t),
+				time.Hour,
+			)
+
+			tokens, err := svc.Refresh(context.Background(), tt.token)
+
+			assert.ErrorIs(t, err, tt.wantErr)
 			if !tt.wantToken {
-				assert.Empty(t, token)
+				assert.Nil(t, tokens)
+				return
+			}
+
+			assert.NotEqual(t, tt.token, tokens.RefreshToken)
+			claims, err := ParseJWTToken(testJWT(t), tokens.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, 7, claims.UserID())
+			assert.Equal(t, 3, claims.TokenVersion)
+			assert.NotEmpty(t, claims.ID)
+		})
+	}
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	p := &model.Principal{
+		UserID:    7,
+		TokenID:   "jti",
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	tests := []struct {
+		name    string
+		refresh string
+		all     bool
+		prepare func(*mocks.MockSessionsRepository)
+	}{
+		{
+			name: "access token only",
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().RevokeAccessToken(gomock.Any(), "jti", p.ExpiresAt).
+					Return(nil)
+			},
+		},
+		{
+			name:    "with refresh token",
+			refresh: "refresh",
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().RevokeAccessToken(gomock.Any(), "jti", p.ExpiresAt).
+					Return(nil)
+				s.EXPECT().RevokeRefreshToken(gomock.Any(), 7, hashToken("refresh")).
+					Return(nil)
+			},
+		},
+		{
+			name: "all sessions",
+			all:  true,
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().RevokeUserSessions(gomock.Any(), 7).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessions := mocks.NewMockSessionsRepository(ctrl)
+			tt.prepare(sessions)
+			svc := NewAuthService(
+				mocks.NewMockUsersRepository(ctrl),
+				sessions,
+				testJWT(t),
+				time.Hour,
+			)
+
+			err := svc.Logout(context.Background(), p, tt.refresh, tt.all)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_VerifyAccessToken(t *testing.T) {
+	token, err := CreateJWTToken(testJWT(t), 7, 2, []string{model.RoleAdmin})
+	assert.NoError(t, err)
+	claims, err := ParseJWTToken(testJWT(t), token)
+	assert.NoError(t, err)
+
+	expiredCfg := testJWT(t)
+	expiredCfg.TTL = -time.Minute
+	expired, err := CreateJWTToken(expiredCfg, 7, 2, nil)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		token      string
+		prepare    func(*mocks.MockSessionsRepository)
+		wantErr    error
+		wantStatus model.UserStatus
+	}{
+		{
+			name:    "bad signature",
+			token:   token + "x",
+			wantErr: model.ErrInvalidToken,
+		},
+		{
+			name:    "expired",
+			token:   expired,
+			wantErr: model.ErrInvalidToken,
+		},
+		{
+			name:  "revoked",
+			token: token,
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().IsAccessTokenValid(gomock.Any(), 7, claims.ID, 2).
+					Return(false, model.UserStatusActive, nil)
+			},
+			wantErr: model.ErrTokenRevoked,
+		},
+		{
+			name:  "account closed",
+			token: token,
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().IsAccessTokenValid(gomock.Any(), 7, claims.ID, 2).
+					Return(true, model.UserStatusClosed, nil)
+			},
+			wantErr: model.ErrAccountClosed,
+		},
+		{
+			name:  "valid",
+			token: token,
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().IsAccessTokenValid(gomock.Any(), 7, claims.ID, 2).
+					Return(true, model.UserStatusActive, nil)
+			},
+		},
+		{
+			name:  "suspended keeps access",
+			token: token,
+			prepare: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().IsAccessTokenValid(gomock.Any(), 7, claims.ID, 2).
+					Return(true, model.UserStatusSuspended, nil)
+			},
+			wantStatus: model.UserStatusSuspended,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessions := mocks.NewMockSessionsRepository(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(sessions)
+			}
+			svc := NewAuthService(
+				mocks.NewMockUsersRepository(ctrl),
+				sessions,
+				testJWT(t),
+				time.Hour,
+			)
+
+			p, err := svc.VerifyAccessToken(context.Background(), tt.token)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, 7, p.UserID)
+				assert.Equal(t, claims.ID, p.TokenID)
+				assert.True(t, p.HasRole(model.RoleAdmin))
+				if tt.wantStatus != "" {
+					assert.Equal(t, tt.wantStatus, p.Status)
+				}
+			}
+		})
+	}
+}
+
+func testJWT(t *testing.T) JWTConfig {
+	t.Helper()
+
+	ks, err := NewKeySet(NewHMACKey("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return JWTConfig{
+		Keys:     ks,
+		Issuer:   "gophermart",
+		Audience: "gophermart",
+		TTL:      time.Minute,
+	}
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	hasher := NewPasswordHasher(testArgon2Params(), 0)
+	hashed, _ := hasher.Hash("current_password")
+	p := &model.Principal{UserID: 7, TokenID: "jti"}
+	user := &model.User{ID: 7, Login: "alice", PasswordHash: hashed}
+
+	tests := []struct {
+		name      string
+		current   string
+		next      string
+		prepare   func(*mocks.MockUsersRepository, *mocks.MockSessionsRepository)
+		wantErr   error
+		wantRules []string
+	}{
+		{
+			name:    "wrong current password",
+			current: "wrong_password",
+			next:    "new_password_42",
+			prepare: func(r *mocks.MockUsersRepository, _ *mocks.MockSessionsRepository) {
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+			},
+			wantErr: model.ErrInvalidCredentials,
+		},
+		{
+			name:    "policy violated",
+			current: "current_password",
+			next:    "alice",
+			prepare: func(r *mocks.MockUsersRepository, _ *mocks.MockSessionsRepository) {
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+			},
+			wantErr:   model.ErrPasswordPolicyViolated,
+			wantRules: []string{"length", "contains_login"},
+		},
+		{
+			name:    "same password",
+			current: "current_password",
+			next:    "current_password",
+			prepare: func(r *mocks.MockUsersRepository, _ *mocks.MockSessionsRepository) {
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+			},
+			wantErr:   model.ErrPasswordPolicyViolated,
+			wantRules: []string{"unchanged"},
+		},
+		{
+			name:    "changed, sessions revoked",
+			current: "current_password",
+			next:    "new_password_42",
+			prepare: func(r *mocks.MockUsersRepository, s *mocks.MockSessionsRepository) {
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				r.EXPECT().ChangePassword(gomock.Any(), 7, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ int, hash string) (*model.User, error) {
+						ok, _, _ := hasher.Verify("new_password_42", hash)
+						assert.True(t, ok)
+						return &model.User{ID: 7, Login: "alice", TokenVersion: 3}, nil
+					})
+				s.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			repo := mocks.NewMockUsersRepository(ctrl)
+			sessions := mocks.NewMockSessionsRepository(ctrl)
+			tt.prepare(repo, sessions)
+			svc := NewAuthService(
+				repo,
+				sessions,
+				testJWT(t),
+				time.Hour,
+				WithPasswordHasher(hasher),
+			)
+
+			tokens, err := svc.ChangePassword(
+				context.Background(),
+				p,
+				tt.current,
+				tt.next,
+			)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != nil {
+				assert.Nil(t, tokens)
+			}
+
+			if tt.wantRules != nil {
+				var policyErr *model.PasswordPolicyError
+				require.ErrorAs(t, err, &policyErr)
+				var rules []string
+				for _, v := range policyErr.Violations {
+					rules = append(rules, v.Rule)
+				}
+				assert.Equal(t, tt.wantRules, rules)
+			}
+
+			if tt.wantErr == nil {
+				claims, err := ParseJWTToken(testJWT(t), tokens.AccessToken)
+				require.NoError(t, err)
+				assert.Equal(t, 3, claims.TokenVersion)
 			}
 		})
 	}
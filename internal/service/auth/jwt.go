@@ -1,31 +1,99 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
+	"github.com/fragpit/gophermart/internal/model"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// JWTConfig параметры выпуска и проверки access токенов.
+type JWTConfig struct {
+	Keys     *KeySet
+	Issuer   string
+	Audience string
+	TTL      time.Duration
+	// Leeway допустимое расхождение часов при проверке exp, nbf и iat.
+	Leeway time.Duration
+	// LegacyUntil до этого момента принимаются токены старого формата
+	// (UserID без iss, aud и sub), нулевое значение - не принимаются.
+	LegacyUntil time.Time
+
+	now func() time.Time
+}
+
+func (c JWTConfig) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
 type Claims struct {
 	jwt.RegisteredClaims
-	UserID int
+	// TokenVersion версия токенов пользователя на момент выдачи.
+	TokenVersion int `json:"ver"`
+	// LegacyUserID идентификатор пользователя в токенах старого формата.
+	LegacyUserID int `json:"UserID,omitempty"`
+	// Roles роли пользователя на момент выдачи. При их смене версия
+	// токенов увеличивается, и токены с прежними ролями отклоняются.
+	Roles []string `json:"roles,omitempty"`
+}
+
+// UserID идентификатор пользователя из sub, для токенов старого
+// формата - из UserID.
+func (c *Claims) UserID() int {
+	if c.Subject == "" {
+		return c.LegacyUserID
+	}
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return 0
+	}
+	return id
 }
 
+func (c *Claims) isLegacy() bool {
+	return c.Subject == "" && c.Issuer == "" && len(c.Audience) == 0 &&
+		c.LegacyUserID > 0
+}
+
+// CreateJWTToken выпускает access токен со случайным jti.
 func CreateJWTToken(
-	secret string,
-	ttl time.Duration,
+	cfg JWTConfig,
 	userID int,
+	version int,
+	roles []string,
 ) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate jti: %w", err)
+	}
+
+	now := cfg.timeNow()
+	claims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			ID:        jti,
+			Issuer:    cfg.Issuer,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.TTL)),
 		},
-		UserID: userID,
-	})
+		TokenVersion: version,
+		Roles:        roles,
+	}
+	if cfg.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{cfg.Audience}
+	}
 
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err := cfg.Keys.Sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -33,22 +101,91 @@ func CreateJWTToken(
 	return tokenString, nil
 }
 
-func GetUserIDFromJWTToken(secret string, tokenString string) (int, error) {
+// ParseJWTToken проверяет подпись, exp/nbf/iat с учётом Leeway, iss, aud
+// и sub. Ошибки - *model.AuthError.
+func ParseJWTToken(cfg JWTConfig, tokenString string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(
+	token, err := cfg.Keys.Parse(
 		tokenString,
 		claims,
-		func(t *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		},
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(cfg.timeNow),
 	)
 	if err != nil {
-		return 0, errors.New("failed to parse token")
+		return nil, classifyJWTError(err)
 	}
 
 	if !token.Valid {
-		return 0, errors.New("invalid token")
+		return nil, model.NewAuthError(model.AuthMalformed, nil)
+	}
+
+	if err := validateClaims(cfg, claims); err != nil {
+		return nil, err
 	}
 
-	return claims.UserID, nil
+	return claims, nil
+}
+
+func validateClaims(cfg JWTConfig, claims *Claims) error {
+	if claims.isLegacy() {
+		if cfg.LegacyUntil.IsZero() || !cfg.timeNow().Before(cfg.LegacyUntil) {
+			return model.NewAuthError(
+				model.AuthInvalidClaims,
+				errors.New("legacy token format is no longer accepted"),
+			)
+		}
+		return nil
+	}
+
+	if claims.Issuer != cfg.Issuer {
+		return model.NewAuthError(
+			model.AuthInvalidClaims,
+			fmt.Errorf("unexpected issuer %q", claims.Issuer),
+		)
+	}
+	if cfg.Audience != "" && !slices.Contains(claims.Audience, cfg.Audience) {
+		return model.NewAuthError(
+			model.AuthInvalidClaims,
+			fmt.Errorf("token audience %v does not include %q",
+				[]string(claims.Audience), cfg.Audience),
+		)
+	}
+	if id, err := strconv.Atoi(claims.Subject); err != nil || id <= 0 {
+		return model.NewAuthError(
+			model.AuthInvalidClaims,
+			fmt.Errorf("invalid subject %q", claims.Subject),
+		)
+	}
+
+	return nil
+}
+
+func classifyJWTError(err error) error {
+	var kind model.AuthErrorKind
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		kind = model.AuthExpired
+	case errors.Is(err, jwt.ErrTokenNotValidYet),
+		errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		kind = model.AuthNotYetValid
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid),
+		errors.Is(err, jwt.ErrTokenUnverifiable):
+		kind = model.AuthBadSignature
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing),
+		errors.Is(err, jwt.ErrTokenInvalidClaims):
+		kind = model.AuthInvalidClaims
+	default:
+		kind = model.AuthMalformed
+	}
+	return model.NewAuthError(kind, err)
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
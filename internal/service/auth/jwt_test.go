@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateJWTToken_Claims(t *testing.T) {
+	cfg := testJWT(t)
+	token, err := CreateJWTToken(cfg, 7, 3, []string{model.RoleSupport})
+	require.NoError(t, err)
+
+	claims, err := ParseJWTToken(cfg, token)
+	require.NoError(t, err)
+
+	assert.Equal(t, "7", claims.Subject)
+	assert.Equal(t, 7, claims.UserID())
+	assert.Equal(t, "gophermart", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"gophermart"}, claims.Audience)
+	assert.Equal(t, 3, claims.TokenVersion)
+	assert.Equal(t, []string{model.RoleSupport}, claims.Roles)
+	assert.NotEmpty(t, claims.ID)
+	assert.NotNil(t, claims.IssuedAt)
+	assert.NotNil(t, claims.NotBefore)
+	assert.Zero(t, claims.LegacyUserID)
+}
+
+func TestParseJWTToken_Errors(t *testing.T) {
+	now := time.Now()
+	at := func(ts time.Time) func() time.Time {
+		return func() time.Time { return ts }
+	}
+
+	sign := func(t *testing.T, mod func(*JWTConfig)) string {
+		t.Helper()
+		cfg := testJWT(t)
+		if mod != nil {
+			mod(&cfg)
+		}
+		token, err := CreateJWTToken(cfg, 7, 1, nil)
+		require.NoError(t, err)
+		return token
+	}
+
+	otherKeys, err := NewKeySet(NewHMACKey("other"), nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		token    string
+		leeway   time.Duration
+		wantKind model.AuthErrorKind
+		wantOK   bool
+	}{
+		{
+			name: "wrong issuer",
+			token: sign(t, func(c *JWTConfig) {
+				c.Issuer = "someone-else"
+			}),
+			wantKind: model.AuthInvalidClaims,
+		},
+		{
+			name: "wrong audience",
+			token: sign(t, func(c *JWTConfig) {
+				c.Audience = "accrual"
+			}),
+			wantKind: model.AuthInvalidClaims,
+		},
+		{
+			name: "expired",
+			token: sign(t, func(c *JWTConfig) {
+				c.TTL = -time.Minute
+			}),
+			wantKind: model.AuthExpired,
+		},
+		{
+			name: "expired within leeway",
+			token: sign(t, func(c *JWTConfig) {
+				c.TTL = -10 * time.Second
+			}),
+			leeway: 30 * time.Second,
+			wantOK: true,
+		},
+		{
+			name: "issued in future",
+			token: sign(t, func(c *JWTConfig) {
+				c.now = at(now.Add(2 * time.Minute))
+			}),
+			leeway:   30 * time.Second,
+			wantKind: model.AuthNotYetValid,
+		},
+		{
+			name: "issued in future within leeway",
+			token: sign(t, func(c *JWTConfig) {
+				c.now = at(now.Add(10 * time.Second))
+			}),
+			leeway: 30 * time.Second,
+			wantOK: true,
+		},
+		{
+			name: "bad signature",
+			token: sign(t, func(c *JWTConfig) {
+				c.Keys = otherKeys
+			}),
+			wantKind: model.AuthBadSignature,
+		},
+		{
+			name:     "malformed",
+			token:    "not-a-jwt",
+			wantKind: model.AuthMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testJWT(t)
+			cfg.Leeway = tt.leeway
+
+			claims, err := ParseJWTToken(cfg, tt.token)
+			if tt.wantOK {
+				require.NoError(t, err)
+				assert.Equal(t, 7, claims.UserID())
+				return
+			}
+
+			require.Error(t, err)
+			assert.ErrorIs(t, err, model.ErrInvalidToken)
+			kind, ok := model.AuthErrorKindOf(err)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantKind, kind)
+		})
+	}
+}
+
+func TestParseJWTToken_InvalidSubject(t *testing.T) {
+	cfg := testJWT(t)
+	token, err := cfg.Keys.Sign(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    cfg.Issuer,
+			Audience:  jwt.ClaimStrings{cfg.Audience},
+			Subject:   "admin",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = ParseJWTToken(cfg, token)
+	kind, ok := model.AuthErrorKindOf(err)
+	require.True(t, ok)
+	assert.Equal(t, model.AuthInvalidClaims, kind)
+}
+
+func TestParseJWTToken_Legacy(t *testing.T) {
+	now := time.Now()
+	cfg := testJWT(t)
+
+	// токен в формате до введения iss/aud/sub
+	token, err := cfg.Keys.Sign(jwt.MapClaims{
+		"UserID": 7,
+		"exp":    now.Add(time.Minute).Unix(),
+	})
+	require.NoError(t, err)
+
+	t.Run("rejected by default", func(t *testing.T) {
+		_, err := ParseJWTToken(cfg, token)
+		kind, ok := model.AuthErrorKindOf(err)
+		require.True(t, ok)
+		assert.Equal(t, model.AuthInvalidClaims, kind)
+	})
+
+	t.Run("accepted within migration window", func(t *testing.T) {
+		legacy := cfg
+		legacy.LegacyUntil = now.Add(time.Hour)
+
+		claims, err := ParseJWTToken(legacy, token)
+		require.NoError(t, err)
+		assert.Equal(t, 7, claims.UserID())
+		assert.Zero(t, claims.TokenVersion)
+	})
+
+	t.Run("rejected after migration window", func(t *testing.T) {
+		legacy := cfg
+		legacy.LegacyUntil = now.Add(-time.Second)
+
+		_, err := ParseJWTToken(legacy, token)
+		assert.ErrorIs(t, err, model.ErrInvalidToken)
+	})
+}
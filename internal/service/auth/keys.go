@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+var (
+	ErrUnknownKey     = errors.New("unknown signing key")
+	ErrKeyRetired     = errors.New("signing key retired")
+	ErrAlgMismatch    = errors.New("token algorithm does not match key")
+	ErrNoPrivateKey   = errors.New("signing key has no private part")
+	ErrUnsupportedPEM = errors.New("unsupported pem key")
+)
+
+// SigningKey ключ подписи токенов. Алгоритм определяется типом ключа:
+// RSA - RS256, Ed25519 - EdDSA, секрет - HS256. Ключ без приватной части
+// (PUBLIC KEY) годится только для проверки.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	sign   any
+	verify any
+}
+
+// NewHMACKey ключ HS256 с пустым kid для токенов, выпущенных до перехода
+// на асимметричную подпись.
+func NewHMACKey(secret string) *SigningKey {
+	return &SigningKey{
+		Method: jwt.SigningMethodHS256,
+		sign:   []byte(secret),
+		verify: []byte(secret),
+	}
+}
+
+// LoadPEMKey читает RSA или Ed25519 ключ в PEM (PKCS#8, PKCS#1 или
+// PKIX для публичных ключей).
+func LoadPEMKey(kid, path string) (*SigningKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+	return ParsePEMKey(kid, b)
+}
+
+func ParsePEMKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no pem block", ErrUnsupportedPEM)
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: block %q", ErrUnsupportedPEM, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %q: %w", kid, err)
+	}
+
+	k := &SigningKey{ID: kid}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		k.Method, k.sign, k.verify = jwt.SigningMethodRS256, key, &key.PublicKey
+	case *rsa.PublicKey:
+		k.Method, k.verify = jwt.SigningMethodRS256, key
+	case ed25519.PrivateKey:
+		k.Method, k.sign, k.verify = jwt.SigningMethodEdDSA, key, key.Public()
+	case ed25519.PublicKey:
+		k.Method, k.verify = jwt.SigningMethodEdDSA, key
+	default:
+		return nil, fmt.Errorf("%w: key type %T", ErrUnsupportedPEM, key)
+	}
+
+	if pub, ok := k.verify.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf(
+			"rsa key %q is too short: %d bits",
+			kid,
+			pub.N.BitLen(),
+		)
+	}
+
+	return k, nil
+}
+
+// PreviousKey прежний ключ, по которому ещё принимаются выпущенные им
+// токены. С RetireAt токены с его kid отклоняются; нулевой RetireAt - ключ
+// принимается, пока его не уберут из набора.
+type PreviousKey struct {
+	Key      *SigningKey
+	RetireAt time.Time
+}
+
+// KeySet активный ключ подписи и прежние ключи, по которым ещё принимаются
+// ранее выпущенные токены. Время вывода ключа абсолютное и не зависит от
+// перезапусков.
+type KeySet struct {
+	now      func() time.Time
+	active   *SigningKey
+	keys     map[string]*SigningKey
+	retireAt map[string]time.Time
+}
+
+// NewKeySet active подписывает новые токены, previous принимаются до
+// своего RetireAt.
+func NewKeySet(
+	active *SigningKey,
+	previous []PreviousKey,
+) (*KeySet, error) {
+	return newKeySet(active, previous, time.Now)
+}
+
+func newKeySet(
+	active *SigningKey,
+	previous []PreviousKey,
+	now func() time.Time,
+) (*KeySet, error) {
+	if active == nil || active.sign == nil {
+		return nil, ErrNoPrivateKey
+	}
+
+	ks := &KeySet{
+		now:      now,
+		active:   active,
+		keys:     map[string]*SigningKey{active.ID: active},
+		retireAt: make(map[string]time.Time),
+	}
+	for _, p := range previous {
+		if _, ok := ks.keys[p.Key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %q", p.Key.ID)
+		}
+		ks.keys[p.Key.ID] = p.Key
+		if !p.RetireAt.IsZero() {
+			ks.retireAt[p.Key.ID] = p.RetireAt
+		}
+	}
+
+	return ks, nil
+}
+
+// Sign подписывает claims активным ключом и пишет его kid в заголовок.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	k := ks.active
+	token := jwt.NewWithClaims(k.Method, claims)
+	if k.ID != "" {
+		token.Header["kid"] = k.ID
+	}
+	return token.SignedString(k.sign)
+}
+
+// Parse проверяет токен. Алгоритм задаёт ключ, найденный по kid, а не
+// заголовок токена: токен с чужим alg (в том числе none или HS256 с
+// публичным ключом в роли секрета) отклоняется.
+func (ks *KeySet) Parse(
+	tokenString string,
+	claims jwt.Claims,
+	opts ...jwt.ParserOption,
+) (*jwt.Token, error) {
+	opts = append(opts, jwt.WithValidMethods(ks.methods()))
+	return jwt.ParseWithClaims(tokenString, claims, ks.keyfunc, opts...)
+}
+
+func (ks *KeySet) keyfunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	}
+	if at, ok := ks.retireAt[kid]; ok && !ks.now().Before(at) {
+		return nil, fmt.Errorf("%w: kid %q", ErrKeyRetired, kid)
+	}
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, fmt.Errorf(
+			"%w: got %s, want %s",
+			ErrAlgMismatch,
+			t.Method.Alg(),
+			k.Method.Alg(),
+		)
+	}
+
+	return k.verify, nil
+}
+
+func (ks *KeySet) methods() []string {
+	seen := make(map[string]struct{})
+	var res []string
+	for _, k := range ks.keys {
+		alg := k.Method.Alg()
+		if _, ok := seen[alg]; ok {
+			continue
+		}
+		seen[alg] = struct{}{}
+		res = append(res, alg)
+	}
+	return res
+}
+
+// JWKS публичные части действующих асимметричных ключей. HMAC ключи не
+// публикуются.
+func (ks *KeySet) JWKS() *model.JWKSet {
+	set := &model.JWKSet{Keys: []model.JWK{}}
+	for kid, k := range ks.keys {
+		if at, ok := ks.retireAt[kid]; ok && !ks.now().Before(at) {
+			continue
+		}
+		jwk, ok := publicJWK(k)
+		if !ok {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+func publicJWK(k *SigningKey) (model.JWK, bool) {
+	enc := base64.RawURLEncoding
+	jwk := model.JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+
+	switch pub := k.verify.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc.EncodeToString(pub.N.Bytes())
+		jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc.EncodeToString(pub)
+	default:
+		return model.JWK{}, false
+	}
+
+	return jwk, true
+}
@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaPEM(t *testing.T, bits int) ([]byte, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	b := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	return b, key
+}
+
+func ed25519PEM(t *testing.T) ([]byte, ed25519.PrivateKey) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), key
+}
+
+func TestParsePEMKey(t *testing.T) {
+	rsaKey, _ := rsaPEM(t, 2048)
+	edKey, edPriv := ed25519PEM(t)
+	shortKey, _ := rsaPEM(t, 1024)
+
+	pubDER, err := x509.MarshalPKIXPublicKey(edPriv.Public())
+	require.NoError(t, err)
+	edPub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantAlg  string
+		wantSign bool
+		wantErr  bool
+	}{
+		{name: "rsa pkcs1", data: rsaKey, wantAlg: "RS256", wantSign: true},
+		{name: "ed25519 pkcs8", data: edKey, wantAlg: "EdDSA", wantSign: true},
+		{name: "ed25519 public", data: edPub, wantAlg: "EdDSA"},
+		{name: "short rsa", data: shortKey, wantErr: true},
+		{name: "not pem", data: []byte("secret"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := ParsePEMKey("kid", tt.data)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "kid", k.ID)
+			assert.Equal(t, tt.wantAlg, k.Method.Alg())
+			assert.Equal(t, tt.wantSign, k.sign != nil)
+		})
+	}
+}
+
+func TestLoadPEMKey(t *testing.T) {
+	data, _ := ed25519PEM(t)
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	k, err := LoadPEMKey("2025-10", path)
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", k.Method.Alg())
+
+	_, err = LoadPEMKey("missing", filepath.Join(t.TempDir(), "missing.pem"))
+	assert.Error(t, err)
+}
+
+func TestKeySet_SignParse(t *testing.T) {
+	rsaData, _ := rsaPEM(t, 2048)
+	edData, _ := ed25519PEM(t)
+
+	for _, data := range [][]byte{rsaData, edData} {
+		k, err := ParsePEMKey("k1", data)
+		require.NoError(t, err)
+		ks, err := NewKeySet(k, nil)
+		require.NoError(t, err)
+
+		token, err := CreateJWTToken(JWTConfig{Keys: ks, TTL: time.Minute}, 7, 1, nil)
+		require.NoError(t, err)
+
+		claims := &Claims{}
+		parsed, err := ks.Parse(token, claims)
+		require.NoError(t, err)
+		assert.Equal(t, "k1", parsed.Header["kid"])
+		assert.Equal(t, k.Method.Alg(), parsed.Method.Alg())
+		assert.Equal(t, 7, claims.UserID())
+	}
+}
+
+func TestKeySet_AlgorithmPinning(t *testing.T) {
+	rsaData, rsaKey := rsaPEM(t, 2048)
+	k, err := ParsePEMKey("rsa", rsaData)
+	require.NoError(t, err)
+	ks, err := NewKeySet(k, []PreviousKey{{Key: NewHMACKey("secret")}})
+	require.NoError(t, err)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "7"},
+	}
+
+	// HS256 с публичным ключом в роли секрета
+	pubDER := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	confused.Header["kid"] = "rsa"
+	confusedToken, err := confused.SignedString(pubDER)
+	require.NoError(t, err)
+
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+	none.Header["kid"] = "rsa"
+	noneToken, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	unknown.Header["kid"] = "other"
+	unknownToken, err := unknown.SignedString(rsaKey)
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{
+		"alg confusion": confusedToken,
+		"none":          noneToken,
+		"unknown kid":   unknownToken,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseJWTToken(JWTConfig{Keys: ks}, token)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeySet_Retirement(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	oldData, _ := ed25519PEM(t)
+	newData, _ := ed25519PEM(t)
+	oldKey, err := ParsePEMKey("old", oldData)
+	require.NoError(t, err)
+	newKey, err := ParsePEMKey("new", newData)
+	require.NoError(t, err)
+
+	oldKS, err := newKeySet(oldKey, nil, clock)
+	require.NoError(t, err)
+	oldToken, err := oldKS.Sign(Claims{LegacyUserID: 7})
+	require.NoError(t, err)
+
+	// прежний ключ выводится в заданный момент, а не через окно от запуска
+	retireAt := now.Add(time.Hour)
+	ks, err := newKeySet(
+		newKey,
+		[]PreviousKey{{Key: oldKey, RetireAt: retireAt}},
+		clock,
+	)
+	require.NoError(t, err)
+
+	newToken, err := ks.Sign(Claims{LegacyUserID: 7})
+	require.NoError(t, err)
+
+	_, err = ks.Parse(oldToken, &Claims{})
+	assert.NoError(t, err, "old key accepted until retirement")
+	_, err = ks.Parse(newToken, &Claims{})
+	assert.NoError(t, err)
+	assert.Len(t, ks.JWKS().Keys, 2)
+
+	now = now.Add(time.Hour)
+
+	_, err = ks.Parse(oldToken, &Claims{})
+	assert.ErrorIs(t, err, ErrKeyRetired)
+	_, err = ks.Parse(newToken, &Claims{})
+	assert.NoError(t, err)
+
+	jwks := ks.JWKS()
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "new", jwks.Keys[0].Kid)
+
+	// перезапуск с тем же набором не продлевает приём прежнего ключа
+	restarted, err := newKeySet(
+		newKey,
+		[]PreviousKey{{Key: oldKey, RetireAt: retireAt}},
+		clock,
+	)
+	require.NoError(t, err)
+	_, err = restarted.Parse(oldToken, &Claims{})
+	assert.ErrorIs(t, err, ErrKeyRetired)
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	rsaData, rsaKey := rsaPEM(t, 2048)
+	edData, edKey := ed25519PEM(t)
+	rk, err := ParsePEMKey("rsa", rsaData)
+	require.NoError(t, err)
+	ek, err := ParsePEMKey("ed", edData)
+	require.NoError(t, err)
+
+	ks, err := NewKeySet(
+		rk,
+		[]PreviousKey{{Key: ek}, {Key: NewHMACKey("secret")}},
+	)
+	require.NoError(t, err)
+
+	jwks := ks.JWKS()
+	require.Len(t, jwks.Keys, 2, "hmac key is not published")
+
+	enc := base64.RawURLEncoding
+	ed, rs := jwks.Keys[0], jwks.Keys[1]
+
+	assert.Equal(t, "ed", ed.Kid)
+	assert.Equal(t, "OKP", ed.Kty)
+	assert.Equal(t, "Ed25519", ed.Crv)
+	assert.Equal(t, "EdDSA", ed.Alg)
+	assert.Equal(t, enc.EncodeToString(edKey.Public().(ed25519.PublicKey)), ed.X)
+
+	assert.Equal(t, "rsa", rs.Kid)
+	assert.Equal(t, "RSA", rs.Kty)
+	assert.Equal(t, "RS256", rs.Alg)
+	assert.Equal(t, "sig", rs.Use)
+	assert.Equal(t, enc.EncodeToString(rsaKey.N.Bytes()), rs.N)
+	assert.Equal(
+		t,
+		enc.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		rs.E,
+	)
+}
+
+func TestNewKeySet_RequiresPrivateKey(t *testing.T) {
+	_, edKey := ed25519PEM(t)
+	pubDER, err := x509.MarshalPKIXPublicKey(edKey.Public())
+	require.NoError(t, err)
+	pub, err := ParsePEMKey(
+		"pub",
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}),
+	)
+	require.NoError(t, err)
+
+	_, err = NewKeySet(pub, nil)
+	assert.ErrorIs(t, err, ErrNoPrivateKey)
+}
@@ -0,0 +1,429 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/fragpit/gophermart/internal/utils/totp"
+)
+
+const (
+	// MFAChallengeTTL время на ввод кода после проверки пароля.
+	MFAChallengeTTL = 5 * time.Minute
+	// MaxMFAAttempts неверных кодов на один вход, затем пароль вводится
+	// заново.
+	MaxMFAAttempts = 5
+	// RecoveryCodesCount кодов восстановления выдаётся при подключении.
+	RecoveryCodesCount = 10
+
+	// mfaSkew принимаются коды соседних шагов: часы телефона расходятся
+	mfaSkew = 1
+	// recoveryCodeLength символов base32 в коде восстановления (50 бит)
+	recoveryCodeLength = 10
+	defaultMFAIssuer   = "gophermart"
+)
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// WithMFA включает второй фактор (TOTP). Без него вход однофакторный,
+// а проверка кода перед списанием пропускается.
+func WithMFA(repo model.MFARepository) Option {
+	return func(a *AuthService) {
+		a.mfa = repo
+	}
+}
+
+// EnrollTOTP начинает подключение: новый секрет и otpauth:// ссылка для
+// приложения-аутентификатора. Второй фактор заработает после
+// подтверждения кодом (ConfirmTOTP).
+func (a *AuthService) EnrollTOTP(
+	ctx context.Context,
+	p *model.Principal,
+) (*model.MFAEnrollment, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.EnrollTOTP")
+	defer span.End()
+
+	u, err := a.repo.GetByID(ctx, p.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+
+	if err := a.mfa.SaveMFASecret(ctx, u.ID, secret); err != nil {
+		return nil, err
+	}
+
+	issuer := a.jwt.Issuer
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+
+	return &model.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(issuer, u.Login, secret),
+	}, nil
+}
+
+// ConfirmTOTP подтверждает подключение первым кодом из приложения.
+// Возвращает коды восстановления: они показываются один раз, хранятся
+// только хеши.
+func (a *AuthService) ConfirmTOTP(
+	ctx context.Context,
+	p *model.Principal,
+	code string,
+) ([]string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ConfirmTOTP")
+	defer span.End()
+
+	m, err := a.mfa.GetMFA(ctx, p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Enabled() {
+		return nil, model.ErrMFAAlreadyEnabled
+	}
+
+	step, ok := totp.Validate(m.Secret, strings.TrimSpace(code), time.Now(), mfaSkew)
+	if !ok {
+		return nil, model.ErrInvalidMFACode
+	}
+
+	codes := make([]string, 0, RecoveryCodesCount)
+	hashes := make([]string, 0, RecoveryCodesCount)
+	for range RecoveryCodesCount {
+		c, err := newRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes = append(codes, c)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(c)))
+	}
+
+	if err := a.mfa.EnableMFA(ctx, p.UserID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	slog.Info("two-factor authentication enabled", slog.Int("user_id", p.UserID))
+
+	return codes, nil
+}
+
+// DisableTOTP отключает второй фактор по паролю и коду из приложения или
+// коду восстановления: с украденным access токеном и подсмотренным кодом
+// защиту не снять.
+func (a *AuthService) DisableTOTP(
+	ctx context.Context,
+	p *model.Principal,
+	code, password string,
+) error {
+	ctx, span := tracer.Start(ctx, "AuthService.DisableTOTP")
+	defer span.End()
+
+	m, err := a.enabledMFA(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+
+	// пароль проверяется первым: иначе при неверном пароле код из
+	// приложения был бы уже израсходован
+	if _, err := a.verifyPassword(ctx, p.UserID, password); err != nil {
+		return err
+	}
+
+	if err := a.checkUserMFACode(ctx, m, code, true); err != nil {
+		return err
+	}
+
+	if err := a.mfa.DisableMFA(ctx, p.UserID); err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+
+	slog.Info("two-factor authentication disabled", slog.Int("user_id", p.UserID))
+
+	return nil
+}
+
+// SetWithdrawMFA включает или отключает проверку кода перед списанием
+// баллов. Требует код: иначе украденный access токен снял бы защиту.
+// Отключение требует и пароль: одного подсмотренного кода мало.
+func (a *AuthService) SetWithdrawMFA(
+	ctx context.Context,
+	p *model.Principal,
+	required bool,
+	code, password string,
+) error {
+	ctx, span := tracer.Start(ctx, "AuthService.SetWithdrawMFA")
+	defer span.End()
+
+	m, err := a.enabledMFA(ctx, p.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !required {
+		if _, err := a.verifyPassword(ctx, p.UserID, password); err != nil {
+			return err
+		}
+	}
+
+	if err := a.checkUserMFACode(ctx, m, code, false); err != nil {
+		return err
+	}
+
+	return a.mfa.SetMFAWithdrawRequired(ctx, p.UserID, required)
+}
+
+// VerifyStepUp проверяет свежий код из приложения перед списанием, если
+// пользователь его требует. ErrMFARequired - код не передан.
+func (a *AuthService) VerifyStepUp(
+	ctx context.Context,
+	p *model.Principal,
+	code string,
+) error {
+	ctx, span := tracer.Start(ctx, "AuthService.VerifyStepUp")
+	defer span.End()
+
+	if a.mfa == nil {
+		return nil
+	}
+
+	m, err := a.mfa.GetMFA(ctx, p.UserID)
+	if errors.Is(err, model.ErrMFANotEnrolled) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get mfa: %w", err)
+	}
+	if !m.Enabled() || !m.WithdrawRequired {
+		return nil
+	}
+
+	if code == "" {
+		return model.ErrMFARequired
+	}
+
+	return a.checkUserMFACode(ctx, m, code, false)
+}
+
+// LoginMFA второй шаг входа: код из приложения или код восстановления
+// по токену, выданному Login. Неверные коды учитываются в ограничении
+// перебора паролей, после MaxMFAAttempts токен удаляется.
+func (a *AuthService) LoginMFA(
+	ctx context.Context,
+	token, code, clientIP string,
+) (*model.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.LoginMFA")
+	defer span.End()
+
+	if token == "" {
+		return nil, model.ErrInvalidMFAChallenge
+	}
+
+	c, err := a.mfa.GetMFAChallenge(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if c.Attempts >= MaxMFAAttempts {
+		return nil, model.ErrInvalidMFAChallenge
+	}
+
+	u, err := a.repo.GetByID(ctx, c.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	keys := a.throttleKeys(u.Login, clientIP)
+	if err := a.checkThrottle(ctx, keys); err != nil {
+		return nil, err
+	}
+
+	m, err := a.enabledMFA(ctx, u.ID)
+	if errors.Is(err, model.ErrMFANotEnrolled) {
+		// второй фактор отключён после первого шага
+		return nil, model.ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.checkMFACode(ctx, m, code, true); err != nil {
+		if errors.Is(err, model.ErrInvalidMFACode) {
+			a.loginFailed(ctx, keys)
+			a.mfaChallengeFailed(ctx, c)
+		}
+		return nil, err
+	}
+
+	// токен одноразовый: из двух одновременных запросов пройдёт один
+	if err := a.mfa.DeleteMFAChallenge(ctx, c.ID); err != nil {
+		return nil, err
+	}
+
+	a.loginSucceeded(ctx, keys)
+
+	if u.Status == model.UserStatusClosed {
+		return nil, model.ErrAccountClosed
+	}
+
+	return a.issueTokens(ctx, u)
+}
+
+// newMFAChallenge первый шаг входа пройден: сохраняет токен второго шага и
+// возвращает его в MFAChallengeError.
+func (a *AuthService) newMFAChallenge(
+	ctx context.Context,
+	userID int,
+) error {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate mfa token: %w", err)
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	c := &model.MFAChallenge{
+		UserID:    userID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(MFAChallengeTTL),
+	}
+	if err := a.mfa.CreateMFAChallenge(ctx, c); err != nil {
+		return fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+
+	return &model.MFAChallengeError{Token: raw, ExpiresIn: MFAChallengeTTL}
+}
+
+func (a *AuthService) mfaChallengeFailed(
+	ctx context.Context,
+	c *model.MFAChallenge,
+) {
+	attempts, err := a.mfa.FailMFAChallenge(ctx, c.ID)
+	if err != nil {
+		slog.Error(
+			"failed to record mfa failure",
+			slog.Int("user_id", c.UserID),
+			slog.Any("error", err),
+		)
+		return
+	}
+	if attempts < MaxMFAAttempts {
+		return
+	}
+
+	slog.Warn(
+		"mfa attempts exhausted, challenge dropped",
+		slog.Int("user_id", c.UserID),
+	)
+	if err := a.mfa.DeleteMFAChallenge(ctx, c.ID); err != nil &&
+		!errors.Is(err, model.ErrInvalidMFAChallenge) {
+		slog.Error(
+			"failed to delete mfa challenge",
+			slog.Int("user_id", c.UserID),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// enabledMFA подтверждённый второй фактор, иначе ErrMFANotEnrolled.
+func (a *AuthService) enabledMFA(
+	ctx context.Context,
+	userID int,
+) (*model.MFA, error) {
+	m, err := a.mfa.GetMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Enabled() {
+		return nil, model.ErrMFANotEnrolled
+	}
+	return m, nil
+}
+
+// checkUserMFACode checkMFACode для аутентифицированного пользователя:
+// неверные коды ограничены, иначе украденный access токен позволял бы
+// подбирать код. Счётчик отдельный (ThrottleScopeMFA): с тем же токеном
+// нельзя заблокировать владельцу вход по паролю, блокируются только
+// операции, требующие код.
+func (a *AuthService) checkUserMFACode(
+	ctx context.Context,
+	m *model.MFA,
+	code string,
+	allowRecovery bool,
+) error {
+	u, err := a.repo.GetByID(ctx, m.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	keys := a.mfaThrottleKeys(u.Login)
+	if err := a.checkThrottle(ctx, keys); err != nil {
+		return err
+	}
+
+	err = a.checkMFACode(ctx, m, code, allowRecovery)
+	if errors.Is(err, model.ErrInvalidMFACode) {
+		a.loginFailed(ctx, keys)
+	}
+	return err
+}
+
+// checkMFACode принимает код из приложения (шаг запоминается, повторно
+// код не пройдёт) или, если allowRecovery, неиспользованный код
+// восстановления.
+func (a *AuthService) checkMFACode(
+	ctx context.Context,
+	m *model.MFA,
+	code string,
+	allowRecovery bool,
+) error {
+	code = strings.TrimSpace(code)
+
+	if step, ok := totp.Validate(m.Secret, code, time.Now(), mfaSkew); ok {
+		return a.mfa.UseMFAStep(ctx, m.UserID, step)
+	}
+
+	if !allowRecovery || len(code) == totp.Digits {
+		return model.ErrInvalidMFACode
+	}
+
+	err := a.mfa.UseRecoveryCode(
+		ctx,
+		m.UserID,
+		hashToken(normalizeRecoveryCode(code)),
+	)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("recovery code used", slog.Int("user_id", m.UserID))
+
+	return nil
+}
+
+// newRecoveryCode код вида abcde-fgh23.
+func newRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(recoveryEncoding.EncodeToString(b))[:recoveryCodeLength]
+	return s[:recoveryCodeLength/2] + "-" + s[recoveryCodeLength/2:], nil
+}
+
+// normalizeRecoveryCode код принимается без дефиса и в любом регистре.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+	return strings.ToLower(code)
+}
@@ -0,0 +1,495 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/auth/mocks"
+	"github.com/fragpit/gophermart/internal/utils/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func currentTOTPCode(t *testing.T) string {
+	t.Helper()
+	code, err := totp.Code(testTOTPSecret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func enabledMFA(required bool) *model.MFA {
+	enabledAt := time.Now().Add(-time.Hour)
+	return &model.MFA{
+		UserID:           7,
+		Secret:           testTOTPSecret,
+		WithdrawRequired: required,
+		EnabledAt:        &enabledAt,
+	}
+}
+
+func TestAuthService_LoginWithMFA(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	hasher := NewPasswordHasher(testArgon2Params(), 0)
+	hashed, _ := hasher.Hash("pass")
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockUsersRepository(ctrl)
+	mfa := mocks.NewMockMFARepository(ctrl)
+	throttle := mocks.NewMockLoginThrottleRepository(ctrl)
+
+	// счётчик неудач по логину не сбрасывается (ResetLoginFailures не
+	// ожидается): его сбросит только LoginMFA
+	throttle.EXPECT().GetLockedUntil(gomock.Any(), gomock.Any()).
+		Return(time.Time{}, nil)
+	repo.EXPECT().GetByLogin(gomock.Any(), "user").
+		Return(&model.User{ID: 7, Login: "user", PasswordHash: hashed}, nil)
+	mfa.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(false), nil)
+
+	var saved *model.MFAChallenge
+	mfa.EXPECT().CreateMFAChallenge(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, c *model.MFAChallenge) error {
+			saved = c
+			return nil
+		})
+
+	svc := NewAuthService(
+		repo,
+		nil,
+		testJWT(t),
+		time.Hour,
+		WithPasswordHasher(hasher),
+		WithLoginThrottle(throttle, LoginThrottle{MaxLoginFailures: 5}),
+		WithMFA(mfa),
+	)
+	tokens, err := svc.Login(context.Background(), "user", "pass", "")
+	assert.Nil(t, tokens)
+	assert.ErrorIs(t, err, model.ErrMFARequired)
+
+	var challenge *model.MFAChallengeError
+	require.ErrorAs(t, err, &challenge)
+	require.NotNil(t, saved)
+	assert.Equal(t, 7, saved.UserID)
+	assert.Equal(t, hashToken(challenge.Token), saved.TokenHash)
+	assert.Equal(t, MFAChallengeTTL, challenge.ExpiresIn)
+}
+
+func TestAuthService_ConfirmTOTP(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+	pending := &model.MFA{UserID: 7, Secret: testTOTPSecret}
+
+	tests := []struct {
+		name    string
+		code    string
+		prepare func(*mocks.MockMFARepository)
+		wantErr error
+	}{
+		{
+			name: "confirmed",
+			code: currentTOTPCode(t),
+			prepare: func(m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(pending, nil)
+				m.EXPECT().
+					EnableMFA(gomock.Any(), 7, gomock.Any(), gomock.Len(RecoveryCodesCount)).
+					Return(nil)
+			},
+		},
+		{
+			name: "wrong code",
+			code: "000000",
+			prepare: func(m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(pending, nil)
+			},
+			wantErr: model.ErrInvalidMFACode,
+		},
+		{
+			name: "already enabled",
+			code: currentTOTPCode(t),
+			prepare: func(m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(false), nil)
+			},
+			wantErr: model.ErrMFAAlreadyEnabled,
+		},
+		{
+			name: "not enrolled",
+			code: currentTOTPCode(t),
+			prepare: func(m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).
+					Return(nil, model.ErrMFANotEnrolled)
+			},
+			wantErr: model.ErrMFANotEnrolled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mfa := mocks.NewMockMFARepository(ctrl)
+			tt.prepare(mfa)
+
+			svc := NewAuthService(nil, nil, testJWT(t), time.Hour, WithMFA(mfa))
+			codes, err := svc.ConfirmTOTP(context.Background(), p, tt.code)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, codes, RecoveryCodesCount)
+			assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+		})
+	}
+}
+
+func TestAuthService_LoginMFA(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	const token = "challenge"
+	user := &model.User{ID: 7, Login: "user", TokenVersion: 2}
+	challenge := func(attempts int) *model.MFAChallenge {
+		return &model.MFAChallenge{
+			ID:        3,
+			UserID:    7,
+			TokenHash: hashToken(token),
+			Attempts:  attempts,
+			ExpiresAt: time.Now().Add(time.Minute),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		code    string
+		prepare func(*mocks.MockUsersRepository, *mocks.MockMFARepository, *mocks.MockSessionsRepository)
+		wantErr error
+	}{
+		{
+			name: "totp code",
+			code: currentTOTPCode(t),
+			prepare: func(
+				r *mocks.MockUsersRepository,
+				m *mocks.MockMFARepository,
+				s *mocks.MockSessionsRepository,
+			) {
+				m.EXPECT().GetMFAChallenge(gomock.Any(), hashToken(token)).
+					Return(challenge(0), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(false), nil)
+				m.EXPECT().UseMFAStep(gomock.Any(), 7, gomock.Any()).Return(nil)
+				m.EXPECT().DeleteMFAChallenge(gomock.Any(), 3).Return(nil)
+				s.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "recovery code",
+			code: "ABCDE-FGH23",
+			prepare: func(
+				r *mocks.MockUsersRepository,
+				m *mocks.MockMFARepository,
+				s *mocks.MockSessionsRepository,
+			) {
+				m.EXPECT().GetMFAChallenge(gomock.Any(), hashToken(token)).
+					Return(challenge(0), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(false), nil)
+				m.EXPECT().
+					UseRecoveryCode(gomock.Any(), 7, hashToken("abcdefgh23")).
+					Return(nil)
+				m.EXPECT().DeleteMFAChallenge(gomock.Any(), 3).Return(nil)
+				s.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "wrong code",
+			code: "000000",
+			prepare: func(
+				r *mocks.MockUsersRepository,
+				m *mocks.MockMFARepository,
+				_ *mocks.MockSessionsRepository,
+			) {
+				m.EXPECT().GetMFAChallenge(gomock.Any(), hashToken(token)).
+					Return(challenge(0), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(false), nil)
+				m.EXPECT().FailMFAChallenge(gomock.Any(), 3).Return(1, nil)
+			},
+			wantErr: model.ErrInvalidMFACode,
+		},
+		{
+			name: "last attempt drops challenge",
+			code: "000000",
+			prepare: func(
+				r *mocks.MockUsersRepository,
+				m *mocks.MockMFARepository,
+				_ *mocks.MockSessionsRepository,
+			) {
+				m.EXPECT().GetMFAChallenge(gomock.Any(), hashToken(token)).
+					Return(challenge(MaxMFAAttempts-1), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(false), nil)
+				m.EXPECT().FailMFAChallenge(gomock.Any(), 3).
+					Return(MaxMFAAttempts, nil)
+				m.EXPECT().DeleteMFAChallenge(gomock.Any(), 3).Return(nil)
+			},
+			wantErr: model.ErrInvalidMFACode,
+		},
+		{
+			name: "attempts exhausted",
+			code: currentTOTPCode(t),
+			prepare: func(
+				_ *mocks.MockUsersRepository,
+				m *mocks.MockMFARepository,
+				_ *mocks.MockSessionsRepository,
+			) {
+				m.EXPECT().GetMFAChallenge(gomock.Any(), hashToken(token)).
+					Return(challenge(MaxMFAAttempts), nil)
+			},
+			wantErr: model.ErrInvalidMFAChallenge,
+		},
+		{
+			name: "unknown challenge",
+			code: currentTOTPCode(t),
+			prepare: func(
+				_ *mocks.MockUsersRepository,
+				m *mocks.MockMFARepository,
+				_ *mocks.MockSessionsRepository,
+			) {
+				m.EXPECT().GetMFAChallenge(gomock.Any(), hashToken(token)).
+					Return(nil, model.ErrInvalidMFAChallenge)
+			},
+			wantErr: model.ErrInvalidMFAChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockUsersRepository(ctrl)
+			mfa := mocks.NewMockMFARepository(ctrl)
+			sessions := mocks.NewMockSessionsRepository(ctrl)
+			tt.prepare(repo, mfa, sessions)
+
+			svc := NewAuthService(
+				repo,
+				sessions,
+				testJWT(t),
+				time.Hour,
+				WithMFA(mfa),
+			)
+			tokens, err := svc.LoginMFA(context.Background(), token, tt.code, "")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, tokens)
+				return
+			}
+			require.NoError(t, err)
+
+			claims, err := ParseJWTToken(testJWT(t), tokens.AccessToken)
+			require.NoError(t, err)
+			assert.Equal(t, 7, claims.UserID())
+			assert.Equal(t, 2, claims.TokenVersion)
+		})
+	}
+}
+
+func TestAuthService_VerifyStepUp(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	p := &model.Principal{UserID: 7}
+	user := &model.User{ID: 7, Login: "user"}
+
+	tests := []struct {
+		name    string
+		code    string
+		prepare func(*mocks.MockUsersRepository, *mocks.MockMFARepository)
+		wantErr error
+	}{
+		{
+			name: "not enrolled",
+			prepare: func(_ *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).
+					Return(nil, model.ErrMFANotEnrolled)
+			},
+		},
+		{
+			name: "not required",
+			prepare: func(_ *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(false), nil)
+			},
+		},
+		{
+			name: "code missing",
+			prepare: func(_ *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(true), nil)
+			},
+			wantErr: model.ErrMFARequired,
+		},
+		{
+			name: "fresh code",
+			code: currentTOTPCode(t),
+			prepare: func(r *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(true), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				m.EXPECT().UseMFAStep(gomock.Any(), 7, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "code reused",
+			code: currentTOTPCode(t),
+			prepare: func(r *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(true), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				m.EXPECT().UseMFAStep(gomock.Any(), 7, gomock.Any()).
+					Return(model.ErrInvalidMFACode)
+			},
+			wantErr: model.ErrInvalidMFACode,
+		},
+		{
+			name: "recovery code not accepted",
+			code: "abcde-fgh23",
+			prepare: func(r *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(true), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+			},
+			wantErr: model.ErrInvalidMFACode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockUsersRepository(ctrl)
+			mfa := mocks.NewMockMFARepository(ctrl)
+			tt.prepare(repo, mfa)
+
+			svc := NewAuthService(repo, nil, testJWT(t), time.Hour, WithMFA(mfa))
+			err := svc.VerifyStepUp(context.Background(), p, tt.code)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthService_VerifyStepUpThrottle(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockUsersRepository(ctrl)
+	mfa := mocks.NewMockMFARepository(ctrl)
+	throttle := mocks.NewMockLoginThrottleRepository(ctrl)
+
+	// неверный код считается отдельно от входа по паролю: ключ логина не
+	// трогается
+	mfaKey := model.LoginThrottleKey{Scope: model.ThrottleScopeMFA, Subject: "user"}
+	mfa.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(true), nil)
+	repo.EXPECT().GetByID(gomock.Any(), 7).
+		Return(&model.User{ID: 7, Login: "user"}, nil)
+	throttle.EXPECT().
+		GetLockedUntil(gomock.Any(), []model.LoginThrottleKey{mfaKey}).
+		Return(time.Time{}, nil)
+	throttle.EXPECT().RecordLoginFailure(gomock.Any(), mfaKey, time.Minute).
+		Return(&model.LoginFailures{LoginThrottleKey: mfaKey, Failures: 1}, nil)
+
+	svc := NewAuthService(
+		repo,
+		nil,
+		testJWT(t),
+		time.Hour,
+		WithLoginThrottle(throttle, LoginThrottle{
+			MaxLoginFailures: 5,
+			Window:           time.Minute,
+		}),
+		WithMFA(mfa),
+	)
+	err := svc.VerifyStepUp(context.Background(), &model.Principal{UserID: 7}, "000000")
+	assert.ErrorIs(t, err, model.ErrInvalidMFACode)
+}
+
+func TestAuthService_SetWithdrawMFA(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	hasher := NewPasswordHasher(testArgon2Params(), 0)
+	hashed, _ := hasher.Hash("pass")
+	user := &model.User{ID: 7, Login: "user", PasswordHash: hashed}
+	p := &model.Principal{UserID: 7}
+
+	tests := []struct {
+		name     string
+		required bool
+		password string
+		prepare  func(*mocks.MockUsersRepository, *mocks.MockMFARepository)
+		wantErr  error
+	}{
+		{
+			name:     "enable with code only",
+			required: true,
+			prepare: func(r *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(false), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+				m.EXPECT().UseMFAStep(gomock.Any(), 7, gomock.Any()).Return(nil)
+				m.EXPECT().SetMFAWithdrawRequired(gomock.Any(), 7, true).
+					Return(nil)
+			},
+		},
+		{
+			// код не проверяется и не расходуется
+			name: "disable without password",
+			prepare: func(r *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(true), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+			},
+			wantErr: model.ErrInvalidCredentials,
+		},
+		{
+			name:     "disable with wrong password",
+			password: "wrong",
+			prepare: func(r *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(true), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil)
+			},
+			wantErr: model.ErrInvalidCredentials,
+		},
+		{
+			name:     "disable with password and code",
+			password: "pass",
+			prepare: func(r *mocks.MockUsersRepository, m *mocks.MockMFARepository) {
+				m.EXPECT().GetMFA(gomock.Any(), 7).Return(enabledMFA(true), nil)
+				r.EXPECT().GetByID(gomock.Any(), 7).Return(user, nil).Times(2)
+				m.EXPECT().UseMFAStep(gomock.Any(), 7, gomock.Any()).Return(nil)
+				m.EXPECT().SetMFAWithdrawRequired(gomock.Any(), 7, false).
+					Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockUsersRepository(ctrl)
+			mfa := mocks.NewMockMFARepository(ctrl)
+			tt.prepare(repo, mfa)
+
+			svc := NewAuthService(
+				repo,
+				nil,
+				testJWT(t),
+				time.Hour,
+				WithPasswordHasher(hasher),
+				WithMFA(mfa),
+			)
+			err := svc.SetWithdrawMFA(
+				context.Background(),
+				p,
+				tt.required,
+				currentTOTPCode(t),
+				tt.password,
+			)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
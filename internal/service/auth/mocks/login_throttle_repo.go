@@ -0,0 +1,131 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/model (interfaces: LoginThrottleRepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination ../service/auth/mocks/login_throttle_repo.go . LoginThrottleRepository
+//
+
+// Package mock_model is a generated GoMock package.
+package mock_model
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLoginThrottleRepository is a mock of LoginThrottleRepository interface.
+type MockLoginThrottleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginThrottleRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockLoginThrottleRepositoryMockRecorder is the mock recorder for MockLoginThrottleRepository.
+type MockLoginThrottleRepositoryMockRecorder struct {
+	mock *MockLoginThrottleRepository
+}
+
+// NewMockLoginThrottleRepository creates a new mock instance.
+func NewMockLoginThrottleRepository(ctrl *gomock.Controller) *MockLoginThrottleRepository {
+	mock := &MockLoginThrottleRepository{ctrl: ctrl}
+	mock.recorder = &MockLoginThrottleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginThrottleRepository) EXPECT() *MockLoginThrottleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetLockedUntil mocks base method.
+func (m *MockLoginThrottleRepository) GetLockedUntil(ctx context.Context, keys []model.LoginThrottleKey) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLockedUntil", ctx, keys)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLockedUntil indicates an expected call of GetLockedUntil.
+func (mr *MockLoginThrottleRepositoryMockRecorder) GetLockedUntil(ctx, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLockedUntil", reflect.TypeOf((*MockLoginThrottleRepository)(nil).GetLockedUntil), ctx, keys)
+}
+
+// GetLockouts mocks base method.
+func (m *MockLoginThrottleRepository) GetLockouts(ctx context.Context, activeOnly bool) ([]model.LoginLockout, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLockouts", ctx, activeOnly)
+	ret0, _ := ret[0].([]model.LoginLockout)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLockouts indicates an expected call of GetLockouts.
+func (mr *MockLoginThrottleRepositoryMockRecorder) GetLockouts(ctx, activeOnly any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLockouts", reflect.TypeOf((*MockLoginThrottleRepository)(nil).GetLockouts), ctx, activeOnly)
+}
+
+// LockLogin mocks base method.
+func (m *MockLoginThrottleRepository) LockLogin(ctx context.Context, l *model.LoginLockout) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, l)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockLoginThrottleRepositoryMockRecorder) LockLogin(ctx, l any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockLoginThrottleRepository)(nil).LockLogin), ctx, l)
+}
+
+// RecordLoginFailure mocks base method.
+func (m *MockLoginThrottleRepository) RecordLoginFailure(ctx context.Context, key model.LoginThrottleKey, window time.Duration) (*model.LoginFailures, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordLoginFailure", ctx, key, window)
+	ret0, _ := ret[0].(*model.LoginFailures)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordLoginFailure indicates an expected call of RecordLoginFailure.
+func (mr *MockLoginThrottleRepositoryMockRecorder) RecordLoginFailure(ctx, key, window any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordLoginFailure", reflect.TypeOf((*MockLoginThrottleRepository)(nil).RecordLoginFailure), ctx, key, window)
+}
+
+// ResetLoginFailures mocks base method.
+func (m *MockLoginThrottleRepository) ResetLoginFailures(ctx context.Context, key model.LoginThrottleKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures.
+func (mr *MockLoginThrottleRepositoryMockRecorder) ResetLoginFailures(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockLoginThrottleRepository)(nil).ResetLoginFailures), ctx, key)
+}
+
+// UnlockLogin mocks base method.
+func (m *MockLoginThrottleRepository) UnlockLogin(ctx context.Context, key model.LoginThrottleKey) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockLogin", ctx, key)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockLogin indicates an expected call of UnlockLogin.
+func (mr *MockLoginThrottleRepositoryMockRecorder) UnlockLogin(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockLogin", reflect.TypeOf((*MockLoginThrottleRepository)(nil).UnlockLogin), ctx, key)
+}
@@ -0,0 +1,199 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/model (interfaces: MFARepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination ../service/auth/mocks/mfa_repo.go . MFARepository
+//
+
+// Package mock_model is a generated GoMock package.
+package mock_model
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMFARepository is a mock of MFARepository interface.
+type MockMFARepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMFARepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockMFARepositoryMockRecorder is the mock recorder for MockMFARepository.
+type MockMFARepositoryMockRecorder struct {
+	mock *MockMFARepository
+}
+
+// NewMockMFARepository creates a new mock instance.
+func NewMockMFARepository(ctrl *gomock.Controller) *MockMFARepository {
+	mock := &MockMFARepository{ctrl: ctrl}
+	mock.recorder = &MockMFARepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMFARepository) EXPECT() *MockMFARepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateMFAChallenge mocks base method.
+func (m *MockMFARepository) CreateMFAChallenge(ctx context.Context, c *model.MFAChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMFAChallenge", ctx, c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateMFAChallenge indicates an expected call of CreateMFAChallenge.
+func (mr *MockMFARepositoryMockRecorder) CreateMFAChallenge(ctx, c any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMFAChallenge", reflect.TypeOf((*MockMFARepository)(nil).CreateMFAChallenge), ctx, c)
+}
+
+// DeleteMFAChallenge mocks base method.
+func (m *MockMFARepository) DeleteMFAChallenge(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMFAChallenge", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMFAChallenge indicates an expected call of DeleteMFAChallenge.
+func (mr *MockMFARepositoryMockRecorder) DeleteMFAChallenge(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMFAChallenge", reflect.TypeOf((*MockMFARepository)(nil).DeleteMFAChallenge), ctx, id)
+}
+
+// DisableMFA mocks base method.
+func (m *MockMFARepository) DisableMFA(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableMFA", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableMFA indicates an expected call of DisableMFA.
+func (mr *MockMFARepositoryMockRecorder) DisableMFA(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableMFA", reflect.TypeOf((*MockMFARepository)(nil).DisableMFA), ctx, userID)
+}
+
+// EnableMFA mocks base method.
+func (m *MockMFARepository) EnableMFA(ctx context.Context, userID int, step int64, recoveryHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableMFA", ctx, userID, step, recoveryHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableMFA indicates an expected call of EnableMFA.
+func (mr *MockMFARepositoryMockRecorder) EnableMFA(ctx, userID, step, recoveryHashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableMFA", reflect.TypeOf((*MockMFARepository)(nil).EnableMFA), ctx, userID, step, recoveryHashes)
+}
+
+// FailMFAChallenge mocks base method.
+func (m *MockMFARepository) FailMFAChallenge(ctx context.Context, id int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailMFAChallenge", ctx, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailMFAChallenge indicates an expected call of FailMFAChallenge.
+func (mr *MockMFARepositoryMockRecorder) FailMFAChallenge(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailMFAChallenge", reflect.TypeOf((*MockMFARepository)(nil).FailMFAChallenge), ctx, id)
+}
+
+// GetMFA mocks base method.
+func (m *MockMFARepository) GetMFA(ctx context.Context, userID int) (*model.MFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFA", ctx, userID)
+	ret0, _ := ret[0].(*model.MFA)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMFA indicates an expected call of GetMFA.
+func (mr *MockMFARepositoryMockRecorder) GetMFA(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFA", reflect.TypeOf((*MockMFARepository)(nil).GetMFA), ctx, userID)
+}
+
+// GetMFAChallenge mocks base method.
+func (m *MockMFARepository) GetMFAChallenge(ctx context.Context, hash string) (*model.MFAChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFAChallenge", ctx, hash)
+	ret0, _ := ret[0].(*model.MFAChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMFAChallenge indicates an expected call of GetMFAChallenge.
+func (mr *MockMFARepositoryMockRecorder) GetMFAChallenge(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFAChallenge", reflect.TypeOf((*MockMFARepository)(nil).GetMFAChallenge), ctx, hash)
+}
+
+// SaveMFASecret mocks base method.
+func (m *MockMFARepository) SaveMFASecret(ctx context.Context, userID int, secret string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMFASecret", ctx, userID, secret)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMFASecret indicates an expected call of SaveMFASecret.
+func (mr *MockMFARepositoryMockRecorder) SaveMFASecret(ctx, userID, secret any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMFASecret", reflect.TypeOf((*MockMFARepository)(nil).SaveMFASecret), ctx, userID, secret)
+}
+
+// SetMFAWithdrawRequired mocks base method.
+func (m *MockMFARepository) SetMFAWithdrawRequired(ctx context.Context, userID int, required bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMFAWithdrawRequired", ctx, userID, required)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMFAWithdrawRequired indicates an expected call of SetMFAWithdrawRequired.
+func (mr *MockMFARepositoryMockRecorder) SetMFAWithdrawRequired(ctx, userID, required any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMFAWithdrawRequired", reflect.TypeOf((*MockMFARepository)(nil).SetMFAWithdrawRequired), ctx, userID, required)
+}
+
+// UseMFAStep mocks base method.
+func (m *MockMFARepository) UseMFAStep(ctx context.Context, userID int, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseMFAStep", ctx, userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseMFAStep indicates an expected call of UseMFAStep.
+func (mr *MockMFARepositoryMockRecorder) UseMFAStep(ctx, userID, step any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseMFAStep", reflect.TypeOf((*MockMFARepository)(nil).UseMFAStep), ctx, userID, step)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID int, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockMFARepositoryMockRecorder) UseRecoveryCode(ctx, userID, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockMFARepository)(nil).UseRecoveryCode), ctx, userID, hash)
+}
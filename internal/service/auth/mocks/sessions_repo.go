@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/fragpit/gophermart/internal/model (interfaces: SessionsRepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination ../service/auth/mocks/sessions_repo.go . SessionsRepository
+//
+
+// Package mock_model is a generated GoMock package.
+package mock_model
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/fragpit/gophermart/internal/model"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSessionsRepository is a mock of SessionsRepository interface.
+type MockSessionsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionsRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSessionsRepositoryMockRecorder is the mock recorder for MockSessionsRepository.
+type MockSessionsRepositoryMockRecorder struct {
+	mock *MockSessionsRepository
+}
+
+// NewMockSessionsRepository creates a new mock instance.
+func NewMockSessionsRepository(ctrl *gomock.Controller) *MockSessionsRepository {
+	mock := &MockSessionsRepository{ctrl: ctrl}
+	mock.recorder = &MockSessionsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionsRepository) EXPECT() *MockSessionsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateRefreshToken mocks base method.
+func (m *MockSessionsRepository) CreateRefreshToken(ctx context.Context, t *model.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRefreshToken", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRefreshToken indicates an expected call of CreateRefreshToken.
+func (mr *MockSessionsRepositoryMockRecorder) CreateRefreshToken(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRefreshToken", reflect.TypeOf((*MockSessionsRepository)(nil).CreateRefreshToken), ctx, t)
+}
+
+// IsAccessTokenValid mocks base method.
+func (m *MockSessionsRepository) IsAccessTokenValid(ctx context.Context, userID int, jti string, version int) (bool, model.UserStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAccessTokenValid", ctx, userID, jti, version)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(model.UserStatus)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// IsAccessTokenValid indicates an expected call of IsAccessTokenValid.
+func (mr *MockSessionsRepositoryMockRecorder) IsAccessTokenValid(ctx, userID, jti, version any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAccessTokenValid", reflect.TypeOf((*MockSessionsRepository)(nil).IsAccessTokenValid), ctx, userID, jti, version)
+}
+
+// RevokeAccessToken mocks base method.
+func (m *MockSessionsRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAccessToken", ctx, jti, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAccessToken indicates an expected call of RevokeAccessToken.
+func (mr *MockSessionsRepositoryMockRecorder) RevokeAccessToken(ctx, jti, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessToken", reflect.TypeOf((*MockSessionsRepository)(nil).RevokeAccessToken), ctx, jti, expiresAt)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockSessionsRepository) RevokeRefreshToken(ctx context.Context, userID int, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", ctx, userID, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockSessionsRepositoryMockRecorder) RevokeRefreshToken(ctx, userID, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockSessionsRepository)(nil).RevokeRefreshToken), ctx, userID, hash)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockSessionsRepository) RevokeUserSessions(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockSessionsRepositoryMockRecorder) RevokeUserSessions(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockSessionsRepository)(nil).RevokeUserSessions), ctx, userID)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockSessionsRepository) RotateRefreshToken(ctx context.Context, oldHash string, next *model.RefreshToken) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, oldHash, next)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockSessionsRepositoryMockRecorder) RotateRefreshToken(ctx, oldHash, next any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockSessionsRepository)(nil).RotateRefreshToken), ctx, oldHash, next)
+}
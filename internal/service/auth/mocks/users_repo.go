@@ -41,6 +41,21 @@ func (m *MockUsersRepository) EXPECT() *MockUsersRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockUsersRepository) ChangePassword(ctx context.Context, userID int, hash string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, hash)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUsersRepositoryMockRecorder) ChangePassword(ctx, userID, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUsersRepository)(nil).ChangePassword), ctx, userID, hash)
+}
+
 // Create mocks base method.
 func (m *MockUsersRepository) Create(ctx context.Context, u *model.User) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +71,50 @@ func (mr *MockUsersRepositoryMockRecorder) Create(ctx, u any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUsersRepository)(nil).Create), ctx, u)
 }
 
+// Erase mocks base method.
+func (m *MockUsersRepository) Erase(ctx context.Context, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Erase", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Erase indicates an expected call of Erase.
+func (mr *MockUsersRepositoryMockRecorder) Erase(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Erase", reflect.TypeOf((*MockUsersRepository)(nil).Erase), ctx, userID)
+}
+
+// ExportData mocks base method.
+func (m *MockUsersRepository) ExportData(ctx context.Context, userID int) (*model.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportData", ctx, userID)
+	ret0, _ := ret[0].(*model.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportData indicates an expected call of ExportData.
+func (mr *MockUsersRepositoryMockRecorder) ExportData(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportData", reflect.TypeOf((*MockUsersRepository)(nil).ExportData), ctx, userID)
+}
+
+// GetByID mocks base method.
+func (m *MockUsersRepository) GetByID(ctx context.Context, id int) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUsersRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUsersRepository)(nil).GetByID), ctx, id)
+}
+
 // GetByLogin mocks base method.
 func (m *MockUsersRepository) GetByLogin(ctx context.Context, login string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -70,3 +129,31 @@ func (mr *MockUsersRepositoryMockRecorder) GetByLogin(ctx, login any) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByLogin", reflect.TypeOf((*MockUsersRepository)(nil).GetByLogin), ctx, login)
 }
+
+// SetRoles mocks base method.
+func (m *MockUsersRepository) SetRoles(ctx context.Context, login string, roles []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRoles", ctx, login, roles)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRoles indicates an expected call of SetRoles.
+func (mr *MockUsersRepositoryMockRecorder) SetRoles(ctx, login, roles any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRoles", reflect.TypeOf((*MockUsersRepository)(nil).SetRoles), ctx, login, roles)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *MockUsersRepository) UpdatePasswordHash(ctx context.Context, userID int, oldHash, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", ctx, userID, oldHash, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockUsersRepositoryMockRecorder) UpdatePasswordHash(ctx, userID, oldHash, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockUsersRepository)(nil).UpdatePasswordHash), ctx, userID, oldHash, hash)
+}
@@ -1,24 +1,219 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 
-const bcryptCost = 12
+	"github.com/fragpit/gophermart/internal/model"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
 
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+const argon2IDPrefix = "$argon2id$"
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// Argon2Params параметры argon2id. Memory в KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// PasswordHasher хеширует пароли argon2id в формате PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>. Алгоритм, его версия и
+// параметры хранятся в самом хеше, поэтому хеши с прежними параметрами и
+// bcrypt ($2a$, $2b$, $2y$) продолжают проверяться.
+//
+// Каждое вычисление argon2id занимает Memory KiB, поэтому одновременно
+// выполняется не больше limit хеширований и проверок; остальные сразу
+// получают model.ErrPasswordHasherBusy.
+type PasswordHasher struct {
+	params Argon2Params
+	slots  chan struct{}
+	dummy  func() string
+}
+
+// NewPasswordHasher limit - число одновременных вычислений, 0 - без
+// ограничения.
+func NewPasswordHasher(params Argon2Params, limit int) *PasswordHasher {
+	h := &PasswordHasher{params: params}
+	if limit > 0 {
+		h.slots = make(chan struct{}, limit)
+	}
+	h.dummy = sync.OnceValue(func() string {
+		hash, _ := h.hash("gophermart-dummy-password")
+		return hash
+	})
+	return h
+}
+
+// WithPasswordHasher хеширование паролей с заданными параметрами argon2id.
+func WithPasswordHasher(h *PasswordHasher) Option {
+	return func(a *AuthService) {
+		a.hasher = h
+	}
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	release, err := h.acquire()
 	if err != nil {
 		return "", err
 	}
-	return string(hash), nil
+	defer release()
+
+	return h.hash(password)
 }
 
-func ComparePasswordHash(password, hash string) bool {
+func (h *PasswordHasher) hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey(
+		[]byte(password),
+		salt,
+		h.params.Iterations,
+		h.params.Memory,
+		h.params.Parallelism,
+		h.params.KeyLength,
+	)
+
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2IDPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		enc.EncodeToString(salt),
+		enc.EncodeToString(key),
+	), nil
+}
+
+// Verify сравнивает пароль с хешем. needsRehash - хеш bcrypt или argon2id с
+// параметрами, отличными от текущих: после успешной проверки его стоит
+// пересчитать. Ошибка - только model.ErrPasswordHasherBusy.
+func (h *PasswordHasher) Verify(
+	password, hash string,
+) (ok, needsRehash bool, err error) {
+	release, err := h.acquire()
+	if err != nil {
+		return false, false, err
+	}
+	defer release()
+
+	if strings.HasPrefix(hash, argon2IDPrefix) {
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false, false, nil
+		}
+
+		got := argon2.IDKey(
+			[]byte(password),
+			salt,
+			params.Iterations,
+			params.Memory,
+			params.Parallelism,
+			params.KeyLength,
+		)
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false, nil
+		}
+
+		return true, params != h.params, nil
+	}
+
 	if err := bcrypt.CompareHashAndPassword(
 		[]byte(hash),
 		[]byte(password),
 	); err != nil {
-		return false
+		return false, false, nil
+	}
+
+	return true, true, nil
+}
+
+// Dummy хеш для сравнения с паролем неизвестного пользователя, чтобы время
+// ответа не выдавало, существует ли логин.
+func (h *PasswordHasher) Dummy() string {
+	return h.dummy()
+}
+
+// acquire занимает слот вычисления без ожидания.
+func (h *PasswordHasher) acquire() (func(), error) {
+	if h.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case h.slots <- struct{}{}:
+		return func() { <-h.slots }, nil
+	default:
+		return nil, model.ErrPasswordHasherBusy
+	}
+}
+
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, hash
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+	if version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf(
+			"%w: argon2 version %d",
+			ErrUnknownHashFormat,
+			version,
+		)
+	}
+
+	var p Argon2Params
+	if _, err := fmt.Sscanf(
+		parts[3],
+		"m=%d,t=%d,p=%d",
+		&p.Memory,
+		&p.Iterations,
+		&p.Parallelism,
+	); err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
+	}
+	key, err := enc.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, ErrUnknownHashFormat
 	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
 
-	return true
+	return p, salt, key, nil
 }
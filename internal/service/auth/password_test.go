@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+func TestPasswordHasher_HashVerify(t *testing.T) {
+	h := NewPasswordHasher(testArgon2Params(), 0)
+
+	hash, err := h.Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+	other, err := h.Hash("correct horse battery staple")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other, "salt is random")
+
+	ok, rehash, _ := h.Verify("correct horse battery staple", hash)
+	assert.True(t, ok)
+	assert.False(t, rehash)
+
+	ok, _, _ = h.Verify("wrong", hash)
+	assert.False(t, ok)
+}
+
+func TestPasswordHasher_LongPassword(t *testing.T) {
+	h := NewPasswordHasher(testArgon2Params(), 0)
+
+	// bcrypt учитывает только первые 72 байта
+	long := strings.Repeat("я", 64)
+	hash, err := h.Hash(long)
+	require.NoError(t, err)
+
+	ok, _, _ := h.Verify(long[:len(long)-2]+"ю", hash)
+	assert.False(t, ok)
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	old := NewPasswordHasher(testArgon2Params(), 0)
+	oldHash, err := old.Hash("pass")
+	require.NoError(t, err)
+
+	params := testArgon2Params()
+	params.Iterations = 2
+	h := NewPasswordHasher(params, 0)
+
+	ok, rehash, _ := h.Verify("pass", oldHash)
+	assert.True(t, ok, "hash with previous params still verifies")
+	assert.True(t, rehash)
+
+	bcrypted, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ok, rehash, _ = h.Verify("pass", string(bcrypted))
+	assert.True(t, ok, "bcrypt hash still verifies")
+	assert.True(t, rehash)
+
+	ok, rehash, _ = h.Verify("wrong", string(bcrypted))
+	assert.False(t, ok)
+	assert.False(t, rehash)
+}
+
+func TestPasswordHasher_MalformedHash(t *testing.T) {
+	h := NewPasswordHasher(testArgon2Params(), 0)
+
+	for _, hash := range []string{
+		"",
+		"plain",
+		"$argon2id$v=19$m=64,t=1,p=1$salt",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$aGFzaA",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$aGFzaA",
+	} {
+		ok, rehash, _ := h.Verify("pass", hash)
+		assert.False(t, ok, hash)
+		assert.False(t, rehash, hash)
+	}
+}
+
+func TestPasswordHasher_Dummy(t *testing.T) {
+	h := NewPasswordHasher(testArgon2Params(), 0)
+
+	assert.Equal(t, h.Dummy(), h.Dummy())
+	ok, _, _ := h.Verify("pass", h.Dummy())
+	assert.False(t, ok)
+}
+
+func TestPasswordHasher_Limit(t *testing.T) {
+	h := NewPasswordHasher(testArgon2Params(), 1)
+	hash, err := h.Hash("pass")
+	require.NoError(t, err)
+
+	// слот занят другим запросом
+	release, err := h.acquire()
+	require.NoError(t, err)
+
+	_, err = h.Hash("pass")
+	assert.ErrorIs(t, err, model.ErrPasswordHasherBusy)
+	_, _, err = h.Verify("pass", hash)
+	assert.ErrorIs(t, err, model.ErrPasswordHasherBusy)
+	assert.NotEmpty(t, h.Dummy(), "dummy hash does not take a slot")
+
+	release()
+	ok, _, err := h.Verify("pass", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+const (
+	MinPasswordLength = 12
+	MaxPasswordLength = 64
+	// minLoginInPassword логины короче не проверяются: иначе логин из
+	// одной-двух букв запрещал бы почти любой пароль.
+	minLoginInPassword = 3
+)
+
+// PasswordRule правило политики паролей. nil - пароль правилу
+// соответствует.
+type PasswordRule interface {
+	Check(login, password string) *model.PasswordViolation
+}
+
+// PasswordRuleFunc функция как PasswordRule.
+type PasswordRuleFunc func(login, password string) *model.PasswordViolation
+
+func (f PasswordRuleFunc) Check(
+	login, password string,
+) *model.PasswordViolation {
+	return f(login, password)
+}
+
+// PasswordPolicy проверяет пароль всеми правилами и возвращает все
+// нарушения сразу, а не первое.
+type PasswordPolicy struct {
+	rules []PasswordRule
+}
+
+func NewPasswordPolicy(rules ...PasswordRule) *PasswordPolicy {
+	return &PasswordPolicy{rules: rules}
+}
+
+// DefaultPasswordPolicy длина 12-64 символа и пароль без логина.
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return NewPasswordPolicy(
+		LengthRule(MinPasswordLength, MaxPasswordLength),
+		NoLoginRule(),
+	)
+}
+
+// WithPasswordPolicy политика паролей при регистрации и смене пароля.
+func WithPasswordPolicy(p *PasswordPolicy) Option {
+	return func(a *AuthService) {
+		a.policy = p
+	}
+}
+
+// Validate возвращает *model.PasswordPolicyError со всеми нарушениями.
+func (p *PasswordPolicy) Validate(login, password string) error {
+	var violations []model.PasswordViolation
+	for _, r := range p.rules {
+		if v := r.Check(login, password); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &model.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// LengthRule длина пароля в символах (не байтах).
+func LengthRule(minLen, maxLen int) PasswordRule {
+	return PasswordRuleFunc(func(_, password string) *model.PasswordViolation {
+		n := utf8.RuneCountInString(password)
+		if n < minLen || n > maxLen {
+			return &model.PasswordViolation{
+				Rule: "length",
+				Message: fmt.Sprintf(
+					"password must be %d to %d characters long",
+					minLen,
+					maxLen,
+				),
+			}
+		}
+		return nil
+	})
+}
+
+// CharClassesRule пароль содержит символы не менее чем minClasses классов
+// из четырёх: строчные и заглавные буквы, цифры, прочие символы.
+func CharClassesRule(minClasses int) PasswordRule {
+	return PasswordRuleFunc(func(_, password string) *model.PasswordViolation {
+		var lower, upper, digit, other bool
+		for _, r := range password {
+			switch {
+			case unicode.IsLower(r):
+				lower = true
+			case unicode.IsUpper(r):
+				upper = true
+			case unicode.IsDigit(r):
+				digit = true
+			default:
+				other = true
+			}
+		}
+
+		classes := 0
+		for _, ok := range []bool{lower, upper, digit, other} {
+			if ok {
+				classes++
+			}
+		}
+
+		if classes < minClasses {
+			return &model.PasswordViolation{
+				Rule: "char_classes",
+				Message: fmt.Sprintf(
+					"password must contain at least %d of: lowercase, "+
+						"uppercase, digits, symbols",
+					minClasses,
+				),
+			}
+		}
+		return nil
+	})
+}
+
+// NoLoginRule пароль не содержит логин без учёта регистра.
+func NoLoginRule() PasswordRule {
+	return PasswordRuleFunc(func(login, password string) *model.PasswordViolation {
+		if utf8.RuneCountInString(login) < minLoginInPassword {
+			return nil
+		}
+		if strings.Contains(strings.ToLower(password), strings.ToLower(login)) {
+			return &model.PasswordViolation{
+				Rule:    "contains_login",
+				Message: "password must not contain the login",
+			}
+		}
+		return nil
+	})
+}
+
+// DenyListRule пароль не входит в список утёкших или распространённых
+// паролей (без учёта регистра).
+func DenyListRule(passwords []string) PasswordRule {
+	deny := make(map[string]struct{}, len(passwords))
+	for _, p := range passwords {
+		deny[strings.ToLower(p)] = struct{}{}
+	}
+
+	return PasswordRuleFunc(func(_, password string) *model.PasswordViolation {
+		if _, ok := deny[strings.ToLower(password)]; ok {
+			return &model.PasswordViolation{
+				Rule:    "common_password",
+				Message: "password is too common or known to be breached",
+			}
+		}
+		return nil
+	})
+}
+
+// LoadDenyList читает список паролей из файла: один пароль на строку,
+// пустые строки и строки с # пропускаются.
+func LoadDenyList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open password deny list: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var passwords []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read password deny list: %w", err)
+	}
+
+	return passwords, nil
+}
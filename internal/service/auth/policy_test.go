@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := NewPasswordPolicy(
+		LengthRule(MinPasswordLength, MaxPasswordLength),
+		NoLoginRule(),
+		CharClassesRule(3),
+		DenyListRule([]string{"Password1234!"}),
+	)
+
+	tests := []struct {
+		name      string
+		login     string
+		password  string
+		wantRules []string
+	}{
+		{
+			name:     "valid",
+			login:    "alice",
+			password: "Correct-horse-42",
+		},
+		{
+			name:      "too short and too simple",
+			login:     "alice",
+			password:  "short",
+			wantRules: []string{"length", "char_classes"},
+		},
+		{
+			name:      "too long",
+			login:     "alice",
+			password:  "Aa1-" + strings.Repeat("a", MaxPasswordLength),
+			wantRules: []string{"length"},
+		},
+		{
+			name:      "contains login",
+			login:     "Alice",
+			password:  "my-ALICE-pass-42",
+			wantRules: []string{"contains_login"},
+		},
+		{
+			name:     "short login is not checked",
+			login:    "al",
+			password: "Always-al-42-pass",
+		},
+		{
+			name:      "common password",
+			login:     "alice",
+			password:  "password1234!",
+			wantRules: []string{"common_password"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.login, tt.password)
+			if len(tt.wantRules) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, model.ErrPasswordPolicyViolated)
+			var policyErr *model.PasswordPolicyError
+			require.ErrorAs(t, err, &policyErr)
+
+			var rules []string
+			for _, v := range policyErr.Violations {
+				assert.NotEmpty(t, v.Message)
+				rules = append(rules, v.Rule)
+			}
+			assert.Equal(t, tt.wantRules, rules)
+		})
+	}
+}
+
+func TestLoadDenyList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords.txt")
+	data := "# top passwords\n123456789012\n\n  qwertyuiopas  \n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	passwords, err := LoadDenyList(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"123456789012", "qwertyuiopas"}, passwords)
+
+	_, err = LoadDenyList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+// LoginThrottle ограничение перебора паролей. После MaxLoginFailures
+// неудачных попыток на логин (MaxIPFailures на IP клиента) за Window вход
+// по этому ключу блокируется на Lockout; каждая следующая блокировка
+// подряд вдвое длиннее, не более MaxLockout. Нулевой порог отключает
+// ограничение по соответствующему ключу.
+type LoginThrottle struct {
+	MaxLoginFailures int
+	MaxIPFailures    int
+	Window           time.Duration
+	Lockout          time.Duration
+	MaxLockout       time.Duration
+}
+
+// WithLoginThrottle включает ограничение перебора паролей при входе.
+func WithLoginThrottle(
+	repo model.LoginThrottleRepository,
+	policy LoginThrottle,
+) Option {
+	return func(a *AuthService) {
+		a.throttle = repo
+		a.throttlePolicy = policy
+	}
+}
+
+func (p LoginThrottle) threshold(scope string) int {
+	if scope == model.ThrottleScopeIP {
+		return p.MaxIPFailures
+	}
+	return p.MaxLoginFailures
+}
+
+// lockoutFor длительность блокировки после lockouts предыдущих подряд.
+func (p LoginThrottle) lockoutFor(lockouts int) time.Duration {
+	d := p.Lockout
+	for range lockouts {
+		if d >= p.MaxLockout {
+			break
+		}
+		d *= 2
+	}
+	return min(d, p.MaxLockout)
+}
+
+func (a *AuthService) throttleKeys(
+	login, clientIP string,
+) []model.LoginThrottleKey {
+	if a.throttle == nil {
+		return nil
+	}
+
+	var keys []model.LoginThrottleKey
+	if a.throttlePolicy.MaxLoginFailures > 0 {
+		keys = append(keys, model.LoginThrottleKey{
+			Scope:   model.ThrottleScopeLogin,
+			Subject: login,
+		})
+	}
+	if a.throttlePolicy.MaxIPFailures > 0 && clientIP != "" {
+		keys = append(keys, model.LoginThrottleKey{
+			Scope:   model.ThrottleScopeIP,
+			Subject: clientIP,
+		})
+	}
+	return keys
+}
+
+// mfaThrottleKeys ключ для кодов второго фактора, проверяемых у
+// аутентифицированного пользователя. Порог тот же, что у логина.
+func (a *AuthService) mfaThrottleKeys(login string) []model.LoginThrottleKey {
+	if a.throttle == nil || a.throttlePolicy.MaxLoginFailures <= 0 {
+		return nil
+	}
+	return []model.LoginThrottleKey{{
+		Scope:   model.ThrottleScopeMFA,
+		Subject: login,
+	}}
+}
+
+// checkThrottle до проверки пароля: заблокированный ключ не тратит bcrypt
+// и не продлевает блокировку.
+func (a *AuthService) checkThrottle(
+	ctx context.Context,
+	keys []model.LoginThrottleKey,
+) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	until, err := a.throttle.GetLockedUntil(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("failed to check login lockout: %w", err)
+	}
+
+	if wait := time.Until(until); wait > 0 {
+		return &model.LoginThrottledError{RetryAfter: wait}
+	}
+
+	return nil
+}
+
+// loginFailed учитывает неудачную попытку по всем ключам и блокирует
+// ключи, достигшие порога. Ошибки хранилища не меняют ответ клиенту.
+func (a *AuthService) loginFailed(
+	ctx context.Context,
+	keys []model.LoginThrottleKey,
+) {
+	for _, key := range keys {
+		f, err := a.throttle.RecordLoginFailure(
+			ctx,
+			key,
+			a.throttlePolicy.Window,
+		)
+		if err != nil {
+			slog.Error(
+				"failed to record login failure",
+				slog.String("key", key.String()),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		if f.Failures < a.throttlePolicy.threshold(key.Scope) {
+			continue
+		}
+
+		lockout := &model.LoginLockout{
+			LoginThrottleKey: key,
+			Failures:         f.Failures,
+			LockedUntil: time.Now().Add(
+				a.throttlePolicy.lockoutFor(f.Lockouts),
+			),
+		}
+		if err := a.throttle.LockLogin(ctx, lockout); err != nil {
+			slog.Error(
+				"failed to lock login",
+				slog.String("key", key.String()),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		slog.Warn(
+			"login locked after failed attempts",
+			slog.String("scope", key.Scope),
+			slog.String("subject", key.Subject),
+			slog.Int("failures", f.Failures),
+			slog.Time("locked_until", lockout.LockedUntil),
+		)
+	}
+}
+
+// loginSucceeded сбрасывает счётчик логина. Счётчик IP не сбрасывается:
+// иначе перебор чужих паролей можно перемежать входом в свой аккаунт.
+func (a *AuthService) loginSucceeded(
+	ctx context.Context,
+	keys []model.LoginThrottleKey,
+) {
+	for _, key := range keys {
+		if key.Scope != model.ThrottleScopeLogin {
+			continue
+		}
+		if err := a.throttle.ResetLoginFailures(ctx, key); err != nil {
+			slog.Error(
+				"failed to reset login failures",
+				slog.String("key", key.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
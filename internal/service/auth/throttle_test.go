@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	mocks "github.com/fragpit/gophermart/internal/service/auth/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestLoginThrottle_lockoutFor(t *testing.T) {
+	p := LoginThrottle{Lockout: time.Minute, MaxLockout: 10 * time.Minute}
+
+	tests := []struct {
+		lockouts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{3, 8 * time.Minute},
+		{4, 10 * time.Minute},
+		{100, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, p.lockoutFor(tt.lockouts), tt.lockouts)
+	}
+}
+
+func TestAuthService_LoginThrottle(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	hashed, _ := NewPasswordHasher(DefaultArgon2Params(), 0).Hash("pass")
+	policy := LoginThrottle{
+		MaxLoginFailures: 5,
+		MaxIPFailures:    50,
+		Window:           15 * time.Minute,
+		Lockout:          time.Minute,
+		MaxLockout:       time.Hour,
+	}
+	loginKey := model.LoginThrottleKey{
+		Scope:   model.ThrottleScopeLogin,
+		Subject: "user",
+	}
+	ipKey := model.LoginThrottleKey{
+		Scope:   model.ThrottleScopeIP,
+		Subject: "192.0.2.1",
+	}
+	keys := []model.LoginThrottleKey{loginKey, ipKey}
+
+	tests := []struct {
+		name      string
+		password  string
+		prepare   func(*mocks.MockUsersRepository, *mocks.MockLoginThrottleRepository)
+		sessions  func(*mocks.MockSessionsRepository)
+		wantErr   error
+		wantRetry time.Duration
+	}{
+		{
+			name:     "locked",
+			password: "pass",
+			prepare: func(
+				_ *mocks.MockUsersRepository,
+				th *mocks.MockLoginThrottleRepository,
+			) {
+				th.EXPECT().GetLockedUntil(gomock.Any(), keys).
+					Return(time.Now().Add(time.Minute), nil)
+			},
+			wantErr:   model.ErrLoginThrottled,
+			wantRetry: time.Minute,
+		},
+		{
+			name:     "failure below threshold",
+			password: "wrong",
+			prepare: func(
+				r *mocks.MockUsersRepository,
+				th *mocks.MockLoginThrottleRepository,
+			) {
+				th.EXPECT().GetLockedUntil(gomock.Any(), keys).
+					Return(time.Time{}, nil)
+				r.EXPECT().GetByLogin(gomock.Any(), "user").
+					Return(&model.User{ID: 7, PasswordHash: hashed}, nil)
+				th.EXPECT().RecordLoginFailure(gomock.Any(), loginKey, policy.Window).
+					Return(&model.LoginFailures{Failures: 2}, nil)
+				th.EXPECT().RecordLoginFailure(gomock.Any(), ipKey, policy.Window).
+					Return(&model.LoginFailures{Failures: 2}, nil)
+			},
+			wantErr: model.ErrInvalidCredentials,
+		},
+		{
+			name:     "failure reaches threshold",
+			password: "wrong",
+			prepare: func(
+				r *mocks.MockUsersRepository,
+				th *mocks.MockLoginThrottleRepository,
+			) {
+				th.EXPECT().GetLockedUntil(gomock.Any(), keys).
+					Return(time.Time{}, nil)
+				r.EXPECT().GetByLogin(gomock.Any(), "user").
+					Return(&model.User{ID: 7, PasswordHash: hashed}, nil)
+				th.EXPECT().RecordLoginFailure(gomock.Any(), loginKey, policy.Window).
+					Return(&model.LoginFailures{Failures: 5, Lockouts: 2}, nil)
+				th.EXPECT().RecordLoginFailure(gomock.Any(), ipKey, policy.Window).
+					Return(&model.LoginFailures{Failures: 6}, nil)
+				th.EXPECT().LockLogin(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, l *model.LoginLockout) error {
+						assert.Equal(t, loginKey, l.LoginThrottleKey)
+						assert.Equal(t, 5, l.Failures)
+						assert.WithinDuration(
+							t,
+							time.Now().Add(4*time.Minute),
+							l.LockedUntil,
+							time.Second,
+						)
+						return nil
+					})
+			},
+			wantErr: model.ErrInvalidCredentials,
+		},
+		{
+			name:     "storage error does not change response",
+			password: "wrong",
+			prepare: func(
+				r *mocks.MockUsersRepository,
+				th *mocks.MockLoginThrottleRepository,
+			) {
+				th.EXPECT().GetLockedUntil(gomock.Any(), keys).
+					Return(time.Time{}, nil)
+				r.EXPECT().GetByLogin(gomock.Any(), "user").
+					Return(&model.User{ID: 7, PasswordHash: hashed}, nil)
+				th.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any(), policy.Window).
+					Return(nil, errors.New("db down")).Times(2)
+			},
+			wantErr: model.ErrInvalidCredentials,
+		},
+		{
+			name:     "success resets login counter only",
+			password: "pass",
+			prepare: func(
+				r *mocks.MockUsersRepository,
+				th *mocks.MockLoginThrottleRepository,
+			) {
+				th.EXPECT().GetLockedUntil(gomock.Any(), keys).
+					Return(time.Time{}, nil)
+				r.EXPECT().GetByLogin(gomock.Any(), "user").
+					Return(&model.User{ID: 7, PasswordHash: hashed}, nil)
+				th.EXPECT().ResetLoginFailures(gomock.Any(), loginKey).
+					Return(nil)
+			},
+			sessions: func(s *mocks.MockSessionsRepository) {
+				s.EXPECT().CreateRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			repo := mocks.NewMockUsersRepository(ctrl)
+			sessions := mocks.NewMockSessionsRepository(ctrl)
+			throttle := mocks.NewMockLoginThrottleRepository(ctrl)
+			svc := NewAuthService(
+				repo,
+				sessions,
+				testJWT(t),
+				time.Hour,
+				WithLoginThrottle(throttle, policy),
+			)
+
+			tt.prepare(repo, throttle)
+			if tt.sessions != nil {
+				tt.sessions(sessions)
+			}
+
+			tokens, err := svc.Login(
+				context.Background(),
+				"user",
+				tt.password,
+				"192.0.2.1",
+			)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				assert.NotEmpty(t, tokens.AccessToken)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantRetry > 0 {
+				var throttled *model.LoginThrottledError
+				require.ErrorAs(t, err, &throttled)
+				assert.InDelta(
+					t,
+					tt.wantRetry.Seconds(),
+					throttled.RetryAfter.Seconds(),
+					1,
+				)
+			}
+		})
+	}
+}
@@ -5,8 +5,11 @@ import (
 
 	"github.com/fragpit/gophermart/internal/api/handlers"
 	"github.com/fragpit/gophermart/internal/model"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/fragpit/gophermart/internal/service/balance")
+
 var _ handlers.BalanceService = (*BalanceService)(nil)
 
 type BalanceService struct {
@@ -23,6 +26,9 @@ func (b *BalanceService) GetUserBalance(
 	ctx context.Context,
 	userID int,
 ) (model.Kopek, error) {
+	ctx, span := tracer.Start(ctx, "BalanceService.GetUserBalance")
+	defer span.End()
+
 	return b.repo.GetUserBalance(ctx, userID)
 }
 
@@ -30,6 +36,9 @@ func (b *BalanceService) GetWithdrawalsSum(
 	ctx context.Context,
 	userID int,
 ) (model.Kopek, error) {
+	ctx, span := tracer.Start(ctx, "BalanceService.GetWithdrawalsSum")
+	defer span.End()
+
 	return b.repo.GetWithdrawalsSum(ctx, userID)
 }
 
@@ -39,5 +48,8 @@ func (b *BalanceService) WithdrawPoints(
 	orderNum string,
 	sum model.Kopek,
 ) error {
+	ctx, span := tracer.Start(ctx, "BalanceService.WithdrawPoints")
+	defer span.End()
+
 	return b.repo.WithdrawPoints(ctx, userID, orderNum, sum)
 }
@@ -2,23 +2,202 @@ package healthcheck
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"go.opentelemetry.io/otel"
 )
 
+const defaultCheckTimeout = 2 * time.Second
+
+var tracer = otel.Tracer("github.com/fragpit/gophermart/internal/service/healthcheck")
+
 //go:generate mockgen -destination ./mocks/health_repo.go . HealthRepository
 type HealthRepository interface {
+	// Ping одна попытка без повторов: проба не должна зависать.
 	Ping(ctx context.Context) error
+	// MigrationVersion текущая версия схемы и версия последней миграции.
+	MigrationVersion(ctx context.Context) (current, latest int32, err error)
+}
+
+// CheckFunc проверка готовности. Details попадают в отчёт как есть.
+type CheckFunc func(ctx context.Context) (details map[string]any, err error)
+
+// Check именованная проверка /readyz. Critical - неуспех делает сервис
+// неготовым, иначе отчёт только помечается degraded. Нулевой Timeout -
+// defaultCheckTimeout.
+type Check struct {
+	Name     string
+	Timeout  time.Duration
+	Critical bool
+	Func     CheckFunc
 }
 
 type HealthService struct {
 	repo HealthRepository
+
+	mu         sync.RWMutex
+	components map[string]func() string
+	checks     []Check
 }
 
 func NewHealthcheckService(repo HealthRepository) *HealthService {
-	return &HealthService{
-		repo: repo,
+	h := &HealthService{
+		repo:       repo,
+		components: make(map[string]func() string),
 	}
+
+	h.AddCheck(Check{
+		Name:     "database",
+		Timeout:  1 * time.Second,
+		Critical: true,
+		Func: func(ctx context.Context) (map[string]any, error) {
+			return nil, repo.Ping(ctx)
+		},
+	})
+	h.AddCheck(Check{
+		Name:     "migrations",
+		Timeout:  1 * time.Second,
+		Critical: true,
+		Func:     h.checkMigrations,
+	})
+
+	return h
 }
 
 func (h *HealthService) Check(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "HealthService.Check")
+	defer span.End()
+
 	return h.repo.Ping(ctx)
 }
+
+// AddComponent регистрирует компонент, состояние которого отдаётся в
+// /health (например, circuit breaker коллектора).
+func (h *HealthService) AddComponent(name string, state func() string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.components[name] = state
+}
+
+func (h *HealthService) Components() map[string]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	res := make(map[string]string, len(h.components))
+	for name, state := range h.components {
+		res[name] = state()
+	}
+	return res
+}
+
+// AddCheck регистрирует проверку для /readyz.
+func (h *HealthService) AddCheck(c Check) {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultCheckTimeout
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, c)
+}
+
+// Ready параллельно выполняет проверки, каждую со своим таймаутом.
+func (h *HealthService) Ready(ctx context.Context) *model.HealthReport {
+	ctx, span := tracer.Start(ctx, "HealthService.Ready")
+	defer span.End()
+
+	h.mu.RLock()
+	checks := make([]Check, len(h.checks))
+	copy(checks, h.checks)
+	h.mu.RUnlock()
+
+	results := make([]model.HealthCheck, len(checks))
+	wg := &sync.WaitGroup{}
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := &model.HealthReport{
+		Status: model.HealthOK,
+		Checks: make(map[string]model.HealthCheck, len(checks)),
+	}
+	for i, c := range checks {
+		res := results[i]
+		report.Checks[c.Name] = res
+		if res.Status == model.HealthOK {
+			continue
+		}
+		if c.Critical {
+			report.Status = model.HealthFail
+		} else if report.Status == model.HealthOK {
+			report.Status = model.HealthDegraded
+		}
+	}
+
+	return report
+}
+
+// runCheck не ждёт проверку дольше таймаута, даже если она не следит за
+// контекстом.
+func runCheck(ctx context.Context, c Check) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	type result struct {
+		details map[string]any
+		err     error
+	}
+	done := make(chan result, 1)
+
+	start := time.Now()
+	go func() {
+		details, err := c.Func(ctx)
+		done <- result{details: details, err: err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("check timed out after %s", c.Timeout)
+	}
+
+	hc := model.HealthCheck{
+		Status:   model.HealthOK,
+		Critical: c.Critical,
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+		Details:  res.details,
+	}
+	if res.err != nil {
+		hc.Status = model.HealthFail
+		hc.Error = res.err.Error()
+	}
+	return hc
+}
+
+func (h *HealthService) checkMigrations(
+	ctx context.Context,
+) (map[string]any, error) {
+	current, latest, err := h.repo.MigrationVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]any{"current": current, "latest": latest}
+	if current < latest {
+		return details, fmt.Errorf(
+			"migrations not applied: version %d of %d",
+			current,
+			latest,
+		)
+	}
+	return details, nil
+}
@@ -5,8 +5,11 @@ import (
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
+	"github.com/fragpit/gophermart/internal/model"
 	mocks "github.com/fragpit/gophermart/internal/service/healthcheck/mocks"
+	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
 
@@ -30,7 +33,7 @@ func TestHealthService_Check(t *testing.T) {
 			svc := NewHealthcheckService(repo)
 			ctx := context.Background()
 
-			repo.EXPECT().Ping(ctx).Return(tt.pingErr)
+			repo.EXPECT().Ping(gomock.Any()).Return(tt.pingErr)
 
 			err := svc.Check(ctx)
 			if !errors.Is(err, tt.pingErr) {
@@ -43,3 +46,141 @@ func TestHealthService_Check(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthService_Components(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewHealthcheckService(mocks.NewMockHealthRepository(ctrl))
+
+	state := "closed"
+	svc.AddComponent("accrual_breaker", func() string { return state })
+
+	got := svc.Components()
+	if got["accrual_breaker"] != "closed" {
+		t.Fatalf("unexpected components: %v", got)
+	}
+
+	state = "open"
+	got = svc.Components()
+	if got["accrual_breaker"] != "open" {
+		t.Fatalf("unexpected components: %v", got)
+	}
+}
+
+func TestHealthService_Ready(t *testing.T) {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	tests := []struct {
+		name       string
+		pingErr    error
+		current    int32
+		extraErr   error
+		wantStatus string
+		wantChecks map[string]string
+	}{
+		{
+			name:       "ok",
+			current:    5,
+			wantStatus: model.HealthOK,
+			wantChecks: map[string]string{
+				"database":   model.HealthOK,
+				"migrations": model.HealthOK,
+				"accrual":    model.HealthOK,
+			},
+		},
+		{
+			name:       "non-critical check failed",
+			current:    5,
+			extraErr:   errors.New("unreachable"),
+			wantStatus: model.HealthDegraded,
+			wantChecks: map[string]string{
+				"database":   model.HealthOK,
+				"migrations": model.HealthOK,
+				"accrual":    model.HealthFail,
+			},
+		},
+		{
+			name:       "database down",
+			pingErr:    errors.New("ping error"),
+			current:    5,
+			wantStatus: model.HealthFail,
+			wantChecks: map[string]string{
+				"database":   model.HealthFail,
+				"migrations": model.HealthOK,
+				"accrual":    model.HealthOK,
+			},
+		},
+		{
+			name:       "migrations not applied",
+			current:    4,
+			wantStatus: model.HealthFail,
+			wantChecks: map[string]string{
+				"database":   model.HealthOK,
+				"migrations": model.HealthFail,
+				"accrual":    model.HealthOK,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockHealthRepository(ctrl)
+			repo.EXPECT().Ping(gomock.Any()).Return(tt.pingErr)
+			repo.EXPECT().MigrationVersion(gomock.Any()).
+				Return(tt.current, int32(5), nil)
+
+			svc := NewHealthcheckService(repo)
+			svc.AddCheck(Check{
+				Name: "accrual",
+				Func: func(context.Context) (map[string]any, error) {
+					return nil, tt.extraErr
+				},
+			})
+
+			report := svc.Ready(context.Background())
+
+			assert.Equal(t, tt.wantStatus, report.Status)
+			got := make(map[string]string, len(report.Checks))
+			for name, c := range report.Checks {
+				got[name] = c.Status
+			}
+			assert.Equal(t, tt.wantChecks, got)
+		})
+	}
+}
+
+func TestHealthService_ReadyTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockHealthRepository(ctrl)
+	repo.EXPECT().Ping(gomock.Any()).Return(nil)
+	repo.EXPECT().MigrationVersion(gomock.Any()).Return(int32(5), int32(5), nil)
+
+	block := make(chan struct{})
+	defer close(block)
+
+	svc := NewHealthcheckService(repo)
+	svc.AddCheck(Check{
+		Name:     "stuck",
+		Timeout:  50 * time.Millisecond,
+		Critical: true,
+		Func: func(context.Context) (map[string]any, error) {
+			// проверка не следит за контекстом
+			<-block
+			return nil, nil
+		},
+	})
+
+	start := time.Now()
+	report := svc.Ready(context.Background())
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, model.HealthFail, report.Status)
+	assert.Equal(t, model.HealthFail, report.Checks["stuck"].Status)
+	assert.Contains(t, report.Checks["stuck"].Error, "timed out")
+}
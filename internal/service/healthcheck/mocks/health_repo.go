@@ -40,6 +40,22 @@ func (m *MockHealthRepository) EXPECT() *MockHealthRepositoryMockRecorder {
 	return m.recorder
 }
 
+// MigrationVersion mocks base method.
+func (m *MockHealthRepository) MigrationVersion(ctx context.Context) (int32, int32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrationVersion", ctx)
+	ret0, _ := ret[0].(int32)
+	ret1, _ := ret[1].(int32)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MigrationVersion indicates an expected call of MigrationVersion.
+func (mr *MockHealthRepositoryMockRecorder) MigrationVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrationVersion", reflect.TypeOf((*MockHealthRepository)(nil).MigrationVersion), ctx)
+}
+
 // Ping mocks base method.
 func (m *MockHealthRepository) Ping(ctx context.Context) error {
 	m.ctrl.T.Helper()
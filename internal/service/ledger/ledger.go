@@ -0,0 +1,33 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/fragpit/gophermart/internal/api/handlers"
+	"github.com/fragpit/gophermart/internal/model"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/fragpit/gophermart/internal/service/ledger")
+
+var _ handlers.LedgerService = (*LedgerService)(nil)
+
+type LedgerService struct {
+	repo model.LedgerRepository
+}
+
+func NewLedgerService(repo model.LedgerRepository) *LedgerService {
+	return &LedgerService{
+		repo: repo,
+	}
+}
+
+func (l *LedgerService) GetEntriesByUser(
+	ctx context.Context,
+	userID int,
+) ([]model.LedgerEntry, error) {
+	ctx, span := tracer.Start(ctx, "LedgerService.GetEntriesByUser")
+	defer span.End()
+
+	return l.repo.GetEntriesByUserID(ctx, userID)
+}
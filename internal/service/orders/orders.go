@@ -5,8 +5,11 @@ import (
 
 	"github.com/fragpit/gophermart/internal/api/handlers"
 	"github.com/fragpit/gophermart/internal/model"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/fragpit/gophermart/internal/service/orders")
+
 var _ handlers.OrdersService = (*OrdersService)(nil)
 
 type OrdersService struct {
@@ -23,6 +26,9 @@ func (o *OrdersService) GetOrdersByUser(
 	ctx context.Context,
 	userID int,
 ) ([]model.Order, error) {
+	ctx, span := tracer.Start(ctx, "OrdersService.GetOrdersByUser")
+	defer span.End()
+
 	return o.repo.GetOrdersByUserID(ctx, userID)
 }
 
@@ -30,8 +36,13 @@ func (o *OrdersService) AddOrder(
 	ctx context.Context,
 	userID int,
 	orderNumber string,
+	goods []model.Good,
 ) error {
+	ctx, span := tracer.Start(ctx, "OrdersService.AddOrder")
+	defer span.End()
+
 	order := model.NewOrder(userID, orderNumber)
+	order.Goods = goods
 
 	return o.repo.AddOrder(ctx, order)
 }
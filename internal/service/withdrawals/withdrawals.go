@@ -4,8 +4,11 @@ import (
 	"context"
 
 	"github.com/fragpit/gophermart/internal/model"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/fragpit/gophermart/internal/service/withdrawals")
+
 // var _ handlers.WithdrawalsService = (*WithdrawalsService)(nil)
 
 type WithdrawalsService struct {
@@ -24,5 +27,8 @@ func (o *WithdrawalsService) GetWithdrawalsByUser(
 	ctx context.Context,
 	userID int,
 ) ([]model.Withdrawal, error) {
+	ctx, span := tracer.Start(ctx, "WithdrawalsService.GetWithdrawalsByUser")
+	defer span.End()
+
 	return o.repo.GetWithdrawalsByUserID(ctx, userID)
 }
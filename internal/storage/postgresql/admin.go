@@ -0,0 +1,339 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	systemAdjustmentsAccount = "system:adjustments"
+	systemForfeitedAccount   = "system:forfeited"
+	systemSettlementsAccount = "system:settlements"
+)
+
+var _ model.AdminRepository = (*AdminRepo)(nil)
+
+type AdminRepo struct {
+	baseRepo
+}
+
+func (r *AdminRepo) SearchUsers(
+	ctx context.Context,
+	query string,
+	limit int,
+) ([]model.User, error) {
+	ctx, span := startSpan(ctx, "AdminRepo.SearchUsers")
+	defer span.End()
+
+	// % и _ в запросе ищутся как обычные символы
+	pattern := "%" + strings.NewReplacer(
+		`\`, `\\`,
+		"%", `\%`,
+		"_", `\_`,
+	).Replace(query) + "%"
+
+	q := `
+		SELECT id, login, token_version, roles, status, created_at
+		FROM users
+		WHERE login ILIKE $1
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, q, pattern, limit)
+	if err != nil {
+		return nil, fmt.Errorf("users query error: %w", err)
+	}
+	defer rows.Close()
+
+	var users []model.User
+	for rows.Next() {
+		var u model.User
+		if err := rows.Scan(
+			&u.ID,
+			&u.Login,
+			&u.TokenVersion,
+			&u.Roles,
+			&u.Status,
+			&u.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *AdminRepo) AdjustBalance(
+	ctx context.Context,
+	rec *model.AuditRecord,
+) error {
+	ctx, span := startSpan(ctx, "AdminRepo.AdjustBalance")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// FOR SHARE: аккаунт не закроется, пока баланс корректируется
+	var status model.UserStatus
+	qUser := `SELECT status FROM users WHERE id = $1 FOR SHARE`
+	err = tx.QueryRow(ctx, qUser, rec.TargetUserID).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if status == model.UserStatusClosed {
+		return model.ErrAccountClosed
+	}
+
+	if err := ensureUserAccount(ctx, tx, rec.TargetUserID); err != nil {
+		return err
+	}
+
+	qBalance := `
+		UPDATE ledger_accounts
+		SET balance = balance + $2::bigint,
+			updated_at = NOW()
+		WHERE user_id = $1 AND balance + $2::bigint >= 0
+	`
+	tag, err := tx.Exec(ctx, qBalance, rec.TargetUserID, rec.Amount)
+	if err != nil {
+		return fmt.Errorf("failed to adjust balance: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrInsufficientPoints
+	}
+
+	qAudit := `
+		INSERT INTO admin_audit (actor_id, action, target_user_id, amount, reason)
+		VALUES (@actor_id, @action, @target_user_id, @amount, @reason)
+		RETURNING id, created_at
+	`
+	args := pgx.NamedArgs{
+		"actor_id":       rec.ActorID,
+		"action":         rec.Action,
+		"target_user_id": rec.TargetUserID,
+		"amount":         rec.Amount,
+		"reason":         rec.Reason,
+	}
+	if err := tx.QueryRow(ctx, qAudit, args).Scan(
+		&rec.ID,
+		&rec.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+
+	if err := postEntries(
+		ctx,
+		tx,
+		rec.TargetUserID,
+		systemAdjustmentsAccount,
+		model.EntryAdjustment,
+		rec.Amount,
+		fmt.Sprintf("audit:%d", rec.ID),
+	); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AdminRepo) ChangeUserStatus(
+	ctx context.Context,
+	change *model.StatusChange,
+) error {
+	ctx, span := startSpan(ctx, "AdminRepo.ChangeUserStatus")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	userID := change.Audit.TargetUserID
+
+	var current model.UserStatus
+	qUser := `SELECT status FROM users WHERE id = $1 FOR UPDATE`
+	err = tx.QueryRow(ctx, qUser, userID).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !current.CanTransitionTo(change.Status) {
+		return fmt.Errorf(
+			"%w: %s -> %s",
+			model.ErrInvalidStatusTransition,
+			current,
+			change.Status,
+		)
+	}
+
+	qStatus := `UPDATE users SET status = $1 WHERE id = $2`
+	if _, err := tx.Exec(ctx, qStatus, change.Status, userID); err != nil {
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	var remaining model.Kopek
+	if change.Status == model.UserStatusClosed {
+		qBalance := `
+			SELECT balance FROM ledger_accounts
+			WHERE user_id = $1
+			FOR UPDATE
+		`
+		err := tx.QueryRow(ctx, qBalance, userID).Scan(&remaining)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to get balance: %w", err)
+		}
+		change.Audit.Amount = -remaining
+	}
+
+	qAudit := `
+		INSERT INTO admin_audit (actor_id, action, target_user_id, amount, reason)
+		VALUES (@actor_id, @action, @target_user_id, @amount, @reason)
+		RETURNING id, created_at
+	`
+	args := pgx.NamedArgs{
+		"actor_id":       change.Audit.ActorID,
+		"action":         change.Audit.Action,
+		"target_user_id": userID,
+		"amount":         change.Audit.Amount,
+		"reason":         change.Audit.Reason,
+	}
+	if err := tx.QueryRow(ctx, qAudit, args).Scan(
+		&change.Audit.ID,
+		&change.Audit.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+
+	if change.Status == model.UserStatusClosed {
+		if err := closeAccount(
+			ctx,
+			tx,
+			userID,
+			change.Settlement,
+			remaining,
+			fmt.Sprintf("audit:%d", change.Audit.ID),
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// closeAccount списывает остаток баланса проводкой FORFEIT или SETTLEMENT
+// со ссылкой reference, отзывает сессии и удаляет API ключи закрываемого
+// аккаунта.
+func closeAccount(
+	ctx context.Context,
+	tx pgx.Tx,
+	userID int,
+	settlement model.BalanceSettlement,
+	remaining model.Kopek,
+	reference string,
+) error {
+	if remaining > 0 {
+		kind, account := model.EntryForfeit, systemForfeitedAccount
+		if settlement == model.SettlementPayout {
+			kind, account = model.EntrySettlement, systemSettlementsAccount
+		}
+
+		qBalance := `
+			UPDATE ledger_accounts
+			SET balance = 0, updated_at = NOW()
+			WHERE user_id = $1
+		`
+		if _, err := tx.Exec(ctx, qBalance, userID); err != nil {
+			return fmt.Errorf("failed to settle balance: %w", err)
+		}
+
+		if err := postEntries(
+			ctx,
+			tx,
+			userID,
+			account,
+			kind,
+			-remaining,
+			reference,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := revokeUserSessions(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return deleteUserAPIKeys(ctx, tx, userID)
+}
+
+func (r *AdminRepo) GetAuditRecords(
+	ctx context.Context,
+	targetUserID int,
+	limit int,
+) ([]model.AuditRecord, error) {
+	ctx, span := startSpan(ctx, "AdminRepo.GetAuditRecords")
+	defer span.End()
+
+	q := `
+		SELECT id, actor_id, action, target_user_id, amount, reason, created_at
+		FROM admin_audit
+		WHERE $1 = 0 OR target_user_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(ctx, q, targetUserID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("audit query error: %w", err)
+	}
+	defer rows.Close()
+
+	var records []model.AuditRecord
+	for rows.Next() {
+		var rec model.AuditRecord
+		if err := rows.Scan(
+			&rec.ID,
+			&rec.ActorID,
+			&rec.Action,
+			&rec.TargetUserID,
+			&rec.Amount,
+			&rec.Reason,
+			&rec.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return records, nil
+}
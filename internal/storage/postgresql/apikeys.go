@@ -0,0 +1,165 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ model.APIKeysRepository = (*APIKeysRepo)(nil)
+
+type APIKeysRepo struct {
+	baseRepo
+}
+
+func (r *APIKeysRepo) CreateAPIKey(ctx context.Context, k *model.APIKey) error {
+	ctx, span := startSpan(ctx, "APIKeysRepo.CreateAPIKey")
+	defer span.End()
+
+	q := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES (@user_id, @name, @prefix, @key_hash, @scopes, @expires_at)
+		RETURNING id, created_at
+	`
+
+	args := pgx.NamedArgs{
+		"user_id":    k.UserID,
+		"name":       k.Name,
+		"prefix":     k.Prefix,
+		"key_hash":   k.KeyHash,
+		"scopes":     k.Scopes,
+		"expires_at": k.ExpiresAt,
+	}
+
+	if err := r.db.QueryRow(ctx, q, args).Scan(&k.ID, &k.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *APIKeysRepo) GetAPIKeysByUserID(
+	ctx context.Context,
+	userID int,
+) ([]model.APIKey, error) {
+	ctx, span := startSpan(ctx, "APIKeysRepo.GetAPIKeysByUserID")
+	defer span.End()
+
+	q := `
+		SELECT id, user_id, name, prefix, key_hash, scopes, expires_at,
+			last_used_at, created_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("api keys query error: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeysRepo) GetAPIKeyByHash(
+	ctx context.Context,
+	hash string,
+) (*model.APIKey, error) {
+	ctx, span := startSpan(ctx, "APIKeysRepo.GetAPIKeyByHash")
+	defer span.End()
+
+	q := `
+		SELECT k.id, k.user_id, k.name, k.prefix, k.key_hash, k.scopes,
+			k.expires_at, k.last_used_at, k.created_at, u.status
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1
+	`
+
+	var status model.UserStatus
+	k, err := scanAPIKey(r.db.QueryRow(ctx, q, hash), &status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	k.UserStatus = status
+
+	return k, nil
+}
+
+func (r *APIKeysRepo) DeleteAPIKey(ctx context.Context, userID, id int) error {
+	ctx, span := startSpan(ctx, "APIKeysRepo.DeleteAPIKey")
+	defer span.End()
+
+	q := `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`
+	tag, err := r.db.Exec(ctx, q, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+func (r *APIKeysRepo) TouchAPIKey(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "APIKeysRepo.TouchAPIKey")
+	defer span.End()
+
+	q := `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1`
+	if _, err := r.db.Exec(ctx, q, id); err != nil {
+		return fmt.Errorf("failed to update api key last use: %w", err)
+	}
+
+	return nil
+}
+
+// scanAPIKey читает колонки api_keys в порядке CreateAPIKey, extra -
+// колонки запроса после них.
+func scanAPIKey(row pgx.Row, extra ...any) (*model.APIKey, error) {
+	var k model.APIKey
+	dest := []any{
+		&k.ID,
+		&k.UserID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		&k.Scopes,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, fmt.Errorf("failed to scan api key: %w", err)
+	}
+	return &k, nil
+}
+
+// deleteUserAPIKeys удаляет все API ключи пользователя в транзакции tx.
+func deleteUserAPIKeys(ctx context.Context, tx pgx.Tx, userID int) error {
+	q := `DELETE FROM api_keys WHERE user_id = $1`
+	if _, err := tx.Exec(ctx, q, userID); err != nil {
+		return fmt.Errorf("failed to delete api keys: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+//go:build integration
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeys_Integration_DeletedWithSessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	repos := newTestStorage(t)
+
+	tests := []struct {
+		name   string
+		revoke func(userID int) error
+	}{
+		{
+			name: "logout all",
+			revoke: func(userID int) error {
+				return repos.Sessions.RevokeUserSessions(ctx, userID)
+			},
+		},
+		{
+			name: "change password",
+			revoke: func(userID int) error {
+				_, err := repos.Users.ChangePassword(ctx, userID, "new hash")
+				return err
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := repos.Users.Create(ctx, &model.User{
+				Login:        tc.name,
+				PasswordHash: "hash",
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, repos.APIKeys.CreateAPIKey(ctx, &model.APIKey{
+				UserID:    u.ID,
+				Name:      "till",
+				Prefix:    "gm_abcdefgh",
+				KeyHash:   tc.name,
+				Scopes:    []string{model.ScopeOrdersWrite},
+				ExpiresAt: time.Now().Add(time.Hour),
+			}))
+
+			require.NoError(t, tc.revoke(u.ID))
+
+			keys, err := repos.APIKeys.GetAPIKeysByUserID(ctx, u.ID)
+			require.NoError(t, err)
+			assert.Empty(t, keys)
+			_, err = repos.APIKeys.GetAPIKeyByHash(ctx, tc.name)
+			assert.ErrorIs(t, err, model.ErrAPIKeyNotFound)
+		})
+	}
+}
@@ -21,19 +21,14 @@ func (r *BalanceRepo) GetUserBalance(
 	ctx context.Context,
 	userID int,
 ) (model.Kopek, error) {
+	ctx, span := startSpan(ctx, "BalanceRepo.GetUserBalance")
+	defer span.End()
+
 	q := `
-		SELECT (
-			COALESCE((
-				SELECT SUM(o.accrual) FROM orders o
-				WHERE o.user_id = $1 AND o.status = 'PROCESSED'
-			), 0)
-			-
-			COALESCE((
-				SELECT SUM(w.sum) FROM withdrawals w
-				WHERE w.user_id = $1
-			), 0)
-		)
-		::bigint AS balance_kopeks
+		SELECT COALESCE((
+			SELECT balance FROM ledger_accounts
+			WHERE user_id = $1
+		), 0)::bigint AS balance_kopeks
 	`
 	row := r.db.QueryRow(ctx, q, userID)
 
@@ -49,10 +44,14 @@ func (r *BalanceRepo) GetWithdrawalsSum(
 	ctx context.Context,
 	userID int,
 ) (model.Kopek, error) {
+	ctx, span := startSpan(ctx, "BalanceRepo.GetWithdrawalsSum")
+	defer span.End()
+
 	q := `
-		SELECT COALESCE(SUM(sum), 0)::bigint as total_withdrawn_kopeks
-		FROM withdrawals
-		WHERE user_id = $1;
+		SELECT COALESCE((
+			SELECT withdrawn FROM ledger_accounts
+			WHERE user_id = $1
+		), 0)::bigint AS total_withdrawn_kopeks
 	`
 
 	row := r.db.QueryRow(ctx, q, userID)
@@ -71,6 +70,9 @@ func (r *BalanceRepo) WithdrawPoints(
 	orderNum string,
 	sum model.Kopek,
 ) error {
+	ctx, span := startSpan(ctx, "BalanceRepo.WithdrawPoints")
+	defer span.End()
+
 	txRetrier := retry.New(func(err error) bool {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
@@ -91,57 +93,42 @@ func (r *BalanceRepo) WithdrawPoints(
 		}
 		defer func() { _ = tx.Rollback(ctx) }()
 
-		q := `
-			WITH bal AS (
-				SELECT (
-					COALESCE(
-						(
-							SELECT SUM(o.accrual)
-							FROM orders o
-							WHERE o.user_id = $1
-							AND o.status = 'PROCESSED'
-						),
-						0
-					)
-					-
-					COALESCE(
-						(
-							SELECT SUM(w.sum)
-							FROM withdrawals w
-							WHERE w.user_id = $1
-						),
-						0
-					)
-				)::bigint AS balance
-			),
-			ins AS (
-				INSERT INTO withdrawals (user_id, order_number, sum)
-				SELECT
-					$1,
-					$2,
-					$3::bigint
-				FROM bal
-				WHERE bal.balance >= $3::bigint
-				RETURNING id
-			)
-			SELECT EXISTS (SELECT 1 FROM ins) AS ok;
+		qDebit := `
+			UPDATE ledger_accounts
+			SET balance = balance - $2::bigint,
+				withdrawn = withdrawn + $2::bigint,
+				updated_at = NOW()
+			WHERE user_id = $1 AND balance >= $2::bigint
 		`
 
-		var ok bool
-		if err := tx.QueryRow(
-			ctx,
-			q,
-			userID,
-			orderNum,
-			sum,
-		).Scan(&ok); err != nil {
+		tag, err := tx.Exec(ctx, qDebit, userID, sum)
+		if err != nil {
 			return fmt.Errorf("withdraw exec: %w", err)
 		}
-
-		if !ok {
+		if tag.RowsAffected() == 0 {
 			return model.ErrInsufficientPoints
 		}
 
+		qInsert := `
+			INSERT INTO withdrawals (user_id, order_number, sum)
+			VALUES ($1, $2, $3)
+		`
+		if _, err := tx.Exec(ctx, qInsert, userID, orderNum, sum); err != nil {
+			return fmt.Errorf("withdraw exec: %w", err)
+		}
+
+		if err := postEntries(
+			ctx,
+			tx,
+			userID,
+			systemWithdrawalsAccount,
+			model.EntryWithdrawal,
+			-sum,
+			orderNum,
+		); err != nil {
+			return err
+		}
+
 		if err := tx.Commit(ctx); err != nil {
 			return fmt.Errorf("failed to commit tx: %w", err)
 		}
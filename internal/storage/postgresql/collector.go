@@ -2,10 +2,13 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/fragpit/gophermart/internal/model"
 	collector "github.com/fragpit/gophermart/internal/service/accrual-collector"
+	"github.com/jackc/pgx/v5"
 )
 
 var _ collector.CollectorRepository = (*CollectorRepo)(nil)
@@ -19,17 +22,95 @@ func (r *CollectorRepo) SetAccrual(
 	id int,
 	sum model.Kopek,
 ) error {
+	ctx, span := startSpan(ctx, "CollectorRepo.SetAccrual")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
 	q := `
 		UPDATE orders
 		SET accrual = $1,
 			status = $2
-		WHERE id = $3
+		WHERE id = $3 AND status <> $2
+		RETURNING user_id, number
 	`
 
-	if _, err := r.db.Exec(ctx, q, sum, model.StatusProcessed, id); err != nil {
+	var (
+		userID int
+		number string
+	)
+	err = tx.QueryRow(ctx, q, sum, model.StatusProcessed, id).
+		Scan(&userID, &number)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// заказ уже обработан, повторно не начисляем
+		return nil
+	}
+	if err != nil {
 		return fmt.Errorf("failed to update accrual: %w", err)
 	}
 
+	if sum > 0 {
+		// блокировка строки пользователя: закрытие аккаунта (FOR UPDATE)
+		// не проходит между проверкой статуса и начислением
+		var status model.UserStatus
+		qStatus := `SELECT status FROM users WHERE id = $1 FOR SHARE`
+		if err := tx.QueryRow(ctx, qStatus, userID).Scan(&status); err != nil {
+			return fmt.Errorf("failed to get user status: %w", err)
+		}
+
+		if err := ensureUserAccount(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		// закрытый аккаунт баллы не копит: начисление проводится и сразу
+		// сгорает, баланс остаётся нулевым
+		if status != model.UserStatusClosed {
+			qCredit := `
+				UPDATE ledger_accounts
+				SET balance = balance + $2::bigint,
+					updated_at = NOW()
+				WHERE user_id = $1
+			`
+			if _, err := tx.Exec(ctx, qCredit, userID, sum); err != nil {
+				return fmt.Errorf("failed to credit account: %w", err)
+			}
+		}
+
+		if err := postEntries(
+			ctx,
+			tx,
+			userID,
+			systemAccrualAccount,
+			model.EntryAccrual,
+			sum,
+			number,
+		); err != nil {
+			return err
+		}
+
+		if status == model.UserStatusClosed {
+			if err := postEntries(
+				ctx,
+				tx,
+				userID,
+				systemForfeitedAccount,
+				model.EntryForfeit,
+				-sum,
+				number,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
 	return nil
 }
 
@@ -38,6 +119,9 @@ func (r *CollectorRepo) SetStatus(
 	id int,
 	status string,
 ) error {
+	ctx, span := startSpan(ctx, "CollectorRepo.SetStatus")
+	defer span.End()
+
 	q := `
 		UPDATE orders
 		SET status = $1
@@ -51,10 +135,81 @@ func (r *CollectorRepo) SetStatus(
 	return nil
 }
 
+func (r *CollectorRepo) SetRegistration(
+	ctx context.Context,
+	id int,
+	state model.RegistrationState,
+) error {
+	ctx, span := startSpan(ctx, "CollectorRepo.SetRegistration")
+	defer span.End()
+
+	q := `
+		UPDATE orders
+		SET registration = $1
+		WHERE id = $2
+	`
+
+	if _, err := r.db.Exec(ctx, q, state, id); err != nil {
+		return fmt.Errorf("failed to set order registration: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CollectorRepo) ParkStaleOrders(
+	ctx context.Context,
+	maxAttempts int,
+	maxAge time.Duration,
+) (int64, error) {
+	ctx, span := startSpan(ctx, "CollectorRepo.ParkStaleOrders")
+	defer span.End()
+
+	if maxAttempts <= 0 && maxAge <= 0 {
+		return 0, nil
+	}
+
+	q := `
+		UPDATE orders
+		SET parked_at = NOW(),
+			park_reason = CASE
+				WHEN $1::int > 0 AND attempts >= $1::int
+					THEN 'max attempts reached'
+				ELSE 'max age reached'
+			END
+		WHERE status IN ('NEW', 'PROCESSING')
+			AND parked_at IS NULL
+			AND (
+				($1::int > 0 AND attempts >= $1::int)
+				OR
+				(
+					$2::float8 > 0
+					AND COALESCE(requeued_at, uploaded_at)
+						< NOW() - $2::float8 * INTERVAL '1 second'
+				)
+			)
+	`
+
+	tag, err := r.db.Exec(
+		ctx,
+		q,
+		maxAttempts,
+		maxAge.Seconds(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to park stale orders: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
 func (r *CollectorRepo) GetOrdersBatch(
 	ctx context.Context,
 	batchSize int,
+	baseDelay, maxDelay time.Duration,
 ) ([]model.Order, error) {
+	ctx, span := startSpan(ctx, "CollectorRepo.GetOrdersBatch")
+	defer span.End()
+
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to start tx: %w", err)
@@ -64,7 +219,9 @@ func (r *CollectorRepo) GetOrdersBatch(
 	qSelect := `
 		SELECT id FROM orders
 		WHERE status IN ('NEW', 'PROCESSING')
-		ORDER BY last_polled_at NULLS FIRST, id
+			AND parked_at IS NULL
+			AND next_poll_at <= NOW()
+		ORDER BY next_poll_at, id
 		LIMIT $1
 		FOR UPDATE SKIP LOCKED
 	`
@@ -94,7 +251,20 @@ func (r *CollectorRepo) GetOrdersBatch(
 
 	qUpdate := `
 		UPDATE orders AS o
-		SET last_polled_at = NOW()
+		SET last_polled_at = NOW(),
+			-- экспоненциальная задержка: вдвое дольше предыдущей, от base
+			-- до max. attempts здесь не растёт: выборка не значит, что
+			-- запрос отправлен (429, открытый breaker)
+			next_poll_at = NOW() + LEAST(
+				GREATEST(
+					$2::float8,
+					2 * COALESCE(
+						EXTRACT(EPOCH FROM o.next_poll_at - o.last_polled_at)::float8,
+						0
+					)
+				),
+				$3::float8
+			) * INTERVAL '1 second'
 		WHERE o.id = ANY($1)
 		RETURNING
 			o.id,
@@ -102,10 +272,19 @@ func (r *CollectorRepo) GetOrdersBatch(
 			o.number,
 			o.status,
 			o.accrual,
+			o.goods,
+			o.registration,
+			o.attempts,
 			o.uploaded_at
 	`
 
-	rows2, err := tx.Query(ctx, qUpdate, ids)
+	rows2, err := tx.Query(
+		ctx,
+		qUpdate,
+		ids,
+		baseDelay.Seconds(),
+		maxDelay.Seconds(),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tx: %w", err)
 	}
@@ -119,6 +298,9 @@ func (r *CollectorRepo) GetOrdersBatch(
 			&o.Number,
 			&o.Status,
 			&o.Accrual,
+			&o.Goods,
+			&o.Registration,
+			&o.Attempts,
 			&o.UploadedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
@@ -136,3 +318,96 @@ func (r *CollectorRepo) GetOrdersBatch(
 
 	return orders, nil
 }
+
+func (r *CollectorRepo) RecordFailure(
+	ctx context.Context,
+	failure *model.AccrualFailure,
+	park bool,
+) error {
+	ctx, span := startSpan(ctx, "CollectorRepo.RecordFailure")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qInsert := `
+		INSERT INTO accrual_failures
+			(order_id, http_status, response_body, error)
+		VALUES ($1, NULLIF($2, 0), $3, $4)
+	`
+	if _, err := tx.Exec(
+		ctx,
+		qInsert,
+		failure.OrderID,
+		failure.HTTPStatus,
+		failure.Response,
+		failure.Error,
+	); err != nil {
+		return fmt.Errorf("failed to record accrual failure: %w", err)
+	}
+
+	// attempts считает только отправленные и неудачные запросы: по нему
+	// заказ откладывается после MaxAttempts
+	qAttempts := `UPDATE orders SET attempts = attempts + 1 WHERE id = $1`
+	if _, err := tx.Exec(ctx, qAttempts, failure.OrderID); err != nil {
+		return fmt.Errorf("failed to count order attempt: %w", err)
+	}
+
+	if park {
+		qPark := `
+			UPDATE orders
+			SET parked_at = NOW(),
+				park_reason = $2
+			WHERE id = $1 AND parked_at IS NULL
+		`
+		if _, err := tx.Exec(ctx, qPark, failure.OrderID, failure.Error); err != nil {
+			return fmt.Errorf("failed to park order: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CollectorRepo) GetQueueDepth(
+	ctx context.Context,
+) (map[model.OrderStatus]int64, error) {
+	ctx, span := startSpan(ctx, "CollectorRepo.GetQueueDepth")
+	defer span.End()
+
+	q := `
+		SELECT status, COUNT(*)
+		FROM orders
+		WHERE status IN ('NEW', 'PROCESSING') AND parked_at IS NULL
+		GROUP BY status
+	`
+
+	rows, err := r.db.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("queue depth query error: %w", err)
+	}
+	defer rows.Close()
+
+	depth := make(map[model.OrderStatus]int64)
+	for rows.Next() {
+		var (
+			status model.OrderStatus
+			count  int64
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		depth[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return depth, nil
+}
@@ -14,17 +14,31 @@ type HealthRepo struct {
 }
 
 func (r *HealthRepo) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "HealthRepo.Ping")
+	defer span.End()
+
 	if r.db == nil {
 		return fmt.Errorf("database connection not initialized")
 	}
 
-	op := func(ctx context.Context) error {
-		return r.db.Ping(ctx)
-	}
+	// без retrier: повторы 1s/3s/5s превращают пробу в девятисекундную
+	return r.db.Ping(ctx)
+}
 
-	if err := r.retrier.Do(ctx, op); err != nil {
-		return err
+func (r *HealthRepo) MigrationVersion(
+	ctx context.Context,
+) (int32, int32, error) {
+	ctx, span := startSpan(ctx, "HealthRepo.MigrationVersion")
+	defer span.End()
+
+	var current int32
+	query := fmt.Sprintf("SELECT version FROM %s", migrationsTable)
+	if err := r.db.QueryRow(ctx, query).Scan(&current); err != nil {
+		return 0, 0, fmt.Errorf("failed to get migration version: %w", err)
 	}
 
-	return nil
+	ms := migrations()
+	latest := ms[len(ms)-1].Sequence
+
+	return current, latest, nil
 }
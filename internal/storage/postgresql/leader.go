@@ -0,0 +1,87 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	collector "github.com/fragpit/gophermart/internal/service/accrual-collector"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// collectorLockKey ключ advisory lock, которым реплики выбирают лидера
+// для коллектора accrual.
+const collectorLockKey int64 = 0x676d5f636f6c6c // "gm_coll"
+
+var _ collector.LeaderLock = (*LeaderLockRepo)(nil)
+
+// LeaderLockRepo session-level advisory lock. Лок живёт, пока открыто
+// соединение, поэтому оно удерживается вне пула до Release.
+type LeaderLockRepo struct {
+	baseRepo
+
+	mu   sync.Mutex
+	conn *pgxpool.Conn
+}
+
+func (r *LeaderLockRepo) TryAcquire(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn != nil {
+		if err := r.conn.Ping(ctx); err == nil {
+			return true, nil
+		}
+		// соединение потеряно, вместе с ним и лок
+		_ = r.conn.Conn().Close(ctx)
+		r.conn.Release()
+		r.conn = nil
+	}
+
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRow(
+		ctx,
+		"SELECT pg_try_advisory_lock($1)",
+		collectorLockKey,
+	).Scan(&locked); err != nil {
+		conn.Release()
+		return false, fmt.Errorf("failed to try advisory lock: %w", err)
+	}
+
+	if !locked {
+		conn.Release()
+		return false, nil
+	}
+
+	r.conn = conn
+	return true, nil
+}
+
+func (r *LeaderLockRepo) Release(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return nil
+	}
+	defer func() {
+		r.conn.Release()
+		r.conn = nil
+	}()
+
+	if _, err := r.conn.Exec(
+		ctx,
+		"SELECT pg_advisory_unlock($1)",
+		collectorLockKey,
+	); err != nil {
+		_ = r.conn.Conn().Close(ctx)
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+
+	return nil
+}
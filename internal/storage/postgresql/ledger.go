@@ -0,0 +1,135 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	systemAccrualAccount     = "system:accrual"
+	systemWithdrawalsAccount = "system:withdrawals"
+)
+
+var _ model.LedgerRepository = (*LedgerRepo)(nil)
+
+type LedgerRepo struct {
+	baseRepo
+}
+
+func (r *LedgerRepo) GetEntriesByUserID(
+	ctx context.Context,
+	userID int,
+) ([]model.LedgerEntry, error) {
+	ctx, span := startSpan(ctx, "LedgerRepo.GetEntriesByUserID")
+	defer span.End()
+
+	q := `
+		SELECT e.id, e.kind, e.amount, e.reference, e.created_at
+		FROM ledger_entries e
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE a.user_id = $1
+		ORDER BY e.id DESC
+	`
+
+	var (
+		entryID   int
+		kind      model.LedgerEntryKind
+		amount    model.Kopek
+		reference string
+		createdAt time.Time
+	)
+	rows, err := r.db.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ledger query error: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.LedgerEntry
+	for rows.Next() {
+		if err := rows.Scan(
+			&entryID,
+			&kind,
+			&amount,
+			&reference,
+			&createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		entry := model.LedgerEntry{
+			ID:        entryID,
+			UserID:    userID,
+			Kind:      kind,
+			Amount:    amount,
+			Reference: reference,
+			CreatedAt: createdAt,
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ensureUserAccount создаёт счёт пользователя, если его ещё нет.
+func ensureUserAccount(ctx context.Context, tx pgx.Tx, userID int) error {
+	q := `
+		INSERT INTO ledger_accounts (user_id, code)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO NOTHING
+	`
+	code := fmt.Sprintf("user:%d", userID)
+	if _, err := tx.Exec(ctx, q, userID, code); err != nil {
+		return fmt.Errorf("failed to create ledger account: %w", err)
+	}
+	return nil
+}
+
+// postEntries записывает пару проводок: amount на счёт пользователя и -amount
+// на системный счёт-корреспондент. Баланс пользователя должен быть уже
+// обновлён вызывающим кодом в той же транзакции.
+func postEntries(
+	ctx context.Context,
+	tx pgx.Tx,
+	userID int,
+	systemAccount string,
+	kind model.LedgerEntryKind,
+	amount model.Kopek,
+	reference string,
+) error {
+	q := `
+		WITH t AS (SELECT nextval('ledger_tx_seq') AS tx_id)
+		INSERT INTO ledger_entries (tx_id, account_id, kind, amount, reference)
+		SELECT t.tx_id, a.id, $3, $4::bigint, $5
+		FROM t, ledger_accounts a WHERE a.user_id = $1
+		UNION ALL
+		SELECT t.tx_id, a.id, $3, -$4::bigint, $5
+		FROM t, ledger_accounts a WHERE a.code = $2
+	`
+
+	tag, err := tx.Exec(
+		ctx,
+		q,
+		userID,
+		systemAccount,
+		kind,
+		amount,
+		reference,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to post ledger entries: %w", err)
+	}
+	if tag.RowsAffected() != 2 {
+		return fmt.Errorf(
+			"failed to post ledger entries: unbalanced transaction, rows=%d",
+			tag.RowsAffected(),
+		)
+	}
+
+	return nil
+}
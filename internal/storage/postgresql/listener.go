@@ -0,0 +1,53 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	collector "github.com/fragpit/gophermart/internal/service/accrual-collector"
+	"github.com/jackc/pgx/v5"
+)
+
+// newOrdersChannel канал NOTIFY, в который AddOrder пишет номер заказа.
+const newOrdersChannel = "gophermart_new_orders"
+
+const listenerCloseTimeout = 5 * time.Second
+
+var _ collector.OrdersListener = (*OrdersListenerRepo)(nil)
+
+// OrdersListenerRepo слушает newOrdersChannel на отдельном соединении вне
+// пула: LISTEN привязан к сессии.
+type OrdersListenerRepo struct {
+	baseRepo
+}
+
+func (r *OrdersListenerRepo) Listen(
+	ctx context.Context,
+	notify func(),
+) error {
+	conn, err := pgx.ConnectConfig(ctx, r.db.Config().ConnConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect listener: %w", err)
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(
+			context.Background(),
+			listenerCloseTimeout,
+		)
+		defer cancel()
+		_ = conn.Close(closeCtx)
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+newOrdersChannel); err != nil {
+		return fmt.Errorf("failed to listen %s: %w", newOrdersChannel, err)
+	}
+
+	notify()
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+		notify()
+	}
+}
@@ -0,0 +1,108 @@
+package postgresql
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var _ prometheus.Collector = (*poolStatsCollector)(nil)
+
+// poolStatsCollector отдаёт pgxpool.Stat() в момент сбора метрик.
+type poolStatsCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+}
+
+func newPoolStatsCollector(pool *pgxpool.Pool) *poolStatsCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName("gophermart", "db_pool", name),
+			help,
+			nil,
+			nil,
+		)
+	}
+
+	return &poolStatsCollector{
+		pool: pool,
+
+		acquiredConns: desc(
+			"acquired_conns",
+			"Number of currently acquired connections.",
+		),
+		idleConns: desc(
+			"idle_conns",
+			"Number of currently idle connections.",
+		),
+		constructingConns: desc(
+			"constructing_conns",
+			"Number of connections being established.",
+		),
+		totalConns: desc(
+			"total_conns",
+			"Total number of connections in the pool.",
+		),
+		maxConns: desc(
+			"max_conns",
+			"Maximum size of the pool.",
+		),
+		acquireCount: desc(
+			"acquire_total",
+			"Number of successful connection acquires.",
+		),
+		emptyAcquireCount: desc(
+			"empty_acquire_total",
+			"Number of acquires that had to wait for a connection.",
+		),
+		canceledAcquireCount: desc(
+			"canceled_acquire_total",
+			"Number of acquires canceled by context.",
+		),
+		acquireDuration: desc(
+			"acquire_duration_seconds_total",
+			"Total time spent acquiring connections.",
+		),
+	}
+}
+
+func (c *poolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.emptyAcquireCount
+	ch <- c.canceledAcquireCount
+	ch <- c.acquireDuration
+}
+
+func (c *poolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquiredConns, float64(s.AcquiredConns()))
+	gauge(c.idleConns, float64(s.IdleConns()))
+	gauge(c.constructingConns, float64(s.ConstructingConns()))
+	gauge(c.totalConns, float64(s.TotalConns()))
+	gauge(c.maxConns, float64(s.MaxConns()))
+	counter(c.acquireCount, float64(s.AcquireCount()))
+	counter(c.emptyAcquireCount, float64(s.EmptyAcquireCount()))
+	counter(c.canceledAcquireCount, float64(s.CanceledAcquireCount()))
+	counter(c.acquireDuration, s.AcquireDuration().Seconds())
+}
@@ -0,0 +1,334 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ model.MFARepository = (*MFARepo)(nil)
+
+type MFARepo struct {
+	baseRepo
+}
+
+func (r *MFARepo) GetMFA(ctx context.Context, userID int) (*model.MFA, error) {
+	ctx, span := startSpan(ctx, "MFARepo.GetMFA")
+	defer span.End()
+
+	q := `
+		SELECT user_id, secret, last_step, withdraw_required, enabled_at, created_at
+		FROM user_mfa
+		WHERE user_id = $1
+	`
+
+	var m model.MFA
+	err := r.db.QueryRow(ctx, q, userID).Scan(
+		&m.UserID,
+		&m.Secret,
+		&m.LastStep,
+		&m.WithdrawRequired,
+		&m.EnabledAt,
+		&m.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa: %w", err)
+	}
+
+	return &m, nil
+}
+
+func (r *MFARepo) SaveMFASecret(
+	ctx context.Context,
+	userID int,
+	secret string,
+) error {
+	ctx, span := startSpan(ctx, "MFARepo.SaveMFASecret")
+	defer span.End()
+
+	// подтверждённый секрет не перезаписывается: сначала отключение
+	q := `
+		INSERT INTO user_mfa (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret,
+			last_step = 0,
+			created_at = NOW()
+		WHERE user_mfa.enabled_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, q, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+func (r *MFARepo) EnableMFA(
+	ctx context.Context,
+	userID int,
+	step int64,
+	recoveryHashes []string,
+) error {
+	ctx, span := startSpan(ctx, "MFARepo.EnableMFA")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := `
+		UPDATE user_mfa
+		SET enabled_at = NOW(), last_step = $2
+		WHERE user_id = $1 AND enabled_at IS NULL
+	`
+	tag, err := tx.Exec(ctx, q, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrMFANotEnrolled
+	}
+
+	qDelete := `DELETE FROM mfa_recovery_codes WHERE user_id = $1`
+	if _, err := tx.Exec(ctx, qDelete, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	qInsert := `
+		INSERT INTO mfa_recovery_codes (user_id, code_hash)
+		SELECT $1, unnest($2::text[])
+	`
+	if _, err := tx.Exec(ctx, qInsert, userID, recoveryHashes); err != nil {
+		return fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MFARepo) DisableMFA(ctx context.Context, userID int) error {
+	ctx, span := startSpan(ctx, "MFARepo.DisableMFA")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := deleteUserMFA(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MFARepo) UseMFAStep(
+	ctx context.Context,
+	userID int,
+	step int64,
+) error {
+	ctx, span := startSpan(ctx, "MFARepo.UseMFAStep")
+	defer span.End()
+
+	// условие в UPDATE: из двух одновременных запросов с одним кодом
+	// пройдёт только один
+	q := `
+		UPDATE user_mfa
+		SET last_step = $2
+		WHERE user_id = $1 AND last_step < $2
+	`
+	tag, err := r.db.Exec(ctx, q, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to save mfa step: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: code already used", model.ErrInvalidMFACode)
+	}
+
+	return nil
+}
+
+func (r *MFARepo) UseRecoveryCode(
+	ctx context.Context,
+	userID int,
+	hash string,
+) error {
+	ctx, span := startSpan(ctx, "MFARepo.UseRecoveryCode")
+	defer span.End()
+
+	q := `
+		UPDATE mfa_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+	tag, err := r.db.Exec(ctx, q, userID, hash)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+func (r *MFARepo) SetMFAWithdrawRequired(
+	ctx context.Context,
+	userID int,
+	required bool,
+) error {
+	ctx, span := startSpan(ctx, "MFARepo.SetMFAWithdrawRequired")
+	defer span.End()
+
+	q := `
+		UPDATE user_mfa
+		SET withdraw_required = $2
+		WHERE user_id = $1 AND enabled_at IS NOT NULL
+	`
+	tag, err := r.db.Exec(ctx, q, userID, required)
+	if err != nil {
+		return fmt.Errorf("failed to update mfa settings: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrMFANotEnrolled
+	}
+
+	return nil
+}
+
+func (r *MFARepo) CreateMFAChallenge(
+	ctx context.Context,
+	c *model.MFAChallenge,
+) error {
+	ctx, span := startSpan(ctx, "MFARepo.CreateMFAChallenge")
+	defer span.End()
+
+	// заодно чистим истёкшие токены: они уже не пройдут проверку
+	qCleanup := `DELETE FROM mfa_challenges WHERE expires_at < NOW()`
+	if _, err := r.db.Exec(ctx, qCleanup); err != nil {
+		return fmt.Errorf("failed to cleanup mfa challenges: %w", err)
+	}
+
+	q := `
+		INSERT INTO mfa_challenges (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+	if err := r.db.QueryRow(
+		ctx,
+		q,
+		c.UserID,
+		c.TokenHash,
+		c.ExpiresAt,
+	).Scan(&c.ID); err != nil {
+		return fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+
+	return nil
+}
+
+func (r *MFARepo) GetMFAChallenge(
+	ctx context.Context,
+	hash string,
+) (*model.MFAChallenge, error) {
+	ctx, span := startSpan(ctx, "MFARepo.GetMFAChallenge")
+	defer span.End()
+
+	q := `
+		SELECT id, user_id, token_hash, attempts, expires_at
+		FROM mfa_challenges
+		WHERE token_hash = $1 AND expires_at > NOW()
+	`
+
+	var c model.MFAChallenge
+	err := r.db.QueryRow(ctx, q, hash).Scan(
+		&c.ID,
+		&c.UserID,
+		&c.TokenHash,
+		&c.Attempts,
+		&c.ExpiresAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+
+	return &c, nil
+}
+
+func (r *MFARepo) FailMFAChallenge(ctx context.Context, id int) (int, error) {
+	ctx, span := startSpan(ctx, "MFARepo.FailMFAChallenge")
+	defer span.End()
+
+	q := `
+		UPDATE mfa_challenges
+		SET attempts = attempts + 1
+		WHERE id = $1
+		RETURNING attempts
+	`
+
+	var attempts int
+	err := r.db.QueryRow(ctx, q, id).Scan(&attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, model.ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to update mfa challenge: %w", err)
+	}
+
+	return attempts, nil
+}
+
+func (r *MFARepo) DeleteMFAChallenge(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "MFARepo.DeleteMFAChallenge")
+	defer span.End()
+
+	q := `DELETE FROM mfa_challenges WHERE id = $1`
+	tag, err := r.db.Exec(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete mfa challenge: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrInvalidMFAChallenge
+	}
+
+	return nil
+}
+
+// deleteUserMFA удаляет секрет, коды восстановления и незавершённые входы
+// пользователя.
+func deleteUserMFA(ctx context.Context, tx pgx.Tx, userID int) error {
+	for _, table := range []string{
+		"mfa_challenges",
+		"mfa_recovery_codes",
+		"user_mfa",
+	} {
+		q := `DELETE FROM ` + table + ` WHERE user_id = $1`
+		if _, err := tx.Exec(ctx, q, userID); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+	return nil
+}
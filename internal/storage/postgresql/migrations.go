@@ -8,6 +8,8 @@ import (
 	"github.com/jackc/tern/v2/migrate"
 )
 
+const migrationsTable = "metrics_migrations"
+
 func runMigrations(ctx context.Context, conn *pgxpool.Pool) error {
 	poolConn, err := conn.Acquire(ctx)
 	if err != nil {
@@ -15,12 +17,22 @@ func runMigrations(ctx context.Context, conn *pgxpool.Pool) error {
 	}
 	defer poolConn.Release()
 
-	m, err := migrate.NewMigrator(ctx, poolConn.Conn(), "metrics_migrations")
+	m, err := migrate.NewMigrator(ctx, poolConn.Conn(), migrationsTable)
 	if err != nil {
 		return fmt.Errorf("error migrations init: %w", err)
 	}
 
-	m.Migrations = []*migrate.Migration{
+	m.Migrations = migrations()
+
+	if err := m.Migrate(ctx); err != nil {
+		return fmt.Errorf("error applying migrations: %w", err)
+	}
+
+	return nil
+}
+
+func migrations() []*migrate.Migration {
+	return []*migrate.Migration{
 		{
 			Sequence: 1,
 			Name:     "init",
@@ -69,11 +81,411 @@ func runMigrations(ctx context.Context, conn *pgxpool.Pool) error {
 			DROP TABLE IF EXISTS withdrawals;
 			`,
 		},
-	}
+		{
+			Sequence: 2,
+			Name:     "ledger",
+			UpSQL: `
+			CREATE SEQUENCE IF NOT EXISTS ledger_tx_seq;
 
-	if err := m.Migrate(ctx); err != nil {
-		return fmt.Errorf("error applying migrations: %w", err)
-	}
+			-- счета пользователей (user_id NOT NULL) и системные счета-корреспонденты
+			CREATE TABLE IF NOT EXISTS ledger_accounts (
+				id SERIAL PRIMARY KEY,
+				user_id INTEGER UNIQUE REFERENCES users(id),
+				code VARCHAR(64) UNIQUE NOT NULL,
+				balance BIGINT NOT NULL DEFAULT 0, -- stored in kopeks
+				withdrawn BIGINT NOT NULL DEFAULT 0, -- stored in kopeks
+				updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+				CONSTRAINT ledger_accounts_balance_non_negative
+					CHECK (user_id IS NULL OR balance >= 0)
+			);
 
-	return nil
+			CREATE TABLE IF NOT EXISTS ledger_entries (
+				id BIGSERIAL PRIMARY KEY,
+				tx_id BIGINT NOT NULL,
+				account_id INTEGER NOT NULL REFERENCES ledger_accounts(id),
+				kind VARCHAR(32) NOT NULL,
+				amount BIGINT NOT NULL, -- stored in kopeks, signed
+				reference VARCHAR(255) NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_id
+			ON ledger_entries (account_id, id);
+
+			CREATE INDEX IF NOT EXISTS idx_ledger_entries_tx_id
+			ON ledger_entries (tx_id);
+
+			CREATE OR REPLACE FUNCTION ledger_entries_append_only()
+			RETURNS trigger AS $$
+			BEGIN
+				RAISE EXCEPTION 'ledger_entries is append-only';
+			END;
+			$$ LANGUAGE plpgsql;
+
+			CREATE TRIGGER ledger_entries_append_only
+			BEFORE UPDATE OR DELETE ON ledger_entries
+			FOR EACH ROW EXECUTE FUNCTION ledger_entries_append_only();
+
+			INSERT INTO ledger_accounts (code) VALUES
+				('system:accrual'),
+				('system:withdrawals')
+			ON CONFLICT (code) DO NOTHING;
+
+			-- перенос существующих балансов
+			INSERT INTO ledger_accounts (user_id, code, balance, withdrawn)
+			SELECT
+				u.id,
+				'user:' || u.id,
+				COALESCE((
+					SELECT SUM(o.accrual) FROM orders o
+					WHERE o.user_id = u.id AND o.status = 'PROCESSED'
+				), 0)
+				-
+				COALESCE((
+					SELECT SUM(w.sum) FROM withdrawals w
+					WHERE w.user_id = u.id
+				), 0),
+				COALESCE((
+					SELECT SUM(w.sum) FROM withdrawals w
+					WHERE w.user_id = u.id
+				), 0)
+			FROM users u
+			ON CONFLICT (user_id) DO NOTHING;
+
+			WITH src AS MATERIALIZED (
+				SELECT
+					nextval('ledger_tx_seq') AS tx_id,
+					o.user_id,
+					o.number,
+					o.accrual,
+					o.uploaded_at
+				FROM orders o
+				WHERE o.status = 'PROCESSED' AND o.accrual > 0
+			)
+			INSERT INTO ledger_entries
+				(tx_id, account_id, kind, amount, reference, created_at)
+			SELECT src.tx_id, a.id, 'ACCRUAL', src.accrual, src.number, src.uploaded_at
+			FROM src JOIN ledger_accounts a ON a.user_id = src.user_id
+			UNION ALL
+			SELECT src.tx_id, a.id, 'ACCRUAL', -src.accrual, src.number, src.uploaded_at
+			FROM src JOIN ledger_accounts a ON a.code = 'system:accrual';
+
+			WITH src AS MATERIALIZED (
+				SELECT
+					nextval('ledger_tx_seq') AS tx_id,
+					w.user_id,
+					w.order_number,
+					w.sum,
+					w.processed_at
+				FROM withdrawals w
+			)
+			INSERT INTO ledger_entries
+				(tx_id, account_id, kind, amount, reference, created_at)
+			SELECT src.tx_id, a.id, 'WITHDRAWAL', -src.sum, src.order_number, src.processed_at
+			FROM src JOIN ledger_accounts a ON a.user_id = src.user_id
+			UNION ALL
+			SELECT src.tx_id, a.id, 'WITHDRAWAL', src.sum, src.order_number, src.processed_at
+			FROM src JOIN ledger_accounts a ON a.code = 'system:withdrawals';
+			`,
+			DownSQL: `
+			DROP INDEX IF EXISTS idx_ledger_entries_account_id;
+			DROP INDEX IF EXISTS idx_ledger_entries_tx_id;
+
+			DROP TABLE IF EXISTS ledger_entries;
+			DROP TABLE IF EXISTS ledger_accounts;
+			DROP FUNCTION IF EXISTS ledger_entries_append_only();
+			DROP SEQUENCE IF EXISTS ledger_tx_seq;
+			`,
+		},
+		{
+			Sequence: 3,
+			Name:     "accrual_registration",
+			UpSQL: `
+			ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS goods JSONB;
+
+			ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS registration VARCHAR(20)
+			NOT NULL DEFAULT 'PENDING';
+
+			-- заказы, по которым accrual уже отвечал, считаем зарегистрированными
+			UPDATE orders SET registration = 'REGISTERED'
+			WHERE status <> 'NEW';
+			`,
+			DownSQL: `
+			ALTER TABLE orders DROP COLUMN IF EXISTS registration;
+			ALTER TABLE orders DROP COLUMN IF EXISTS goods;
+			`,
+		},
+		{
+			Sequence: 4,
+			Name:     "poll_schedule",
+			UpSQL: `
+			ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS next_poll_at TIMESTAMP WITH TIME ZONE
+			NOT NULL DEFAULT NOW();
+
+			ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
+
+			ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS parked_at TIMESTAMP WITH TIME ZONE;
+
+			ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS park_reason TEXT;
+
+			DROP INDEX IF EXISTS idx_orders_status_last_polled;
+
+			CREATE INDEX IF NOT EXISTS idx_orders_poll_queue
+			ON orders (next_poll_at, id)
+			WHERE status IN ('NEW', 'PROCESSING') AND parked_at IS NULL;
+			`,
+			DownSQL: `
+			DROP INDEX IF EXISTS idx_orders_poll_queue;
+
+			CREATE INDEX IF NOT EXISTS idx_orders_status_last_polled
+			ON orders (status, last_polled_at);
+
+			ALTER TABLE orders DROP COLUMN IF EXISTS park_reason;
+			ALTER TABLE orders DROP COLUMN IF EXISTS parked_at;
+			ALTER TABLE orders DROP COLUMN IF EXISTS attempts;
+			ALTER TABLE orders DROP COLUMN IF EXISTS next_poll_at;
+			`,
+		},
+		{
+			Sequence: 5,
+			Name:     "accrual_failures",
+			UpSQL: `
+			CREATE TABLE IF NOT EXISTS accrual_failures (
+				id BIGSERIAL PRIMARY KEY,
+				order_id INTEGER NOT NULL REFERENCES orders(id),
+				http_status INTEGER,
+				response_body TEXT,
+				error TEXT NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_accrual_failures_order_id
+			ON accrual_failures (order_id, id);
+
+			CREATE INDEX IF NOT EXISTS idx_orders_parked_at
+			ON orders (parked_at)
+			WHERE parked_at IS NOT NULL;
+			`,
+			DownSQL: `
+			DROP INDEX IF EXISTS idx_orders_parked_at;
+			DROP INDEX IF EXISTS idx_accrual_failures_order_id;
+
+			DROP TABLE IF EXISTS accrual_failures;
+			`,
+		},
+		{
+			Sequence: 6,
+			Name:     "sessions",
+			UpSQL: `
+			ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+
+			CREATE TABLE IF NOT EXISTS refresh_tokens (
+				id BIGSERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id),
+				family_id VARCHAR(64) NOT NULL,
+				token_hash VARCHAR(64) UNIQUE NOT NULL,
+				expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+				used_at TIMESTAMP WITH TIME ZONE,
+				revoked_at TIMESTAMP WITH TIME ZONE
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id
+			ON refresh_tokens (family_id);
+
+			CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id
+			ON refresh_tokens (user_id)
+			WHERE revoked_at IS NULL;
+
+			CREATE TABLE IF NOT EXISTS revoked_tokens (
+				jti VARCHAR(64) PRIMARY KEY,
+				expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+			);
+			`,
+			DownSQL: `
+			DROP TABLE IF EXISTS revoked_tokens;
+
+			DROP INDEX IF EXISTS idx_refresh_tokens_user_id;
+			DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+			DROP TABLE IF EXISTS refresh_tokens;
+
+			ALTER TABLE users DROP COLUMN IF EXISTS token_version;
+			`,
+		},
+		{
+			Sequence: 7,
+			Name:     "login_throttle",
+			UpSQL: `
+			CREATE TABLE IF NOT EXISTS login_throttle (
+				scope VARCHAR(16) NOT NULL,
+				subject VARCHAR(255) NOT NULL,
+				failures INTEGER NOT NULL DEFAULT 0,
+				window_start TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+				lockouts INTEGER NOT NULL DEFAULT 0,
+				locked_until TIMESTAMP WITH TIME ZONE,
+				PRIMARY KEY (scope, subject)
+			);
+
+			CREATE TABLE IF NOT EXISTS login_lockouts (
+				id BIGSERIAL PRIMARY KEY,
+				scope VARCHAR(16) NOT NULL,
+				subject VARCHAR(255) NOT NULL,
+				failures INTEGER NOT NULL,
+				locked_until TIMESTAMP WITH TIME ZONE NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+				unlocked_at TIMESTAMP WITH TIME ZONE
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_login_lockouts_subject
+			ON login_lockouts (scope, subject)
+			WHERE unlocked_at IS NULL;
+			`,
+			DownSQL: `
+			DROP INDEX IF EXISTS idx_login_lockouts_subject;
+			DROP TABLE IF EXISTS login_lockouts;
+			DROP TABLE IF EXISTS login_throttle;
+			`,
+		},
+		{
+			Sequence: 8,
+			Name:     "api_keys",
+			UpSQL: `
+			CREATE TABLE IF NOT EXISTS api_keys (
+				id SERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id),
+				name VARCHAR(64) NOT NULL,
+				prefix VARCHAR(16) NOT NULL,
+				key_hash VARCHAR(64) UNIQUE NOT NULL,
+				scopes TEXT[] NOT NULL,
+				expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				last_used_at TIMESTAMP WITH TIME ZONE,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_api_keys_user_id
+			ON api_keys (user_id);
+			`,
+			DownSQL: `
+			DROP INDEX IF EXISTS idx_api_keys_user_id;
+			DROP TABLE IF EXISTS api_keys;
+			`,
+		},
+		{
+			Sequence: 9,
+			Name:     "roles_admin_audit",
+			UpSQL: `
+			ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS roles TEXT[] NOT NULL DEFAULT '{}';
+
+			INSERT INTO ledger_accounts (code) VALUES ('system:adjustments')
+			ON CONFLICT (code) DO NOTHING;
+
+			CREATE TABLE IF NOT EXISTS admin_audit (
+				id BIGSERIAL PRIMARY KEY,
+				actor_id INTEGER NOT NULL REFERENCES users(id),
+				action VARCHAR(64) NOT NULL,
+				target_user_id INTEGER NOT NULL REFERENCES users(id),
+				amount BIGINT NOT NULL DEFAULT 0, -- stored in kopeks, signed
+				reason TEXT NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+			);
+
+			CREATE INDEX IF NOT EXISTS idx_admin_audit_target_user_id
+			ON admin_audit (target_user_id, id);
+			`,
+			DownSQL: `
+			DROP INDEX IF EXISTS idx_admin_audit_target_user_id;
+			DROP TABLE IF EXISTS admin_audit;
+
+			-- счёт с проводками остаётся: ledger_entries append-only
+			DELETE FROM ledger_accounts a
+			WHERE a.code = 'system:adjustments'
+				AND NOT EXISTS (
+					SELECT 1 FROM ledger_entries e WHERE e.account_id = a.id
+				);
+
+			ALTER TABLE users DROP COLUMN IF EXISTS roles;
+			`,
+		},
+		{
+			Sequence: 10,
+			Name:     "user_status",
+			UpSQL: `
+			ALTER TABLE users
+			ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active'
+				CONSTRAINT users_status_valid
+				CHECK (status IN ('active', 'suspended', 'closed'));
+
+			INSERT INTO ledger_accounts (code) VALUES
+				('system:forfeited'),
+				('system:settlements')
+			ON CONFLICT (code) DO NOTHING;
+			`,
+			DownSQL: `
+			-- счета с проводками остаются: ledger_entries append-only
+			DELETE FROM ledger_accounts a
+			WHERE a.code IN ('system:forfeited', 'system:settlements')
+				AND NOT EXISTS (
+					SELECT 1 FROM ledger_entries e WHERE e.account_id = a.id
+				);
+
+			ALTER TABLE users DROP COLUMN IF EXISTS status;
+			`,
+		},
+		{
+			Sequence: 11,
+			Name:     "mfa",
+			UpSQL: `
+			CREATE TABLE IF NOT EXISTS user_mfa (
+				user_id INTEGER PRIMARY KEY REFERENCES users(id),
+				secret VARCHAR(64) NOT NULL,
+				last_step BIGINT NOT NULL DEFAULT 0,
+				withdraw_required BOOLEAN NOT NULL DEFAULT FALSE,
+				enabled_at TIMESTAMP WITH TIME ZONE,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+			);
+
+			CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+				id SERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id),
+				code_hash VARCHAR(64) NOT NULL,
+				used_at TIMESTAMP WITH TIME ZONE,
+				UNIQUE (user_id, code_hash)
+			);
+
+			CREATE TABLE IF NOT EXISTS mfa_challenges (
+				id SERIAL PRIMARY KEY,
+				user_id INTEGER NOT NULL REFERENCES users(id),
+				token_hash VARCHAR(64) UNIQUE NOT NULL,
+				attempts INTEGER NOT NULL DEFAULT 0,
+				expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+			);
+			`,
+			DownSQL: `
+			DROP TABLE IF EXISTS mfa_challenges;
+			DROP TABLE IF EXISTS mfa_recovery_codes;
+			DROP TABLE IF EXISTS user_mfa;
+			`,
+		},
+		{
+			Sequence: 12,
+			Name:     "order_requeued_at",
+			UpSQL: `
+			-- возраст вернувшегося в очередь заказа считается от requeued_at
+			ALTER TABLE orders
+			ADD COLUMN IF NOT EXISTS requeued_at TIMESTAMP WITH TIME ZONE;
+			`,
+			DownSQL: `
+			ALTER TABLE orders DROP COLUMN IF EXISTS requeued_at;
+			`,
+		},
+	}
 }
@@ -22,6 +22,9 @@ func (r *OrdersRepo) GetOrdersByUserID(
 	ctx context.Context,
 	userID int,
 ) ([]model.Order, error) {
+	ctx, span := startSpan(ctx, "OrdersRepo.GetOrdersByUserID")
+	defer span.End()
+
 	q := `
 		SELECT id, number, status, accrual, uploaded_at
 		FROM orders
@@ -71,16 +74,46 @@ func (r *OrdersRepo) AddOrder(
 	ctx context.Context,
 	order *model.Order,
 ) error {
+	ctx, span := startSpan(ctx, "OrdersRepo.AddOrder")
+	defer span.End()
+
+	// NOTIFY доставляется при коммите, поэтому коллектор увидит заказ
 	q := `
-		INSERT INTO orders (user_id, number, status, accrual)
-		VALUES (@userID, @orderNumber, @orderStatus, @accrual)
+		WITH ins AS (
+			INSERT INTO orders (
+				user_id,
+				number,
+				status,
+				accrual,
+				goods,
+				registration
+			)
+			VALUES (
+				@userID,
+				@orderNumber,
+				@orderStatus,
+				@accrual,
+				@goods,
+				@registration
+			)
+			RETURNING number
+		)
+		SELECT pg_notify(@channel, number) FROM ins
 	`
 
+	var goods any
+	if len(order.Goods) > 0 {
+		goods = order.Goods
+	}
+
 	args := pgx.NamedArgs{
-		"userID":      order.UserID,
-		"orderNumber": order.Number,
-		"orderStatus": order.Status,
-		"accrual":     order.Accrual,
+		"userID":       order.UserID,
+		"orderNumber":  order.Number,
+		"orderStatus":  order.Status,
+		"accrual":      order.Accrual,
+		"goods":        goods,
+		"registration": order.Registration,
+		"channel":      newOrdersChannel,
 	}
 	if _, err := r.db.Exec(ctx, q, args); err != nil {
 		var pgErr *pgconn.PgError
@@ -0,0 +1,124 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fragpit/gophermart/internal/model"
+)
+
+var _ model.ParkedOrdersRepository = (*ParkedOrdersRepo)(nil)
+
+type ParkedOrdersRepo struct {
+	baseRepo
+}
+
+func (r *ParkedOrdersRepo) GetParkedOrders(
+	ctx context.Context,
+) ([]model.ParkedOrder, error) {
+	ctx, span := startSpan(ctx, "ParkedOrdersRepo.GetParkedOrders")
+	defer span.End()
+
+	q := `
+		SELECT
+			o.id,
+			o.user_id,
+			o.number,
+			o.status,
+			o.attempts,
+			o.parked_at,
+			COALESCE(o.park_reason, ''),
+			(SELECT COUNT(*) FROM accrual_failures f WHERE f.order_id = o.id),
+			COALESCE((
+				SELECT f.error FROM accrual_failures f
+				WHERE f.order_id = o.id
+				ORDER BY f.id DESC
+				LIMIT 1
+			), '')
+		FROM orders o
+		WHERE o.parked_at IS NOT NULL
+		ORDER BY o.parked_at, o.id
+	`
+
+	rows, err := r.db.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("parked orders query error: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []model.ParkedOrder
+	for rows.Next() {
+		var o model.ParkedOrder
+		if err := rows.Scan(
+			&o.ID,
+			&o.UserID,
+			&o.Number,
+			&o.Status,
+			&o.Attempts,
+			&o.ParkedAt,
+			&o.ParkReason,
+			&o.Failures,
+			&o.LastError,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (r *ParkedOrdersRepo) RequeueOrder(
+	ctx context.Context,
+	number string,
+) error {
+	ctx, span := startSpan(ctx, "ParkedOrdersRepo.RequeueOrder")
+	defer span.End()
+
+	q := `
+		UPDATE orders
+		SET parked_at = NULL,
+			park_reason = NULL,
+			attempts = 0,
+			last_polled_at = NULL,
+			next_poll_at = NOW(),
+			requeued_at = NOW()
+		WHERE number = $1 AND parked_at IS NOT NULL
+	`
+
+	tag, err := r.db.Exec(ctx, q, number)
+	if err != nil {
+		return fmt.Errorf("failed to requeue order: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrOrderNotParked
+	}
+
+	return nil
+}
+
+func (r *ParkedOrdersRepo) RequeueAll(ctx context.Context) (int64, error) {
+	ctx, span := startSpan(ctx, "ParkedOrdersRepo.RequeueAll")
+	defer span.End()
+
+	q := `
+		UPDATE orders
+		SET parked_at = NULL,
+			park_reason = NULL,
+			attempts = 0,
+			last_polled_at = NULL,
+			next_poll_at = NOW(),
+			requeued_at = NOW()
+		WHERE parked_at IS NOT NULL
+	`
+
+	tag, err := r.db.Exec(ctx, q)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue orders: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,51 @@
+//go:build integration
+
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParkedOrders_Integration_RequeuedOrderNotReparkedByAge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	ctx := context.Background()
+
+	repos := newTestStorage(t)
+	db := repos.Parked.(*ParkedOrdersRepo).db
+
+	var userID int
+	err := db.QueryRow(
+		ctx,
+		`INSERT INTO users (login, password_hash) VALUES ('u', 'h') RETURNING id`,
+	).Scan(&userID)
+	require.NoError(t, err)
+
+	// оба заказа старше MaxAge и отложены по возрасту
+	_, err = db.Exec(ctx, `
+		INSERT INTO orders (user_id, number, uploaded_at, parked_at, park_reason)
+		VALUES
+			($1, '79927398713', NOW() - INTERVAL '30 days', NOW(), 'max age reached'),
+			($1, '12345678903', NOW() - INTERVAL '30 days', NULL, NULL)
+	`, userID)
+	require.NoError(t, err)
+
+	require.NoError(t, repos.Parked.RequeueOrder(ctx, "79927398713"))
+
+	parked, err := repos.Collector.ParkStaleOrders(ctx, 0, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), parked)
+
+	orders, err := repos.Parked.GetParkedOrders(ctx)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, "12345678903", orders[0].Number)
+	assert.Equal(t, "max age reached", orders[0].ParkReason)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 type baseRepo struct {
@@ -22,10 +23,20 @@ type baseRepo struct {
 type Repositories struct {
 	Health      healthcheck.HealthRepository
 	Users       model.UsersRepository
+	Sessions    model.SessionsRepository
+	Throttle    model.LoginThrottleRepository
+	APIKeys     model.APIKeysRepository
+	MFA         model.MFARepository
+	Admin       model.AdminRepository
 	Orders      model.OrdersRepository
 	Balance     model.BalanceRepository
 	Withdrawals model.WithdrawalsRepository
+	Ledger      model.LedgerRepository
+	Parked      model.ParkedOrdersRepository
 	Collector   collector.CollectorRepository
+	Leader      collector.LeaderLock
+	Listener    collector.OrdersListener
+	PoolStats   prometheus.Collector
 }
 
 func NewStorage(ctx context.Context, dbDSN string) (*Repositories, error) {
@@ -66,10 +77,20 @@ func NewStorage(ctx context.Context, dbDSN string) (*Repositories, error) {
 	repos := &Repositories{
 		Health:      &HealthRepo{baseRepo: b},
 		Users:       &UsersRepo{baseRepo: b},
+		Sessions:    &SessionsRepo{baseRepo: b},
+		Throttle:    &LoginThrottleRepo{baseRepo: b},
+		APIKeys:     &APIKeysRepo{baseRepo: b},
+		MFA:         &MFARepo{baseRepo: b},
+		Admin:       &AdminRepo{baseRepo: b},
 		Orders:      &OrdersRepo{baseRepo: b},
 		Balance:     &BalanceRepo{baseRepo: b},
 		Withdrawals: &WithdrawalsRepo{baseRepo: b},
+		Ledger:      &LedgerRepo{baseRepo: b},
+		Parked:      &ParkedOrdersRepo{baseRepo: b},
 		Collector:   &CollectorRepo{baseRepo: b},
+		Leader:      &LeaderLockRepo{baseRepo: b},
+		Listener:    &OrdersListenerRepo{baseRepo: b},
+		PoolStats:   newPoolStatsCollector(db),
 	}
 	return repos, nil
 }
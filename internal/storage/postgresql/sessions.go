@@ -0,0 +1,287 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ model.SessionsRepository = (*SessionsRepo)(nil)
+
+type SessionsRepo struct {
+	baseRepo
+}
+
+func (r *SessionsRepo) CreateRefreshToken(
+	ctx context.Context,
+	t *model.RefreshToken,
+) error {
+	ctx, span := startSpan(ctx, "SessionsRepo.CreateRefreshToken")
+	defer span.End()
+
+	q := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES (@user_id, @family_id, @token_hash, @expires_at)
+		RETURNING id
+	`
+
+	args := pgx.NamedArgs{
+		"user_id":    t.UserID,
+		"family_id":  t.FamilyID,
+		"token_hash": t.TokenHash,
+		"expires_at": t.ExpiresAt,
+	}
+
+	if err := r.db.QueryRow(ctx, q, args).Scan(&t.ID); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SessionsRepo) RotateRefreshToken(
+	ctx context.Context,
+	oldHash string,
+	next *model.RefreshToken,
+) (*model.User, error) {
+	ctx, span := startSpan(ctx, "SessionsRepo.RotateRefreshToken")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qSelect := `
+		SELECT rt.id, rt.family_id, rt.expires_at, rt.used_at, rt.revoked_at,
+			u.id, u.login, u.token_version, u.roles
+		FROM refresh_tokens rt
+		JOIN users u ON u.id = rt.user_id
+		WHERE rt.token_hash = $1
+		FOR UPDATE OF rt
+	`
+
+	var (
+		id        int
+		familyID  string
+		expiresAt time.Time
+		usedAt    *time.Time
+		revokedAt *time.Time
+		u         model.User
+	)
+	err = tx.QueryRow(ctx, qSelect, oldHash).Scan(
+		&id,
+		&familyID,
+		&expiresAt,
+		&usedAt,
+		&revokedAt,
+		&u.ID,
+		&u.Login,
+		&u.TokenVersion,
+		&u.Roles,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if revokedAt != nil || !expiresAt.After(time.Now()) {
+		return nil, model.ErrInvalidRefreshToken
+	}
+
+	if usedAt != nil {
+		// токен уже обменян: его украли или клиент повторил запрос,
+		// в обоих случаях сессию закрываем
+		if err := revokeFamily(ctx, tx, familyID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to commit tx: %w", err)
+		}
+		return nil, model.ErrRefreshTokenReused
+	}
+
+	qUse := `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`
+	if _, err := tx.Exec(ctx, qUse, id); err != nil {
+		return nil, fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+
+	next.UserID = u.ID
+	next.FamilyID = familyID
+
+	qInsert := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES (@user_id, @family_id, @token_hash, @expires_at)
+		RETURNING id
+	`
+	args := pgx.NamedArgs{
+		"user_id":    next.UserID,
+		"family_id":  next.FamilyID,
+		"token_hash": next.TokenHash,
+		"expires_at": next.ExpiresAt,
+	}
+	if err := tx.QueryRow(ctx, qInsert, args).Scan(&next.ID); err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return &u, nil
+}
+
+func (r *SessionsRepo) RevokeRefreshToken(
+	ctx context.Context,
+	userID int,
+	hash string,
+) error {
+	ctx, span := startSpan(ctx, "SessionsRepo.RevokeRefreshToken")
+	defer span.End()
+
+	q := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE revoked_at IS NULL
+			AND family_id = (
+				SELECT family_id
+				FROM refresh_tokens
+				WHERE token_hash = $1 AND user_id = $2
+			)
+	`
+
+	if _, err := r.db.Exec(ctx, q, hash, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SessionsRepo) RevokeAccessToken(
+	ctx context.Context,
+	jti string,
+	expiresAt time.Time,
+) error {
+	ctx, span := startSpan(ctx, "SessionsRepo.RevokeAccessToken")
+	defer span.End()
+
+	// заодно чистим записи об истёкших токенах: они уже не пройдут
+	// проверку срока действия
+	qCleanup := `DELETE FROM revoked_tokens WHERE expires_at < NOW()`
+	if _, err := r.db.Exec(ctx, qCleanup); err != nil {
+		return fmt.Errorf("failed to cleanup revoked tokens: %w", err)
+	}
+
+	q := `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO NOTHING
+	`
+	if _, err := r.db.Exec(ctx, q, jti, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SessionsRepo) RevokeUserSessions(
+	ctx context.Context,
+	userID int,
+) error {
+	ctx, span := startSpan(ctx, "SessionsRepo.RevokeUserSessions")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := revokeUserSessions(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err := deleteUserAPIKeys(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+	return nil
+}
+
+func (r *SessionsRepo) IsAccessTokenValid(
+	ctx context.Context,
+	userID int,
+	jti string,
+	version int,
+) (bool, model.UserStatus, error) {
+	ctx, span := startSpan(ctx, "SessionsRepo.IsAccessTokenValid")
+	defer span.End()
+
+	q := `
+		SELECT u.token_version = $2
+			AND NOT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $3),
+			u.status
+		FROM users u
+		WHERE u.id = $1
+	`
+
+	var (
+		valid  bool
+		status model.UserStatus
+	)
+	err := r.db.QueryRow(ctx, q, userID, version, jti).Scan(&valid, &status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check access token: %w", err)
+	}
+
+	return valid, status, nil
+}
+
+func revokeFamily(ctx context.Context, tx pgx.Tx, familyID string) error {
+	q := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`
+	if _, err := tx.Exec(ctx, q, familyID); err != nil {
+		return fmt.Errorf("failed to revoke token family: %w", err)
+	}
+	return nil
+}
+
+// revokeUserSessions делает недействительными все выданные пользователю
+// токены: access - через версию, refresh - отзывом.
+func revokeUserSessions(ctx context.Context, tx pgx.Tx, userID int) error {
+	qVersion := `
+		UPDATE users
+		SET token_version = token_version + 1
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(ctx, qVersion, userID); err != nil {
+		return fmt.Errorf("failed to bump token version: %w", err)
+	}
+
+	qRevoke := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+	if _, err := tx.Exec(ctx, qRevoke, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fragpit/gophermart/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+var _ model.LoginThrottleRepository = (*LoginThrottleRepo)(nil)
+
+type LoginThrottleRepo struct {
+	baseRepo
+}
+
+func (r *LoginThrottleRepo) GetLockedUntil(
+	ctx context.Context,
+	keys []model.LoginThrottleKey,
+) (time.Time, error) {
+	ctx, span := startSpan(ctx, "LoginThrottleRepo.GetLockedUntil")
+	defer span.End()
+
+	scopes := make([]string, 0, len(keys))
+	subjects := make([]string, 0, len(keys))
+	for _, k := range keys {
+		scopes = append(scopes, k.Scope)
+		subjects = append(subjects, k.Subject)
+	}
+
+	q := `
+		SELECT MAX(t.locked_until)
+		FROM login_throttle t
+		JOIN unnest($1::text[], $2::text[]) AS k(scope, subject)
+			ON t.scope = k.scope AND t.subject = k.subject
+		WHERE t.locked_until > NOW()
+	`
+
+	var until *time.Time
+	if err := r.db.QueryRow(ctx, q, scopes, subjects).Scan(&until); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get login lockout: %w", err)
+	}
+	if until == nil {
+		return time.Time{}, nil
+	}
+
+	return *until, nil
+}
+
+func (r *LoginThrottleRepo) RecordLoginFailure(
+	ctx context.Context,
+	key model.LoginThrottleKey,
+	window time.Duration,
+) (*model.LoginFailures, error) {
+	ctx, span := startSpan(ctx, "LoginThrottleRepo.RecordLoginFailure")
+	defer span.End()
+
+	// заодно чистим записи, которые уже ничего не значат: счётчик и число
+	// блокировок подряд у них всё равно начнутся заново
+	qCleanup := `
+		DELETE FROM login_throttle
+		WHERE window_start < NOW() - $1::float8 * INTERVAL '1 second'
+			AND COALESCE(locked_until, '-infinity')
+				< NOW() - $1::float8 * INTERVAL '1 second'
+	`
+	if _, err := r.db.Exec(ctx, qCleanup, window.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to cleanup login throttle: %w", err)
+	}
+
+	// счётчик старше окна начинается заново; число блокировок подряд
+	// забывается, если после последней блокировки прошло больше окна
+	q := `
+		INSERT INTO login_throttle (scope, subject, failures, window_start)
+		VALUES (@scope, @subject, 1, NOW())
+		ON CONFLICT (scope, subject) DO UPDATE
+		SET failures = CASE
+				WHEN login_throttle.window_start < NOW() - @window::float8 * INTERVAL '1 second'
+					THEN 1
+				ELSE login_throttle.failures + 1
+			END,
+			lockouts = CASE
+				WHEN login_throttle.window_start < NOW() - @window::float8 * INTERVAL '1 second'
+					AND COALESCE(login_throttle.locked_until, '-infinity')
+						< NOW() - @window::float8 * INTERVAL '1 second'
+					THEN 0
+				ELSE login_throttle.lockouts
+			END,
+			window_start = CASE
+				WHEN login_throttle.window_start < NOW() - @window::float8 * INTERVAL '1 second'
+					THEN NOW()
+				ELSE login_throttle.window_start
+			END
+		RETURNING failures, lockouts, locked_until
+	`
+
+	args := pgx.NamedArgs{
+		"scope":   key.Scope,
+		"subject": key.Subject,
+		"window":  window.Seconds(),
+	}
+
+	f := &model.LoginFailures{LoginThrottleKey: key}
+	if err := r.db.QueryRow(ctx, q, args).Scan(
+		&f.Failures,
+		&f.Lockouts,
+		&f.LockedUntil,
+	); err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+
+	return f, nil
+}
+
+func (r *LoginThrottleRepo) LockLogin(
+	ctx context.Context,
+	l *model.LoginLockout,
+) error {
+	ctx, span := startSpan(ctx, "LoginThrottleRepo.LockLogin")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qLock := `
+		UPDATE login_throttle
+		SET locked_until = @locked_until,
+			lockouts = lockouts + 1,
+			failures = 0,
+			window_start = NOW()
+		WHERE scope = @scope AND subject = @subject
+	`
+	args := pgx.NamedArgs{
+		"scope":        l.Scope,
+		"subject":      l.Subject,
+		"failures":     l.Failures,
+		"locked_until": l.LockedUntil,
+	}
+	if _, err := tx.Exec(ctx, qLock, args); err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+
+	qEvent := `
+		INSERT INTO login_lockouts (scope, subject, failures, locked_until)
+		VALUES (@scope, @subject, @failures, @locked_until)
+		RETURNING id, created_at
+	`
+	if err := tx.QueryRow(ctx, qEvent, args).Scan(
+		&l.ID,
+		&l.CreatedAt,
+	); err != nil {
+		return fmt.Errorf("failed to record lockout: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LoginThrottleRepo) ResetLoginFailures(
+	ctx context.Context,
+	key model.LoginThrottleKey,
+) error {
+	ctx, span := startSpan(ctx, "LoginThrottleRepo.ResetLoginFailures")
+	defer span.End()
+
+	q := `DELETE FROM login_throttle WHERE scope = $1 AND subject = $2`
+	if _, err := r.db.Exec(ctx, q, key.Scope, key.Subject); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LoginThrottleRepo) GetLockouts(
+	ctx context.Context,
+	activeOnly bool,
+) ([]model.LoginLockout, error) {
+	ctx, span := startSpan(ctx, "LoginThrottleRepo.GetLockouts")
+	defer span.End()
+
+	q := `
+		SELECT id, scope, subject, failures, locked_until, created_at, unlocked_at
+		FROM login_lockouts
+		WHERE NOT $1::bool OR (unlocked_at IS NULL AND locked_until > NOW())
+		ORDER BY id DESC
+	`
+
+	rows, err := r.db.Query(ctx, q, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("lockouts query error: %w", err)
+	}
+	defer rows.Close()
+
+	var lockouts []model.LoginLockout
+	for rows.Next() {
+		var l model.LoginLockout
+		if err := rows.Scan(
+			&l.ID,
+			&l.Scope,
+			&l.Subject,
+			&l.Failures,
+			&l.LockedUntil,
+			&l.CreatedAt,
+			&l.UnlockedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		lockouts = append(lockouts, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return lockouts, nil
+}
+
+func (r *LoginThrottleRepo) UnlockLogin(
+	ctx context.Context,
+	key model.LoginThrottleKey,
+) (bool, error) {
+	ctx, span := startSpan(ctx, "LoginThrottleRepo.UnlockLogin")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	qEvents := `
+		UPDATE login_lockouts
+		SET unlocked_at = NOW()
+		WHERE scope = $1 AND subject = $2
+			AND unlocked_at IS NULL
+			AND locked_until > NOW()
+	`
+	tag, err := tx.Exec(ctx, qEvents, key.Scope, key.Subject)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock login: %w", err)
+	}
+
+	qReset := `DELETE FROM login_throttle WHERE scope = $1 AND subject = $2`
+	if _, err := tx.Exec(ctx, qReset, key.Scope, key.Subject); err != nil {
+		return false, fmt.Errorf("failed to reset login failures: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
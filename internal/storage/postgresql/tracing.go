@@ -0,0 +1,23 @@
+package postgresql
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer(
+	"github.com/fragpit/gophermart/internal/storage/postgresql",
+)
+
+// startSpan открывает спан метода репозитория.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(
+		ctx,
+		name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL),
+	)
+}
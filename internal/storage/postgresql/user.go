@@ -2,10 +2,13 @@ package postgresql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/fragpit/gophermart/internal/model"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var _ model.UsersRepository = (*UsersRepo)(nil)
@@ -18,6 +21,9 @@ func (r *UsersRepo) Create(
 	ctx context.Context,
 	user *model.User,
 ) (*model.User, error) {
+	ctx, span := startSpan(ctx, "UsersRepo.Create")
+	defer span.End()
+
 	q := `
 		INSERT INTO users (login, password_hash)
 		VALUES (@login, @password_hash)
@@ -32,6 +38,12 @@ func (r *UsersRepo) Create(
 	var id int32
 	row := r.db.QueryRow(ctx, q, args)
 	if err := row.Scan(&id); err != nil {
+		// уникальность логина проверяет только ограничение в БД: проверка
+		// перед вставкой не защищает от одновременной регистрации
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, model.ErrUserExists
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 	user.ID = int(id)
@@ -43,27 +55,424 @@ func (r *UsersRepo) GetByLogin(
 	ctx context.Context,
 	login string,
 ) (*model.User, error) {
+	ctx, span := startSpan(ctx, "UsersRepo.GetByLogin")
+	defer span.End()
+
 	q := `
-		SELECT id, login, password_hash
+		SELECT id, login, password_hash, token_version, roles, status
 		FROM users
 		WHERE login = $1
 	`
 
-	var (
-		userID    int
-		userLogin string
-		userPHash string
-	)
-	row := r.db.QueryRow(ctx, q, login)
-	if err := row.Scan(&userID, &userLogin, &userPHash); err != nil {
-		return nil, fmt.Errorf("failed to get user by login: %w", err)
+	return scanUser(r.db.QueryRow(ctx, q, login))
+}
+
+func (r *UsersRepo) GetByID(ctx context.Context, id int) (*model.User, error) {
+	ctx, span := startSpan(ctx, "UsersRepo.GetByID")
+	defer span.End()
+
+	q := `
+		SELECT id, login, password_hash, token_version, roles, status
+		FROM users
+		WHERE id = $1
+	`
+
+	return scanUser(r.db.QueryRow(ctx, q, id))
+}
+
+func (r *UsersRepo) UpdatePasswordHash(
+	ctx context.Context,
+	userID int,
+	oldHash, hash string,
+) error {
+	ctx, span := startSpan(ctx, "UsersRepo.UpdatePasswordHash")
+	defer span.End()
+
+	q := `
+		UPDATE users SET password_hash = $1
+		WHERE id = $2 AND password_hash = $3
+	`
+	if _, err := r.db.Exec(ctx, q, hash, userID, oldHash); err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UsersRepo) ChangePassword(
+	ctx context.Context,
+	userID int,
+	hash string,
+) (*model.User, error) {
+	ctx, span := startSpan(ctx, "UsersRepo.ChangePassword")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := `UPDATE users SET password_hash = $1 WHERE id = $2`
+	tag, err := tx.Exec(ctx, q, hash, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update password hash: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, model.ErrUserNotFound
+	}
+
+	if err := revokeUserSessions(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := deleteUserAPIKeys(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	qUser := `
+		SELECT id, login, password_hash, token_version, roles, status
+		FROM users
+		WHERE id = $1
+	`
+	u, err := scanUser(tx.QueryRow(ctx, qUser, userID))
+	if err != nil {
+		return nil, err
 	}
 
-	u := &model.User{
-		ID:           userID,
-		Login:        userLogin,
-		PasswordHash: userPHash,
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
 	}
 
 	return u, nil
 }
+
+func (r *UsersRepo) SetRoles(
+	ctx context.Context,
+	login string,
+	roles []string,
+) error {
+	ctx, span := startSpan(ctx, "UsersRepo.SetRoles")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if roles == nil {
+		roles = []string{}
+	}
+
+	var userID int
+	q := `UPDATE users SET roles = $1 WHERE login = $2 RETURNING id`
+	err = tx.QueryRow(ctx, q, roles, login).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set roles: %w", err)
+	}
+
+	if err := revokeUserSessions(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UsersRepo) ExportData(
+	ctx context.Context,
+	userID int,
+) (*model.UserExport, error) {
+	ctx, span := startSpan(ctx, "UsersRepo.ExportData")
+	defer span.End()
+
+	// все выборки видят один снимок: заказ или списание, появившиеся во
+	// время выгрузки, не попадут в неё частично
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var export model.UserExport
+
+	qUser := `
+		SELECT id, login, roles, status, created_at
+		FROM users
+		WHERE id = $1
+	`
+	err = tx.QueryRow(ctx, qUser, userID).Scan(
+		&export.User.ID,
+		&export.User.Login,
+		&export.User.Roles,
+		&export.User.Status,
+		&export.User.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if export.Orders, err = exportOrders(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if export.Withdrawals, err = exportWithdrawals(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if export.Sessions, err = exportSessions(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+func (r *UsersRepo) Erase(ctx context.Context, userID int) error {
+	ctx, span := startSpan(ctx, "UsersRepo.Erase")
+	defer span.End()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var login string
+	qUser := `SELECT login FROM users WHERE id = $1 FOR UPDATE`
+	err = tx.QueryRow(ctx, qUser, userID).Scan(&login)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var remaining model.Kopek
+	qBalance := `
+		SELECT balance FROM ledger_accounts
+		WHERE user_id = $1
+		FOR UPDATE
+	`
+	err = tx.QueryRow(ctx, qBalance, userID).Scan(&remaining)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	// запись аудита, как при закрытии администратором: по ней
+	// прослеживается сгоревший остаток
+	qAudit := `
+		INSERT INTO admin_audit (actor_id, action, target_user_id, amount, reason)
+		VALUES (@actor_id, @action, @target_user_id, @amount, @reason)
+	`
+	args := pgx.NamedArgs{
+		"actor_id":       userID,
+		"action":         model.AuditUserErased,
+		"target_user_id": userID,
+		"amount":         -remaining,
+		"reason":         "account deleted by user",
+	}
+	if _, err := tx.Exec(ctx, qAudit, args); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+
+	if err := closeAccount(
+		ctx,
+		tx,
+		userID,
+		model.SettlementForfeit,
+		remaining,
+		fmt.Sprintf("erasure:%d", userID),
+	); err != nil {
+		return err
+	}
+
+	// пустой хеш не совпадает ни с одним паролем
+	qErase := `
+		UPDATE users
+		SET login = $2,
+			password_hash = '',
+			roles = '{}',
+			status = $3
+		WHERE id = $1
+	`
+	if _, err := tx.Exec(
+		ctx,
+		qErase,
+		userID,
+		model.ErasedLogin(userID),
+		model.UserStatusClosed,
+	); err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	qSessions := `DELETE FROM refresh_tokens WHERE user_id = $1`
+	if _, err := tx.Exec(ctx, qSessions, userID); err != nil {
+		return fmt.Errorf("failed to delete refresh tokens: %w", err)
+	}
+
+	if err := deleteUserMFA(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	// логин хранится и в ограничении перебора паролей и кодов
+	scopes := []string{model.ThrottleScopeLogin, model.ThrottleScopeMFA}
+	for _, table := range []string{"login_throttle", "login_lockouts"} {
+		q := `DELETE FROM ` + table + ` WHERE scope = ANY($1) AND subject = $2`
+		if _, err := tx.Exec(ctx, q, scopes, login); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func exportOrders(
+	ctx context.Context,
+	tx pgx.Tx,
+	userID int,
+) ([]model.Order, error) {
+	q := `
+		SELECT id, number, status, accrual, uploaded_at
+		FROM orders
+		WHERE user_id = $1
+		ORDER BY id
+	`
+
+	rows, err := tx.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("orders query error: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []model.Order
+	for rows.Next() {
+		o := model.Order{UserID: userID}
+		if err := rows.Scan(
+			&o.ID,
+			&o.Number,
+			&o.Status,
+			&o.Accrual,
+			&o.UploadedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return orders, nil
+}
+
+func exportWithdrawals(
+	ctx context.Context,
+	tx pgx.Tx,
+	userID int,
+) ([]model.Withdrawal, error) {
+	q := `
+		SELECT id, order_number, sum, processed_at
+		FROM withdrawals
+		WHERE user_id = $1
+		ORDER BY id
+	`
+
+	rows, err := tx.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("withdrawals query error: %w", err)
+	}
+	defer rows.Close()
+
+	var withdrawals []model.Withdrawal
+	for rows.Next() {
+		w := model.Withdrawal{UserID: userID}
+		if err := rows.Scan(
+			&w.ID,
+			&w.OrderNum,
+			&w.Sum,
+			&w.ProcessedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		withdrawals = append(withdrawals, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return withdrawals, nil
+}
+
+func exportSessions(
+	ctx context.Context,
+	tx pgx.Tx,
+	userID int,
+) ([]model.Session, error) {
+	q := `
+		SELECT family_id, created_at, expires_at, used_at, revoked_at
+		FROM refresh_tokens
+		WHERE user_id = $1
+		ORDER BY id
+	`
+
+	rows, err := tx.Query(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("sessions query error: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var s model.Session
+		if err := rows.Scan(
+			&s.FamilyID,
+			&s.CreatedAt,
+			&s.ExpiresAt,
+			&s.UsedAt,
+			&s.RevokedAt,
+		); err != nil {
+			return nil, fmt.Errorf("error reading values: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func scanUser(row pgx.Row) (*model.User, error) {
+	var u model.User
+	err := row.Scan(
+		&u.ID,
+		&u.Login,
+		&u.PasswordHash,
+		&u.TokenVersion,
+		&u.Roles,
+		&u.Status,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, model.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &u, nil
+}
@@ -18,6 +18,9 @@ func (r *WithdrawalsRepo) GetWithdrawalsByUserID(
 	ctx context.Context,
 	userID int,
 ) ([]model.Withdrawal, error) {
+	ctx, span := startSpan(ctx, "WithdrawalsRepo.GetWithdrawalsByUserID")
+	defer span.End()
+
 	q := `
 		SELECT id, order_number, sum, processed_at
 		FROM withdrawals
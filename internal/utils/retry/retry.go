@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Operation func(ctx context.Context) error
@@ -53,8 +56,14 @@ func (r *Retrier) Do(ctx context.Context, op Operation) error {
 	}
 	lastErr = err
 
-	for _, t := range r.backoff {
+	span := trace.SpanFromContext(ctx)
+	for i, t := range r.backoff {
 		log.Printf("operation error, retrying in %v", t)
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("retry.attempt", i+1),
+			attribute.String("retry.delay", t.String()),
+			attribute.String("retry.error", lastErr.Error()),
+		))
 		time.Sleep(t)
 
 		err = op(ctx)